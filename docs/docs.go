@@ -58,24 +58,46 @@ const docTemplate = `{
                         "schema": {
                             "type": "boolean"
                         }
+                    },
+                    "402": {
+                        "description": "Payment Required",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "boolean"
+                        }
                     }
                 }
             }
         },
-        "/course": {
+        "/bundle": {
             "get": {
-                "description": "read courses",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "read bundles on sale, admins get the deactivated ones too with all=true",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Read courses",
-                "operationId": "course.read",
+                "summary": "Read bundles",
+                "operationId": "bundle.read",
                 "parameters": [
                     {
                         "type": "integer",
+                        "format": "int64",
                         "description": "offset",
                         "name": "offset",
                         "in": "query",
@@ -83,15 +105,16 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
+                        "format": "int64",
                         "description": "limit",
                         "name": "limit",
                         "in": "query",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "id",
-                        "name": "id",
+                        "type": "boolean",
+                        "description": "include deactivated bundles, admins only",
+                        "name": "all",
                         "in": "query"
                     }
                 ],
@@ -101,110 +124,48 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/Course"
+                                "$ref": "#/definitions/Bundle"
                             }
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "boolean"
                         }
                     }
                 }
             },
-            "put": {
-                "description": "update course",
+            "post": {
+                "description": "create a bundle of courses sold together for its own price",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Update course",
-                "operationId": "course.update",
+                "summary": "Create bundle",
+                "operationId": "bundle.create",
                 "parameters": [
                     {
-                        "description": "update course body",
+                        "description": "new bundle body",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/CourseUpdateBody"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "boolean"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "boolean"
+                            "$ref": "#/definitions/NewBundle"
                         }
                     }
-                }
-            },
-            "post": {
-                "description": "create course",
-                "consumes": [
-                    "multipart/form-data"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "summary": "Create course",
-                "operationId": "course.create",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "title",
-                        "name": "title",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "description",
-                        "name": "description",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "number",
-                        "description": "price",
-                        "name": "price",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "file",
-                        "description": "cover",
-                        "name": "cover",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "file",
-                        "description": "cover",
-                        "name": "attachments",
-                        "in": "formData",
-                        "required": true
-                    }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "boolean"
+                            "type": "string"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "422": {
+                        "description": "Unprocessable Entity",
                         "schema": {
                             "type": "boolean"
                         }
@@ -212,19 +173,16 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "delete course",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "take the bundle off sale, orders already made keep their access",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Delete course",
-                "operationId": "course.delete",
+                "summary": "Deactivate bundle",
+                "operationId": "bundle.delete",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "course id",
+                        "description": "id",
                         "name": "id",
                         "in": "query",
                         "required": true
@@ -237,8 +195,8 @@ const docTemplate = `{
                             "type": "boolean"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "422": {
+                        "description": "Unprocessable Entity",
                         "schema": {
                             "type": "boolean"
                         }
@@ -246,117 +204,85 @@ const docTemplate = `{
                 }
             }
         },
-        "/login": {
-            "post": {
-                "description": "login user",
-                "consumes": [
-                    "application/json"
-                ],
+        "/certificate": {
+            "get": {
+                "description": "read certificates of the current user",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Login a user",
-                "operationId": "login",
-                "parameters": [
-                    {
-                        "description": "login body",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handler.LoginRequest"
-                        }
-                    }
-                ],
+                "summary": "Read my certificates",
+                "operationId": "certificate.read",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handler.LoginResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/Certificate"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/handler.LoginResponse"
+                            "type": "boolean"
                         }
                     }
                 }
             }
         },
-        "/module": {
+        "/certificate/verify/{serial}": {
             "get": {
-                "description": "read modules",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "confirm that a certificate with the serial was issued, no login required",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Read modules",
-                "operationId": "module.read",
+                "summary": "Verify certificate",
+                "operationId": "certificate.verify",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "id",
-                        "name": "id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "course_id",
-                        "name": "course_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "offset",
-                        "name": "offset",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "limit",
-                        "name": "limit",
-                        "in": "query"
+                        "description": "certificate serial",
+                        "name": "serial",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/Module"
-                            }
+                            "$ref": "#/definitions/CertificateVerification"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "type": "boolean"
+                            "$ref": "#/definitions/CertificateVerification"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/checkout": {
             "post": {
-                "description": "update module",
+                "description": "create one order for several courses or a bundle and get the checkout URL of the payment provider",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Update module",
-                "operationId": "module.update",
+                "summary": "Checkout cart",
+                "operationId": "order.checkout",
                 "parameters": [
                     {
-                        "description": "update module body",
+                        "description": "cart body",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/ModuleUpdateBody"
+                            "$ref": "#/definitions/CheckoutRequest"
                         }
                     }
                 ],
@@ -364,71 +290,55 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "boolean"
+                            "$ref": "#/definitions/OrderResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "type": "boolean"
+                            "$ref": "#/definitions/OrderResponse"
                         }
-                    }
-                }
-            },
-            "delete": {
-                "description": "delete module",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "summary": "Delete module",
-                "operationId": "module.delete",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "module id",
-                        "name": "id",
-                        "in": "query",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "type": "boolean"
+                            "$ref": "#/definitions/OrderResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
-                            "type": "boolean"
+                            "$ref": "#/definitions/OrderResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/OrderResponse"
                         }
                     }
                 }
             }
         },
-        "/register": {
+        "/checkout/preview": {
             "post": {
-                "description": "register user",
+                "description": "price the cart for the current user without creating an order",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Register a user",
-                "operationId": "register",
+                "summary": "Preview cart",
+                "operationId": "order.checkout.preview",
                 "parameters": [
                     {
-                        "description": "register body",
+                        "description": "cart body",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handler.RegisterRequest"
+                            "$ref": "#/definitions/CheckoutRequest"
                         }
                     }
                 ],
@@ -436,32 +346,42 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handler.RegisterResponse"
+                            "$ref": "#/definitions/CartQuoteResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/handler.RegisterResponse"
+                            "$ref": "#/definitions/CartQuoteResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/CartQuoteResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/CartQuoteResponse"
                         }
                     }
                 }
             }
         },
-        "/user": {
+        "/code-batch": {
             "get": {
-                "description": "read users",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "read code batches with how many of their codes were redeemed",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Read users",
-                "operationId": "user.read",
+                "summary": "Read code batches",
+                "operationId": "gift.batch.read",
                 "parameters": [
                     {
                         "type": "integer",
+                        "format": "int64",
                         "description": "offset",
                         "name": "offset",
                         "in": "query",
@@ -469,6 +389,7 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
+                        "format": "int64",
                         "description": "limit",
                         "name": "limit",
                         "in": "query",
@@ -476,8 +397,8 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "id",
-                        "name": "id",
+                        "description": "course id",
+                        "name": "courseId",
                         "in": "query"
                     }
                 ],
@@ -487,48 +408,151 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/User"
+                                "$ref": "#/definitions/CodeBatch"
                             }
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "boolean"
                         }
                     }
                 }
             },
-            "put": {
-                "description": "update user",
+            "post": {
+                "description": "generate a batch of single use access codes for a course, for example for a corporate client",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Update user",
-                "operationId": "user.update",
+                "summary": "Create code batch",
+                "operationId": "gift.batch.create",
                 "parameters": [
                     {
-                        "description": "update user body",
+                        "description": "code batch body",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/UserUpdateBody"
+                            "$ref": "#/definitions/NewCodeBatch"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/CodeBatch"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "boolean"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/code-batch/codes": {
+            "get": {
+                "description": "read the codes of a batch with who redeemed them",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Read batch codes",
+                "operationId": "gift.batch.codes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "batch id",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "offset",
+                        "name": "offset",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "limit",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/AccessCode"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/coupon": {
+            "get": {
+                "description": "read coupons with the number of paid orders that used them",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Read coupons",
+                "operationId": "coupon.read",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "offset",
+                        "name": "offset",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "limit",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/Coupon"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "boolean"
                         }
@@ -536,23 +560,23 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "create user",
+                "description": "create a percent or fixed discount coupon for a course, or sitewide when course id is empty",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Create user",
-                "operationId": "user.create",
+                "summary": "Create coupon",
+                "operationId": "coupon.create",
                 "parameters": [
                     {
-                        "description": "new user body",
+                        "description": "new coupon body",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/NewUser"
+                            "$ref": "#/definitions/NewCoupon"
                         }
                     }
                 ],
@@ -563,8 +587,8 @@ const docTemplate = `{
                             "type": "boolean"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "422": {
+                        "description": "Unprocessable Entity",
                         "schema": {
                             "type": "boolean"
                         }
@@ -572,22 +596,159 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "delete user",
+                "description": "deactivate coupon, it can no longer be applied",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Deactivate coupon",
+                "operationId": "coupon.delete",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "id",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/coupon/preview": {
+            "post": {
+                "description": "price the course for the current user with the coupon applied",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Delete user",
-                "operationId": "user.delete",
+                "summary": "Preview discounted price",
+                "operationId": "coupon.preview",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "user id",
-                        "name": "id",
+                        "description": "preview body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/CouponPreviewRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Quote"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/course": {
+            "get": {
+                "description": "read courses",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Read courses",
+                "operationId": "course.read",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "offset",
+                        "name": "offset",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "limit",
+                        "name": "limit",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "id",
+                        "name": "id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/Course"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "update course",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Update course",
+                "operationId": "course.update",
+                "parameters": [
+                    {
+                        "description": "update course body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/CourseUpdateBody"
+                        }
                     }
                 ],
                 "responses": {
@@ -604,225 +765,3286 @@ const docTemplate = `{
                         }
                     }
                 }
+            },
+            "post": {
+                "description": "create course",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Create course",
+                "operationId": "course.create",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "title",
+                        "name": "title",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "description",
+                        "name": "description",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "price",
+                        "name": "price",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "currency, KZT by default",
+                        "name": "currency",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "file",
+                        "description": "cover",
+                        "name": "cover",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "cover",
+                        "name": "attachments",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "delete course",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Delete course",
+                "operationId": "course.delete",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "course id",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/course/{id}/attachments/{n}": {
+            "get": {
+                "description": "redirect to a short lived download URL of the nth attachment of the course, counting from zero, for enrolled users and admins",
+                "summary": "Download course attachment",
+                "operationId": "course.attachment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "course id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "attachment number",
+                        "name": "n",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Found"
+                    },
+                    "402": {
+                        "description": "Payment Required",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/enrollment": {
+            "get": {
+                "description": "read enrollments of a user or a course, including revoked ones unless active=true",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Read enrollments",
+                "operationId": "enrollment.read",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "offset",
+                        "name": "offset",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "limit",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "userId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "course id",
+                        "name": "courseId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only enrollments that are not revoked",
+                        "name": "active",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/Enrollment"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "enroll the current user in a course that costs nothing, paid courses are bought with an order",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Enroll in free course",
+                "operationId": "enrollment.create",
+                "parameters": [
+                    {
+                        "description": "enroll body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/EnrollRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "402": {
+                        "description": "Payment Required",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/enrollment/grant": {
+            "post": {
+                "description": "enroll every user in every course as an admin grant",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Enroll users",
+                "operationId": "enrollment.grant",
+                "parameters": [
+                    {
+                        "description": "bulk enrollment body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/BulkEnrollment"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/BulkEnrollmentResult"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/enrollment/revoke": {
+            "post": {
+                "description": "unenroll every user from every course whatever the enrollment came from, payments are not refunded",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Unenroll users",
+                "operationId": "enrollment.revoke",
+                "parameters": [
+                    {
+                        "description": "bulk enrollment body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/BulkEnrollment"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/BulkEnrollmentResult"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/login": {
+            "post": {
+                "description": "login user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Login a user",
+                "operationId": "login",
+                "parameters": [
+                    {
+                        "description": "login body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.LoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/logout": {
+            "post": {
+                "description": "revoke the session of the refresh token (body or refresh_token cookie)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Logout",
+                "operationId": "logout",
+                "parameters": [
+                    {
+                        "description": "logout body",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handler.RefreshRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/logout/all": {
+            "post": {
+                "description": "revoke every session of the current user",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Logout everywhere",
+                "operationId": "logout.all",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/courses": {
+            "get": {
+                "description": "read the paid courses of the current user with progress and the module to resume",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Read my courses",
+                "operationId": "me.courses",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/LearnerCourse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/gifts": {
+            "get": {
+                "description": "read the access codes of the gifts the current user bought, with whether they were redeemed",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Read my gifts",
+                "operationId": "gift.mine",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "offset",
+                        "name": "offset",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "limit",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/AccessCode"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/payments": {
+            "get": {
+                "description": "read the paid and refunded orders of the current user with their receipts",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Read my payments",
+                "operationId": "receipt.payments",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "offset",
+                        "name": "offset",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "limit",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/PaymentRecord"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/payments/receipt": {
+            "get": {
+                "description": "redirect to the receipt PDF of an order of the current user, admins can download any receipt",
+                "summary": "Download receipt",
+                "operationId": "receipt.download",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "order id",
+                        "name": "orderId",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Found"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/subscription": {
+            "get": {
+                "description": "get the latest subscription of the current user with its status",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Current subscription",
+                "operationId": "subscription.current",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Subscription"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/module": {
+            "get": {
+                "description": "read modules, the content of modules other than free previews is only returned to enrolled users and admins",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Read modules",
+                "operationId": "module.read",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "id",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "course_id",
+                        "name": "course_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "limit",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/Module"
+                            }
+                        }
+                    },
+                    "402": {
+                        "description": "Payment Required",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "update module",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Update module",
+                "operationId": "module.update",
+                "parameters": [
+                    {
+                        "description": "update module body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ModuleUpdateBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "delete module",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Delete module",
+                "operationId": "module.delete",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "module id",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/order": {
+            "post": {
+                "description": "start a purchase of the course by the current user and get the checkout URL of the payment provider",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Create order",
+                "operationId": "order.create",
+                "parameters": [
+                    {
+                        "description": "order body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/OrderCreateBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/OrderResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/OrderResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/OrderResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/OrderResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/OrderResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/order/gift": {
+            "post": {
+                "description": "buy the course as a gift, once paid an access code is issued that enrolls whoever redeems it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Create gift order",
+                "operationId": "order.gift",
+                "parameters": [
+                    {
+                        "description": "gift order body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/GiftOrderCreateBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/OrderResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/OrderResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/OrderResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/OrderResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/password/forgot": {
+            "post": {
+                "description": "send a single-use password reset link to the email, responds ok even if the email is unknown",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Request a password reset",
+                "operationId": "password.forgot",
+                "parameters": [
+                    {
+                        "description": "forgot password body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ForgotPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/password/reset": {
+            "post": {
+                "description": "set a new password with a reset token, revokes every session of the user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Reset password",
+                "operationId": "password.reset",
+                "parameters": [
+                    {
+                        "description": "reset password body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ResetPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/payment/receipt": {
+            "post": {
+                "description": "render the receipt of a paid order again keeping its number, orders without a receipt get one",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Regenerate receipt",
+                "operationId": "receipt.regenerate",
+                "parameters": [
+                    {
+                        "description": "regenerate body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ReceiptRegenerateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Receipt"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/payment/refund": {
+            "post": {
+                "description": "refund the whole or a part of a payment, a fully refunded payment no longer gives access to the course",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Refund payment",
+                "operationId": "payment.refund",
+                "parameters": [
+                    {
+                        "description": "refund body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/RefundRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Refund"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/payment/review": {
+            "get": {
+                "description": "read orders flagged by reconciliation because their status disagrees with the payment provider",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Read payment reviews",
+                "operationId": "payment.review.read",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "offset",
+                        "name": "offset",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "limit",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only reviews that are not resolved",
+                        "name": "open",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/PaymentReview"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/payment/review/resolve": {
+            "post": {
+                "description": "close the review of an order once it has been sorted out, the order is not reconciled again",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Resolve payment review",
+                "operationId": "payment.review.resolve",
+                "parameters": [
+                    {
+                        "description": "resolve body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ReviewResolveRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/plan": {
+            "get": {
+                "description": "read subscription plans on sale, admins get the deactivated ones too with all=true",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Read plans",
+                "operationId": "plan.read",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "offset",
+                        "name": "offset",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "limit",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "include deactivated plans, admins only",
+                        "name": "all",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/Plan"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "create a monthly or annual subscription plan that unlocks every course",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Create plan",
+                "operationId": "plan.create",
+                "parameters": [
+                    {
+                        "description": "new plan body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/NewPlan"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "take the plan off sale, subscribers keep access until their paid period ends",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Deactivate plan",
+                "operationId": "plan.delete",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "id",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/redeem": {
+            "post": {
+                "description": "enroll the current user in the course of a gift or batch access code, a code can be redeemed once",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Redeem access code",
+                "operationId": "gift.redeem",
+                "parameters": [
+                    {
+                        "description": "redeem body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/RedeemRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Redemption"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/register": {
+            "post": {
+                "description": "register user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Register a user",
+                "operationId": "register",
+                "parameters": [
+                    {
+                        "description": "register body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.RegisterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.RegisterResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.RegisterResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/revenue": {
+            "get": {
+                "description": "sum orders by day, week or month and currency, net of refunds; format=csv downloads the report as CSV",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "summary": "Revenue report",
+                "operationId": "report.revenue",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "first date, YYYY-MM-DD, 30 days before the last date by default",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "last date, YYYY-MM-DD, today by default",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "course id, only the part of orders paid for the course is counted",
+                        "name": "courseId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated payment statuses, succeeded and refunded by default",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "day, week or month",
+                        "name": "groupBy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json or csv",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/RevenueReport"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription": {
+            "post": {
+                "description": "create an order for a period of the plan, it renews the current subscription of the user to the same plan",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Subscribe",
+                "operationId": "subscription.create",
+                "parameters": [
+                    {
+                        "description": "subscribe body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/SubscribeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/OrderResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/OrderResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/OrderResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/OrderResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/OrderResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "cancel the subscription of the current user at the end of the paid period",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Cancel subscription",
+                "operationId": "subscription.cancel",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Subscription"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/token/refresh": {
+            "post": {
+                "description": "exchange a refresh token (body or refresh_token cookie) for a new token pair",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Refresh tokens",
+                "operationId": "token.refresh",
+                "parameters": [
+                    {
+                        "description": "refresh body",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handler.RefreshRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.LoginResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.LoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/upload": {
+            "post": {
+                "description": "start an upload of a course attachment sent straight to the storage, the response holds a presigned PUT URL or, for large files, presigned URLs of the parts",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Start upload",
+                "operationId": "upload.create",
+                "parameters": [
+                    {
+                        "description": "new upload body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/NewUpload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/UploadSession"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "drop a pending upload and whatever was uploaded for it",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Abort upload",
+                "operationId": "upload.abort",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "upload id",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/upload/complete": {
+            "post": {
+                "description": "verify the size and checksum of the uploaded file and add it to the attachments of the course, a file that does not match is deleted",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Complete upload",
+                "operationId": "upload.complete",
+                "parameters": [
+                    {
+                        "description": "upload completion body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/UploadCompletion"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Upload"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/user": {
+            "get": {
+                "description": "read users",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Read users",
+                "operationId": "user.read",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "offset",
+                        "name": "offset",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "limit",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "id",
+                        "name": "id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/User"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "update user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Update user",
+                "operationId": "user.update",
+                "parameters": [
+                    {
+                        "description": "update user body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/UserUpdateBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "create user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Create user",
+                "operationId": "user.create",
+                "parameters": [
+                    {
+                        "description": "new user body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/NewUser"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "delete user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Delete user",
+                "operationId": "user.delete",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/verify-email": {
+            "post": {
+                "description": "confirm the email of a user with the token from the verification link",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Verify email",
+                "operationId": "email.verify",
+                "parameters": [
+                    {
+                        "description": "verify email body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/VerifyEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/verify-email/resend": {
+            "post": {
+                "description": "send a new verification link to the current user, throttled per user",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Resend verification email",
+                "operationId": "email.verify.resend",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "AccessCode": {
+            "type": "object",
+            "required": [
+                "code",
+                "courseId",
+                "createdAt",
+                "id"
+            ],
+            "properties": {
+                "batchId": {
+                    "type": "string"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "courseId": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "orderId": {
+                    "type": "string"
+                },
+                "redeemedAt": {
+                    "type": "string"
+                },
+                "redeemedBy": {
+                    "type": "string"
+                },
+                "voidedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "BulkEnrollment": {
+            "type": "object",
+            "properties": {
+                "courseIds": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "userIds": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "BulkEnrollmentResult": {
+            "type": "object",
+            "properties": {
+                "changed": {
+                    "description": "Changed is the number of user and course pairs that were enrolled or unenrolled, pairs\nalready in the requested state are not counted.",
+                    "type": "integer"
+                }
+            }
+        },
+        "Bundle": {
+            "type": "object",
+            "required": [
+                "courseIds",
+                "id",
+                "price",
+                "title"
+            ],
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "courseIds": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "CartItem": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "courseId": {
+                    "type": "string"
+                },
+                "price": {
+                    "description": "Price is the list price of the course and Amount the part of the order total paid for it.",
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "CartQuote": {
+            "type": "object",
+            "properties": {
+                "bundleId": {
+                    "type": "string"
+                },
+                "couponCode": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "discount": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/CartItem"
+                    }
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "CartQuoteResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "quote": {
+                    "$ref": "#/definitions/CartQuote"
+                }
+            }
+        },
+        "Certificate": {
+            "type": "object",
+            "properties": {
+                "courseId": {
+                    "type": "string"
+                },
+                "courseTitle": {
+                    "type": "string"
+                },
+                "fileUrl": {
+                    "type": "string"
+                },
+                "issuedAt": {
+                    "type": "string"
+                },
+                "learnerName": {
+                    "type": "string"
+                },
+                "serial": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "CertificateVerification": {
+            "type": "object",
+            "properties": {
+                "courseTitle": {
+                    "type": "string"
+                },
+                "issuedAt": {
+                    "type": "string"
+                },
+                "learnerName": {
+                    "type": "string"
+                },
+                "serial": {
+                    "type": "string"
+                },
+                "valid": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "CheckoutRequest": {
+            "type": "object",
+            "properties": {
+                "bundleId": {
+                    "type": "string"
+                },
+                "couponCode": {
+                    "type": "string"
+                },
+                "courseIds": {
+                    "description": "CourseIDs are the courses in the cart, leave empty when buying a bundle.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "CodeBatch": {
+            "type": "object",
+            "required": [
+                "courseId",
+                "createdAt",
+                "id",
+                "quantity"
+            ],
+            "properties": {
+                "codes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/AccessCode"
+                    }
+                },
+                "courseId": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "redeemed": {
+                    "type": "integer"
+                }
+            }
+        },
+        "Coupon": {
+            "type": "object",
+            "required": [
+                "code",
+                "discountType",
+                "discountValue",
+                "id"
+            ],
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "courseId": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "discountType": {
+                    "$ref": "#/definitions/entity.DiscountType"
+                },
+                "discountValue": {
+                    "type": "integer"
+                },
+                "endsAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "maxPerUser": {
+                    "type": "integer"
+                },
+                "maxRedemptions": {
+                    "type": "integer"
+                },
+                "redemptions": {
+                    "type": "integer"
+                },
+                "startsAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "CouponPreviewRequest": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "courseId": {
+                    "type": "string"
+                }
+            }
+        },
+        "Course": {
+            "type": "object",
+            "required": [
+                "coverUrl",
+                "createdAt",
+                "description",
+                "id",
+                "price",
+                "title"
+            ],
+            "properties": {
+                "attachmentUrls": {
+                    "type": "string"
+                },
+                "coverUrl": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "isPaid": {
+                    "type": "boolean"
+                },
+                "modules": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/Module"
+                    }
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "CourseUpdateBody": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "currency": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "EnrollRequest": {
+            "type": "object",
+            "properties": {
+                "courseId": {
+                    "type": "string"
+                }
+            }
+        },
+        "Enrollment": {
+            "type": "object",
+            "required": [
+                "courseId",
+                "createdAt",
+                "id",
+                "source",
+                "userId"
+            ],
+            "properties": {
+                "courseId": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "grantedBy": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "orderId": {
+                    "type": "string"
+                },
+                "revokedAt": {
+                    "type": "string"
+                },
+                "source": {
+                    "$ref": "#/definitions/entity.EnrollmentSource"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "ForgotPasswordRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "GiftOrderCreateBody": {
+            "type": "object",
+            "properties": {
+                "couponCode": {
+                    "type": "string"
+                },
+                "courseId": {
+                    "type": "string"
+                },
+                "recipientEmail": {
+                    "description": "RecipientEmail is where the access code is sent once the order is paid, leave empty to hand\nthe code over yourself, it is listed at /me/gifts.",
+                    "type": "string"
+                }
+            }
+        },
+        "LearnerCourse": {
+            "type": "object",
+            "properties": {
+                "completedMinutes": {
+                    "type": "integer"
+                },
+                "completedModules": {
+                    "type": "integer"
+                },
+                "courseId": {
+                    "type": "string"
+                },
+                "coverUrl": {
+                    "type": "string"
+                },
+                "lastActivityAt": {
+                    "type": "string"
+                },
+                "nextModule": {
+                    "$ref": "#/definitions/NextModule"
+                },
+                "percent": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "totalMinutes": {
+                    "type": "integer"
+                },
+                "totalModules": {
+                    "type": "integer"
+                }
+            }
+        },
+        "Module": {
+            "type": "object",
+            "required": [
+                "content",
+                "courseId",
+                "createdAt",
+                "durationMinutes",
+                "id",
+                "name",
+                "order"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "courseId": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "durationMinutes": {
+                    "type": "integer"
+                },
+                "freePreview": {
+                    "description": "FreePreview modules show their content to everyone, including users who did not buy the course.",
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "isCompleted": {
+                    "type": "boolean"
+                },
+                "locked": {
+                    "description": "Locked is set when the content is withheld because the user has no access to the course.",
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "order": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "ModuleUpdateBody": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "durationMinutes": {
+                    "type": "integer"
+                },
+                "freePreview": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "order": {
+                    "type": "integer"
+                }
+            }
+        },
+        "NewActivity": {
+            "type": "object",
+            "required": [
+                "courseId",
+                "courseName",
+                "moduleId",
+                "userEmail",
+                "userFullname",
+                "userId"
+            ],
+            "properties": {
+                "courseId": {
+                    "type": "string"
+                },
+                "courseName": {
+                    "type": "string"
+                },
+                "isLast": {
+                    "type": "boolean"
+                },
+                "moduleId": {
+                    "type": "string"
+                },
+                "userEmail": {
+                    "type": "string"
+                },
+                "userFullname": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "NewBundle": {
+            "type": "object",
+            "required": [
+                "courseIds",
+                "price",
+                "title"
+            ],
+            "properties": {
+                "courseIds": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "NewCodeBatch": {
+            "type": "object",
+            "required": [
+                "courseId",
+                "quantity"
+            ],
+            "properties": {
+                "courseId": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                }
+            }
+        },
+        "NewCoupon": {
+            "type": "object",
+            "required": [
+                "code",
+                "discountType",
+                "discountValue"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "courseId": {
+                    "type": "string"
+                },
+                "discountType": {
+                    "$ref": "#/definitions/entity.DiscountType"
+                },
+                "discountValue": {
+                    "type": "integer"
+                },
+                "endsAt": {
+                    "type": "string"
+                },
+                "maxPerUser": {
+                    "type": "integer"
+                },
+                "maxRedemptions": {
+                    "type": "integer"
+                },
+                "startsAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "NewModule": {
+            "type": "object",
+            "required": [
+                "content",
+                "courseId",
+                "durationMinutes",
+                "name",
+                "order"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "courseId": {
+                    "type": "string"
+                },
+                "durationMinutes": {
+                    "type": "integer"
+                },
+                "freePreview": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "order": {
+                    "type": "integer"
+                }
+            }
+        },
+        "NewPlan": {
+            "type": "object",
+            "required": [
+                "interval",
+                "name",
+                "price"
+            ],
+            "properties": {
+                "currency": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "graceDays": {
+                    "description": "GraceDays is how long access continues after an unpaid period ends.",
+                    "type": "integer"
+                },
+                "interval": {
+                    "$ref": "#/definitions/entity.PlanInterval"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "integer"
+                }
+            }
+        },
+        "NewUpload": {
+            "type": "object",
+            "properties": {
+                "checksum": {
+                    "description": "Checksum is the hex encoded SHA-256 of the whole file, it is required for files sent with a\nsingle request.",
+                    "type": "string"
+                },
+                "contentType": {
+                    "type": "string"
+                },
+                "courseId": {
+                    "type": "string"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "partChecksums": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "partSize": {
+                    "description": "PartSize and PartChecksums are required for files larger than 100 MiB, which are sent in parts.\nEvery part but the last has PartSize bytes, at least 5 MiB, and PartChecksums lists the hex\nencoded SHA-256 of every part in order.",
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "NewUser": {
+            "type": "object",
+            "required": [
+                "email",
+                "name",
+                "password",
+                "phone",
+                "role"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "locale": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "role": {
+                    "$ref": "#/definitions/entity.Role"
+                }
+            }
+        },
+        "NextModule": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "order": {
+                    "type": "integer"
+                }
+            }
+        },
+        "Order": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "bundleId": {
+                    "type": "string"
+                },
+                "checkoutUrl": {
+                    "type": "string"
+                },
+                "couponCode": {
+                    "type": "string"
+                },
+                "courseId": {
+                    "description": "CourseID is set for orders of a single course, BundleID for orders of a bundle and\nSubscriptionID for orders paying a subscription period. Items lists the courses the order\ngives access to, unless the order is a gift: then it pays for an access code instead.",
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "discount": {
+                    "type": "integer"
+                },
+                "gift": {
+                    "type": "boolean"
+                },
+                "giftRecipientEmail": {
+                    "description": "GiftRecipientEmail is where the access code of a gift is sent, without it the buyer hands\nthe code over themselves.",
+                    "type": "string"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/CartItem"
+                    }
+                },
+                "orderId": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/entity.PaymentStatus"
+                },
+                "subscriptionId": {
+                    "type": "string"
+                }
+            }
+        },
+        "OrderCreateBody": {
+            "type": "object",
+            "properties": {
+                "couponCode": {
+                    "type": "string"
+                },
+                "courseId": {
+                    "type": "string"
+                }
+            }
+        },
+        "OrderResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "order": {
+                    "$ref": "#/definitions/Order"
+                }
+            }
+        },
+        "PaymentRecord": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "bundleId": {
+                    "type": "string"
+                },
+                "courseId": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "discount": {
+                    "type": "integer"
+                },
+                "gift": {
+                    "type": "boolean"
+                },
+                "orderId": {
+                    "type": "string"
+                },
+                "receipt": {
+                    "$ref": "#/definitions/Receipt"
+                },
+                "refundedAmount": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/entity.PaymentStatus"
+                },
+                "subscriptionId": {
+                    "type": "string"
+                }
+            }
+        },
+        "PaymentReview": {
+            "type": "object",
+            "required": [
+                "createdAt",
+                "id",
+                "localStatus",
+                "orderId",
+                "reason",
+                "updatedAt"
+            ],
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "localStatus": {
+                    "$ref": "#/definitions/entity.PaymentStatus"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "orderId": {
+                    "type": "string"
+                },
+                "providerStatus": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "resolvedAt": {
+                    "type": "string"
+                },
+                "resolvedBy": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "Plan": {
+            "type": "object",
+            "required": [
+                "id",
+                "interval",
+                "name",
+                "price"
+            ],
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "graceDays": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "interval": {
+                    "$ref": "#/definitions/entity.PlanInterval"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "integer"
+                }
+            }
+        },
+        "Quote": {
+            "type": "object",
+            "properties": {
+                "couponCode": {
+                    "type": "string"
+                },
+                "courseId": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "discount": {
+                    "type": "integer"
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "Receipt": {
+            "type": "object",
+            "required": [
+                "createdAt",
+                "fileUrl",
+                "id",
+                "number",
+                "orderId",
+                "paidAt",
+                "updatedAt",
+                "userId"
+            ],
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "fileUrl": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "number": {
+                    "type": "string"
+                },
+                "orderId": {
+                    "type": "string"
+                },
+                "paidAt": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "ReceiptRegenerateRequest": {
+            "type": "object",
+            "properties": {
+                "orderId": {
+                    "type": "string"
+                }
+            }
+        },
+        "RedeemRequest": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "Redemption": {
+            "type": "object",
+            "properties": {
+                "courseId": {
+                    "type": "string"
+                }
+            }
+        },
+        "Refund": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "orderId": {
+                    "type": "string"
+                },
+                "paidAmount": {
+                    "type": "integer"
+                },
+                "refundedAmount": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/entity.PaymentStatus"
+                }
+            }
+        },
+        "RefundRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "Amount to refund, the whole remaining amount when zero.",
+                    "type": "integer"
+                },
+                "orderId": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "ResetPasswordRequest": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "passwordConfirmation": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
             }
-        }
-    },
-    "definitions": {
-        "Course": {
+        },
+        "RevenueReport": {
             "type": "object",
-            "required": [
-                "coverUrl",
-                "createdAt",
-                "description",
-                "id",
-                "price",
-                "title"
-            ],
             "properties": {
-                "attachmentUrls": {
+                "courseId": {
                     "type": "string"
                 },
-                "coverUrl": {
+                "from": {
                     "type": "string"
                 },
-                "createdAt": {
+                "groupBy": {
                     "type": "string"
                 },
-                "description": {
-                    "type": "string"
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/RevenueRow"
+                    }
                 },
-                "id": {
-                    "type": "string"
+                "statuses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.PaymentStatus"
+                    }
                 },
-                "isPaid": {
-                    "type": "boolean"
+                "to": {
+                    "type": "string"
                 },
-                "modules": {
+                "totals": {
+                    "description": "Totals has a row per currency, amounts in different currencies are never added up.",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/Module"
+                        "$ref": "#/definitions/RevenueRow"
                     }
+                }
+            }
+        },
+        "RevenueRow": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string"
                 },
-                "price": {
+                "discount": {
                     "type": "integer"
                 },
-                "title": {
-                    "type": "string"
+                "gross": {
+                    "type": "integer"
                 },
-                "updatedAt": {
+                "net": {
+                    "description": "Net is the gross amount less refunds.",
+                    "type": "integer"
+                },
+                "orders": {
+                    "type": "integer"
+                },
+                "period": {
+                    "description": "Period is the first day of the day, week or month, it is empty in totals.",
                     "type": "string"
+                },
+                "refunded": {
+                    "type": "integer"
                 }
             }
         },
-        "CourseUpdateBody": {
+        "ReviewResolveRequest": {
             "type": "object",
-            "required": [
-                "id"
-            ],
             "properties": {
-                "description": {
+                "note": {
                     "type": "string"
                 },
-                "id": {
+                "orderId": {
+                    "type": "string"
+                }
+            }
+        },
+        "SubscribeRequest": {
+            "type": "object",
+            "properties": {
+                "couponCode": {
                     "type": "string"
                 },
-                "price": {
-                    "type": "integer"
-                },
-                "title": {
+                "planId": {
                     "type": "string"
                 }
             }
         },
-        "Module": {
+        "Subscription": {
             "type": "object",
             "required": [
-                "content",
-                "courseId",
-                "createdAt",
-                "durationMinutes",
                 "id",
-                "name",
-                "order"
+                "plan",
+                "status",
+                "userId"
             ],
             "properties": {
-                "content": {
-                    "type": "string"
+                "cancelAtPeriodEnd": {
+                    "type": "boolean"
                 },
-                "courseId": {
+                "cancelledAt": {
                     "type": "string"
                 },
                 "createdAt": {
                     "type": "string"
                 },
-                "durationMinutes": {
-                    "type": "integer"
+                "currentPeriodEnd": {
+                    "type": "string"
                 },
-                "id": {
+                "currentPeriodStart": {
                     "type": "string"
                 },
-                "isCompleted": {
-                    "type": "boolean"
+                "graceUntil": {
+                    "type": "string"
                 },
-                "name": {
+                "id": {
                     "type": "string"
                 },
-                "order": {
-                    "type": "integer"
+                "plan": {
+                    "$ref": "#/definitions/Plan"
                 },
-                "updatedAt": {
+                "status": {
+                    "$ref": "#/definitions/entity.SubscriptionStatus"
+                },
+                "userId": {
                     "type": "string"
                 }
             }
         },
-        "ModuleUpdateBody": {
+        "Upload": {
             "type": "object",
             "required": [
-                "id"
+                "checksum",
+                "contentType",
+                "courseId",
+                "createdAt",
+                "createdBy",
+                "expiresAt",
+                "filename",
+                "id",
+                "key",
+                "size",
+                "status",
+                "updatedAt"
             ],
             "properties": {
-                "content": {
+                "checksum": {
                     "type": "string"
                 },
-                "durationMinutes": {
-                    "type": "integer"
-                },
-                "id": {
+                "completedAt": {
                     "type": "string"
                 },
-                "name": {
+                "contentType": {
                     "type": "string"
                 },
-                "order": {
-                    "type": "integer"
-                }
-            }
-        },
-        "NewActivity": {
-            "type": "object",
-            "required": [
-                "courseId",
-                "courseName",
-                "moduleId",
-                "userEmail",
-                "userFullname",
-                "userId"
-            ],
-            "properties": {
                 "courseId": {
                     "type": "string"
                 },
-                "courseName": {
+                "createdAt": {
                     "type": "string"
                 },
-                "isLast": {
-                    "type": "boolean"
+                "createdBy": {
+                    "type": "string"
                 },
-                "moduleId": {
+                "expiresAt": {
                     "type": "string"
                 },
-                "userEmail": {
+                "filename": {
                     "type": "string"
                 },
-                "userFullname": {
+                "id": {
                     "type": "string"
                 },
-                "userId": {
+                "key": {
+                    "type": "string"
+                },
+                "partChecksums": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "partSize": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/entity.UploadStatus"
+                },
+                "updatedAt": {
                     "type": "string"
                 }
             }
         },
-        "NewModule": {
+        "UploadCompletion": {
             "type": "object",
-            "required": [
-                "content",
-                "courseId",
-                "durationMinutes",
-                "name",
-                "order"
-            ],
             "properties": {
-                "content": {
-                    "type": "string"
-                },
-                "courseId": {
+                "id": {
                     "type": "string"
                 },
-                "durationMinutes": {
+                "parts": {
+                    "description": "Parts are required for multipart uploads.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/UploadedPart"
+                    }
+                }
+            }
+        },
+        "UploadPartURL": {
+            "type": "object",
+            "properties": {
+                "number": {
                     "type": "integer"
                 },
-                "name": {
+                "url": {
                     "type": "string"
-                },
-                "order": {
-                    "type": "integer"
                 }
             }
         },
-        "NewUser": {
+        "UploadSession": {
             "type": "object",
-            "required": [
-                "email",
-                "name",
-                "password",
-                "phone",
-                "role"
-            ],
             "properties": {
-                "email": {
-                    "type": "string"
+                "partSize": {
+                    "type": "integer"
                 },
-                "name": {
-                    "type": "string"
+                "parts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/UploadPartURL"
+                    }
                 },
-                "password": {
-                    "type": "string"
+                "upload": {
+                    "$ref": "#/definitions/Upload"
                 },
-                "phone": {
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "UploadedPart": {
+            "type": "object",
+            "properties": {
+                "etag": {
                     "type": "string"
                 },
-                "role": {
-                    "$ref": "#/definitions/entity.Role"
+                "number": {
+                    "type": "integer"
                 }
             }
         },
@@ -842,9 +4064,15 @@ const docTemplate = `{
                 "email": {
                     "type": "string"
                 },
+                "emailVerifiedAt": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "string"
                 },
+                "locale": {
+                    "type": "string"
+                },
                 "name": {
                     "type": "string"
                 },
@@ -874,6 +4102,9 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
+                "locale": {
+                    "type": "string"
+                },
                 "name": {
                     "type": "string"
                 },
@@ -888,6 +4119,72 @@ const docTemplate = `{
                 }
             }
         },
+        "VerifyEmailRequest": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.DiscountType": {
+            "type": "string",
+            "enum": [
+                "percent",
+                "fixed"
+            ],
+            "x-enum-varnames": [
+                "PercentDiscount",
+                "FixedDiscount"
+            ]
+        },
+        "entity.EnrollmentSource": {
+            "type": "string",
+            "enum": [
+                "purchase",
+                "free",
+                "admin",
+                "gift",
+                "subscription"
+            ],
+            "x-enum-varnames": [
+                "PurchaseEnrollment",
+                "FreeEnrollment",
+                "AdminEnrollment",
+                "GiftEnrollment",
+                "SubscriptionEnrollment"
+            ]
+        },
+        "entity.PaymentStatus": {
+            "type": "string",
+            "enum": [
+                "created",
+                "pending",
+                "succeeded",
+                "failed",
+                "cancelled",
+                "refunded"
+            ],
+            "x-enum-varnames": [
+                "PaymentCreated",
+                "PaymentPending",
+                "PaymentSucceeded",
+                "PaymentFailed",
+                "PaymentCancelled",
+                "PaymentRefunded"
+            ]
+        },
+        "entity.PlanInterval": {
+            "type": "string",
+            "enum": [
+                "month",
+                "year"
+            ],
+            "x-enum-varnames": [
+                "MonthlyPlan",
+                "AnnualPlan"
+            ]
+        },
         "entity.Role": {
             "type": "string",
             "enum": [
@@ -899,6 +4196,42 @@ const docTemplate = `{
                 "UserRole"
             ]
         },
+        "entity.SubscriptionStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "active",
+                "past_due",
+                "expired",
+                "ended"
+            ],
+            "x-enum-varnames": [
+                "SubscriptionPending",
+                "SubscriptionActive",
+                "SubscriptionPastDue",
+                "SubscriptionExpired",
+                "SubscriptionEnded"
+            ]
+        },
+        "entity.UploadStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "verifying",
+                "completed",
+                "failed",
+                "aborted",
+                "expired"
+            ],
+            "x-enum-varnames": [
+                "UploadPending",
+                "UploadVerifying",
+                "UploadCompleted",
+                "UploadFailed",
+                "UploadAborted",
+                "UploadExpired"
+            ]
+        },
         "handler.LoginRequest": {
             "type": "object",
             "properties": {
@@ -916,17 +4249,31 @@ const docTemplate = `{
                 "error": {
                     "type": "string"
                 },
+                "refreshToken": {
+                    "type": "string"
+                },
                 "token": {
                     "type": "string"
                 }
             }
         },
+        "handler.RefreshRequest": {
+            "type": "object",
+            "properties": {
+                "refreshToken": {
+                    "type": "string"
+                }
+            }
+        },
         "handler.RegisterRequest": {
             "type": "object",
             "properties": {
                 "email": {
                     "type": "string"
                 },
+                "locale": {
+                    "type": "string"
+                },
                 "name": {
                     "type": "string"
                 },
@@ -947,6 +4294,9 @@ const docTemplate = `{
                 "error": {
                     "type": "string"
                 },
+                "refreshToken": {
+                    "type": "string"
+                },
                 "token": {
                     "type": "string"
                 }
@@ -0,0 +1,114 @@
+package document
+
+import (
+	"fmt"
+	"time"
+)
+
+type CertificateData struct {
+	Serial      string
+	LearnerName string
+	CourseTitle string
+	IssuedAt    time.Time
+	VerifyURL   string
+	Locale      string
+}
+
+type certificateText struct {
+	title     string
+	certifies string
+	completed string
+	issued    string
+	serial    string
+	verify    string
+}
+
+var certificateTexts = map[string]certificateText{
+	"en": {
+		title:     "CERTIFICATE OF COMPLETION",
+		certifies: "This is to certify that",
+		completed: "has successfully completed the course",
+		issued:    "Date of issue",
+		serial:    "Serial number",
+		verify:    "Scan to verify",
+	},
+	"ru": {
+		title:     "СЕРТИФИКАТ О ПРОХОЖДЕНИИ КУРСА",
+		certifies: "Настоящим подтверждается, что",
+		completed: "успешно завершил(а) курс",
+		issued:    "Дата выдачи",
+		serial:    "Серийный номер",
+		verify:    "Проверить подлинность",
+	},
+	"kk": {
+		title:     "КУРСТЫ АЯҚТАҒАНЫ ТУРАЛЫ СЕРТИФИКАТ",
+		certifies: "Осы сертификат растайды:",
+		completed: "курсын сәтті аяқтады",
+		issued:    "Берілген күні",
+		serial:    "Сериялық нөмірі",
+		verify:    "Түпнұсқалығын тексеру",
+	},
+}
+
+func Certificate(data CertificateData) ([]byte, error) {
+	text, ok := certificateTexts[data.Locale]
+	if !ok {
+		text = certificateTexts["ru"]
+	}
+
+	pdf := newPDF("L")
+	width, height := pdf.GetPageSize()
+
+	pdf.SetDrawColor(30, 64, 120)
+	pdf.SetLineWidth(2)
+	pdf.Rect(10, 10, width-20, height-20, "D")
+	pdf.SetLineWidth(0.5)
+	pdf.Rect(14, 14, width-28, height-28, "D")
+
+	pdf.SetTextColor(30, 64, 120)
+	pdf.SetFont(fontFamily, "B", 28)
+	pdf.SetXY(20, 36)
+	pdf.CellFormat(width-40, 14, text.title, "", 1, "C", false, 0, "")
+
+	pdf.SetTextColor(40, 40, 40)
+	pdf.SetFont(fontFamily, "", 14)
+	pdf.SetXY(20, 62)
+	pdf.CellFormat(width-40, 10, text.certifies, "", 1, "C", false, 0, "")
+
+	pdf.SetFont(fontFamily, "B", 30)
+	pdf.SetXY(20, 76)
+	pdf.CellFormat(width-40, 16, data.LearnerName, "", 1, "C", false, 0, "")
+
+	pdf.SetFont(fontFamily, "", 14)
+	pdf.SetXY(20, 98)
+	pdf.CellFormat(width-40, 10, text.completed, "", 1, "C", false, 0, "")
+
+	pdf.SetFont(fontFamily, "B", 20)
+	pdf.SetXY(40, 110)
+	pdf.MultiCell(width-80, 10, "«"+data.CourseTitle+"»", "", "C", false)
+
+	pdf.SetFont(fontFamily, "", 11)
+	pdf.SetXY(30, height-50)
+	pdf.CellFormat(120, 7, fmt.Sprintf("%v: %v", text.issued, data.IssuedAt.Format("02.01.2006")), "", 1, "L", false, 0, "")
+	pdf.SetX(30)
+	pdf.CellFormat(120, 7, fmt.Sprintf("%v: %v", text.serial, data.Serial), "", 1, "L", false, 0, "")
+	pdf.SetX(30)
+	pdf.SetFont(fontFamily, "", 9)
+	pdf.CellFormat(160, 7, data.VerifyURL, "", 1, "L", false, 0, "")
+
+	qrSize := 34.0
+	err := drawQR(pdf, data.VerifyURL, width-30-qrSize, height-58, qrSize)
+	if err != nil {
+		return nil, fmt.Errorf("certificate: %v", err)
+	}
+	pdf.SetFont(fontFamily, "", 8)
+	pdf.SetXY(width-30-qrSize, height-58+qrSize)
+	pdf.CellFormat(qrSize, 5, text.verify, "", 1, "C", false, 0, "")
+
+	content, err := output(pdf)
+	if err != nil {
+		return nil, fmt.Errorf("certificate: %v", err)
+	}
+
+	return content, nil
+}
@@ -0,0 +1,64 @@
+// Package document renders the PDF documents the server hands out to learners.
+package document
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/skip2/go-qrcode"
+)
+
+// DejaVu fonts cover Cyrillic and the Kazakh letters, which the PDF core fonts do not.
+var (
+	//go:embed fonts/DejaVuSansCondensed.ttf
+	regularFont []byte
+	//go:embed fonts/DejaVuSansCondensed-Bold.ttf
+	boldFont []byte
+)
+
+const fontFamily = "DejaVu"
+
+func newPDF(orientation string) *gofpdf.Fpdf {
+	pdf := gofpdf.New(orientation, "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(fontFamily, "", regularFont)
+	pdf.AddUTF8FontFromBytes(fontFamily, "B", boldFont)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddPage()
+
+	return pdf
+}
+
+// drawQR draws the QR code of content as vector squares with the top left corner at x, y.
+func drawQR(pdf *gofpdf.Fpdf, content string, x float64, y float64, size float64) error {
+	code, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return fmt.Errorf("encoding qr code: %v", err)
+	}
+
+	bitmap := code.Bitmap()
+	module := size / float64(len(bitmap))
+
+	pdf.SetFillColor(0, 0, 0)
+	for row := range bitmap {
+		for col := range bitmap[row] {
+			if bitmap[row][col] {
+				pdf.Rect(x+float64(col)*module, y+float64(row)*module, module, module, "F")
+			}
+		}
+	}
+
+	return nil
+}
+
+func output(pdf *gofpdf.Fpdf) ([]byte, error) {
+	buf := &bytes.Buffer{}
+
+	err := pdf.Output(buf)
+	if err != nil {
+		return nil, fmt.Errorf("writing pdf: %v", err)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,202 @@
+package document
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+type ReceiptLine struct {
+	Title  string
+	Amount int64
+}
+
+type ReceiptData struct {
+	Number        string
+	OrderID       string
+	PaidAt        time.Time
+	SellerName    string
+	SellerBIN     string
+	SellerAddress string
+	BuyerName     string
+	BuyerEmail    string
+	// Lines hold the amounts paid for each item, the discount is already taken off them and is only
+	// shown for reference.
+	Lines []ReceiptLine
+	// Gift marks the lines as bought as a gift.
+	Gift     bool
+	Discount int64
+	Total    int64
+	Currency string
+	Locale   string
+}
+
+type receiptText struct {
+	title    string
+	date     string
+	order    string
+	seller   string
+	bin      string
+	buyer    string
+	item     string
+	amount   string
+	discount string
+	total    string
+	paid     string
+	gift     string
+}
+
+var receiptTexts = map[string]receiptText{
+	"en": {
+		title:    "RECEIPT",
+		date:     "Date of payment",
+		order:    "Order number",
+		seller:   "Seller",
+		bin:      "BIN",
+		buyer:    "Buyer",
+		item:     "Item",
+		amount:   "Amount",
+		discount: "Discount applied",
+		total:    "Total paid",
+		paid:     "Paid online by card.",
+		gift:     "Gift",
+	},
+	"ru": {
+		title:    "КВИТАНЦИЯ ОБ ОПЛАТЕ",
+		date:     "Дата оплаты",
+		order:    "Номер заказа",
+		seller:   "Продавец",
+		bin:      "БИН",
+		buyer:    "Покупатель",
+		item:     "Наименование",
+		amount:   "Сумма",
+		discount: "Применена скидка",
+		total:    "Итого оплачено",
+		paid:     "Оплачено онлайн банковской картой.",
+		gift:     "Подарок",
+	},
+	"kk": {
+		title:    "ТӨЛЕМ ТУРАЛЫ ТҮБІРТЕК",
+		date:     "Төлем күні",
+		order:    "Тапсырыс нөмірі",
+		seller:   "Сатушы",
+		bin:      "БСН",
+		buyer:    "Сатып алушы",
+		item:     "Атауы",
+		amount:   "Сомасы",
+		discount: "Қолданылған жеңілдік",
+		total:    "Барлығы төленді",
+		paid:     "Банк картасымен онлайн төленді.",
+		gift:     "Сыйлық",
+	},
+}
+
+func Receipt(data ReceiptData) ([]byte, error) {
+	text, ok := receiptTexts[data.Locale]
+	if !ok {
+		text = receiptTexts["ru"]
+	}
+
+	pdf := newPDF("P")
+	width, _ := pdf.GetPageSize()
+	left := 20.0
+	contentWidth := width - 2*left
+	amountWidth := 45.0
+
+	pdf.SetTextColor(30, 64, 120)
+	pdf.SetFont(fontFamily, "B", 20)
+	pdf.SetXY(left, 24)
+	pdf.CellFormat(contentWidth, 10, fmt.Sprintf("%v № %v", text.title, data.Number), "", 1, "L", false, 0, "")
+
+	pdf.SetTextColor(40, 40, 40)
+	pdf.SetFont(fontFamily, "", 10)
+	pdf.SetX(left)
+	pdf.CellFormat(contentWidth, 6, fmt.Sprintf("%v: %v", text.date, data.PaidAt.Format("02.01.2006 15:04")), "", 1, "L", false, 0, "")
+	pdf.SetX(left)
+	pdf.CellFormat(contentWidth, 6, fmt.Sprintf("%v: %v", text.order, data.OrderID), "", 1, "L", false, 0, "")
+
+	pdf.SetY(pdf.GetY() + 8)
+	sellerBIN := ""
+	if data.SellerBIN != "" {
+		sellerBIN = fmt.Sprintf("%v: %v", text.bin, data.SellerBIN)
+	}
+	receiptParty(pdf, left, contentWidth, text.seller, data.SellerName, sellerBIN, data.SellerAddress)
+	pdf.SetY(pdf.GetY() + 4)
+	receiptParty(pdf, left, contentWidth, text.buyer, data.BuyerName, data.BuyerEmail)
+
+	pdf.SetY(pdf.GetY() + 8)
+	pdf.SetDrawColor(30, 64, 120)
+	pdf.SetFillColor(235, 240, 248)
+	pdf.SetFont(fontFamily, "B", 10)
+	pdf.SetX(left)
+	pdf.CellFormat(contentWidth-amountWidth, 8, text.item, "1", 0, "L", true, 0, "")
+	pdf.CellFormat(amountWidth, 8, text.amount, "1", 1, "R", true, 0, "")
+
+	pdf.SetFont(fontFamily, "", 10)
+	for _, line := range data.Lines {
+		title := line.Title
+		if data.Gift {
+			title = fmt.Sprintf("%v: %v", text.gift, title)
+		}
+		pdf.SetX(left)
+		pdf.CellFormat(contentWidth-amountWidth, 8, title, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(amountWidth, 8, formatAmount(line.Amount, data.Currency), "1", 1, "R", false, 0, "")
+	}
+
+	pdf.SetFont(fontFamily, "B", 11)
+	pdf.SetX(left)
+	pdf.CellFormat(contentWidth-amountWidth, 9, text.total, "1", 0, "R", false, 0, "")
+	pdf.CellFormat(amountWidth, 9, formatAmount(data.Total, data.Currency), "1", 1, "R", false, 0, "")
+
+	pdf.SetFont(fontFamily, "", 9)
+	pdf.SetXY(left, pdf.GetY()+6)
+	if data.Discount != 0 {
+		pdf.CellFormat(contentWidth, 6, fmt.Sprintf("%v: %v", text.discount, formatAmount(data.Discount, data.Currency)), "", 1, "L", false, 0, "")
+		pdf.SetX(left)
+	}
+	pdf.CellFormat(contentWidth, 6, text.paid, "", 1, "L", false, 0, "")
+
+	content, err := output(pdf)
+	if err != nil {
+		return nil, fmt.Errorf("receipt: %v", err)
+	}
+
+	return content, nil
+}
+
+// receiptParty writes the heading and the non empty lines of a seller or buyer block.
+func receiptParty(pdf *gofpdf.Fpdf, left float64, width float64, heading string, lines ...string) {
+	pdf.SetFont(fontFamily, "B", 11)
+	pdf.SetX(left)
+	pdf.CellFormat(width, 7, heading, "", 1, "L", false, 0, "")
+
+	pdf.SetFont(fontFamily, "", 10)
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		pdf.SetX(left)
+		pdf.CellFormat(width, 6, line, "", 1, "L", false, 0, "")
+	}
+}
+
+// formatAmount groups thousands with spaces, as amounts are written in Kazakhstan: 12 500 KZT.
+func formatAmount(amount int64, currency string) string {
+	digits := strconv.FormatInt(amount, 10)
+	sign := ""
+	if amount < 0 {
+		sign, digits = "-", digits[1:]
+	}
+
+	grouped := make([]byte, 0, len(digits)+len(digits)/3)
+	for i := range digits {
+		if i != 0 && (len(digits)-i)%3 == 0 {
+			grouped = append(grouped, ' ')
+		}
+		grouped = append(grouped, digits[i])
+	}
+
+	return fmt.Sprintf("%v%s %v", sign, grouped, currency)
+}
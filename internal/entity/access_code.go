@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// AccessCode is a single use code that enrolls whoever redeems it in the course. Codes are either
+// bought as a gift, then OrderID is set, or generated by admins in a batch.
+type AccessCode struct {
+	ID         uuid.UUID  `db:"id" json:"id" validate:"required"`
+	CreatedAt  time.Time  `db:"created_at" json:"createdAt" validate:"required"`
+	Code       string     `db:"code" json:"code" validate:"required"`
+	CourseID   uuid.UUID  `db:"course_id" json:"courseId" validate:"required"`
+	BatchID    *uuid.UUID `db:"batch_id" json:"batchId"`
+	OrderID    *uuid.UUID `db:"order_id" json:"orderId"`
+	ExpiresAt  *time.Time `db:"expires_at" json:"expiresAt"`
+	RedeemedBy *uuid.UUID `db:"redeemed_by" json:"redeemedBy"`
+	RedeemedAt *time.Time `db:"redeemed_at" json:"redeemedAt"`
+	VoidedAt   *time.Time `db:"voided_at" json:"voidedAt"`
+} // @name AccessCode
+
+type CodeBatch struct {
+	ID        uuid.UUID    `db:"id" json:"id" validate:"required"`
+	CreatedAt time.Time    `db:"created_at" json:"createdAt" validate:"required"`
+	CourseID  uuid.UUID    `db:"course_id" json:"courseId" validate:"required"`
+	Label     string       `db:"label" json:"label"`
+	Quantity  int          `db:"quantity" json:"quantity" validate:"required"`
+	ExpiresAt *time.Time   `db:"expires_at" json:"expiresAt"`
+	CreatedBy *uuid.UUID   `db:"created_by" json:"createdBy"`
+	Redeemed  int          `json:"redeemed"`
+	Codes     []AccessCode `json:"codes,omitempty"`
+} // @name CodeBatch
+
+type NewCodeBatch struct {
+	CourseID  uuid.UUID  `db:"course_id" json:"courseId" validate:"required"`
+	Label     string     `db:"label" json:"label"`
+	Quantity  int        `db:"quantity" json:"quantity" validate:"required"`
+	ExpiresAt *time.Time `db:"expires_at" json:"expiresAt"`
+} // @name NewCodeBatch
@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type Bundle struct {
+	ID          uuid.UUID   `db:"id" json:"id" validate:"required"`
+	CreatedAt   time.Time   `db:"created_at" json:"createdAt"`
+	Title       string      `db:"title" json:"title" validate:"required"`
+	Description string      `db:"description" json:"description"`
+	Price       int64       `db:"price" json:"price" validate:"required"`
+	Currency    string      `db:"currency" json:"currency"`
+	Active      bool        `db:"active" json:"active"`
+	CourseIDs   []uuid.UUID `json:"courseIds" validate:"required"`
+} // @name Bundle
+
+type NewBundle struct {
+	Title       string      `db:"title" json:"title" validate:"required"`
+	Description string      `db:"description" json:"description"`
+	Price       int64       `db:"price" json:"price" validate:"required"`
+	Currency    string      `db:"currency" json:"currency"`
+	CourseIDs   []uuid.UUID `json:"courseIds" validate:"required"`
+} // @name NewBundle
@@ -0,0 +1,49 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type DiscountType string
+
+const (
+	PercentDiscount DiscountType = "percent"
+	FixedDiscount   DiscountType = "fixed"
+)
+
+type Coupon struct {
+	ID             uuid.UUID    `db:"id" json:"id" validate:"required"`
+	CreatedAt      time.Time    `db:"created_at" json:"createdAt"`
+	Code           string       `db:"code" json:"code" validate:"required"`
+	DiscountType   DiscountType `db:"discount_type" json:"discountType" validate:"required"`
+	DiscountValue  int64        `db:"discount_value" json:"discountValue" validate:"required"`
+	CourseID       *uuid.UUID   `db:"course_id" json:"courseId"`
+	StartsAt       *time.Time   `db:"starts_at" json:"startsAt"`
+	EndsAt         *time.Time   `db:"ends_at" json:"endsAt"`
+	MaxRedemptions *int64       `db:"max_redemptions" json:"maxRedemptions"`
+	MaxPerUser     *int64       `db:"max_per_user" json:"maxPerUser"`
+	Active         bool         `db:"active" json:"active"`
+	Redemptions    int64        `json:"redemptions"`
+} // @name Coupon
+
+type NewCoupon struct {
+	Code           string       `db:"code" json:"code" validate:"required"`
+	DiscountType   DiscountType `db:"discount_type" json:"discountType" validate:"required"`
+	DiscountValue  int64        `db:"discount_value" json:"discountValue" validate:"required"`
+	CourseID       *uuid.UUID   `db:"course_id" json:"courseId"`
+	StartsAt       *time.Time   `db:"starts_at" json:"startsAt"`
+	EndsAt         *time.Time   `db:"ends_at" json:"endsAt"`
+	MaxRedemptions *int64       `db:"max_redemptions" json:"maxRedemptions"`
+	MaxPerUser     *int64       `db:"max_per_user" json:"maxPerUser"`
+} // @name NewCoupon
+
+// Discount returns how much the coupon takes off the price, never more than the price itself.
+func (c Coupon) Discount(price int64) int64 {
+	discount := c.DiscountValue
+	if c.DiscountType == PercentDiscount {
+		discount = price * c.DiscountValue / 100
+	}
+
+	return min(max(discount, 0), price)
+}
@@ -5,6 +5,8 @@ import (
 	"time"
 )
 
+const DefaultCurrency = "KZT"
+
 type Course struct {
 	ID             uuid.UUID `db:"id" json:"id" validate:"required"`
 	CreatedAt      time.Time `db:"created_at" json:"createdAt" validate:"required"`
@@ -12,6 +14,7 @@ type Course struct {
 	Title          string    `db:"title" json:"title" validate:"required"`
 	Description    string    `db:"description" json:"description" validate:"required"`
 	Price          int64     `db:"price" json:"price" validate:"required"`
+	Currency       string    `db:"currency" json:"currency"`
 	CoverURL       string    `db:"cover_url" json:"coverUrl" validate:"required"`
 	AttachmentURLs string    `db:"attachment_urls" json:"attachmentUrls"`
 	Modules        *[]Module `json:"modules"`
@@ -23,6 +26,7 @@ type CourseUpdateBody struct {
 	Title       *string   `db:"title" json:"title"`
 	Description *string   `db:"description" json:"description"`
 	Price       *int64    `db:"price" json:"price"`
+	Currency    *string   `db:"currency" json:"currency"`
 } // @name CourseUpdateBody
 
 type CourseFilters struct {
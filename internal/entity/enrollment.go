@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// EnrollmentSource is how the user got access to the course.
+type EnrollmentSource string
+
+const (
+	PurchaseEnrollment     EnrollmentSource = "purchase"
+	FreeEnrollment         EnrollmentSource = "free"
+	AdminEnrollment        EnrollmentSource = "admin"
+	GiftEnrollment         EnrollmentSource = "gift"
+	SubscriptionEnrollment EnrollmentSource = "subscription"
+)
+
+func (s EnrollmentSource) Valid() bool {
+	switch s {
+	case PurchaseEnrollment, FreeEnrollment, AdminEnrollment, GiftEnrollment, SubscriptionEnrollment:
+		return true
+	}
+
+	return false
+}
+
+type Enrollment struct {
+	ID        uuid.UUID        `db:"id" json:"id" validate:"required"`
+	CreatedAt time.Time        `db:"created_at" json:"createdAt" validate:"required"`
+	UserID    uuid.UUID        `db:"user_id" json:"userId" validate:"required"`
+	CourseID  uuid.UUID        `db:"course_id" json:"courseId" validate:"required"`
+	Source    EnrollmentSource `db:"source" json:"source" validate:"required"`
+	OrderID   *uuid.UUID       `db:"order_id" json:"orderId"`
+	GrantedBy *uuid.UUID       `db:"granted_by" json:"grantedBy"`
+	RevokedAt *time.Time       `db:"revoked_at" json:"revokedAt"`
+} // @name Enrollment
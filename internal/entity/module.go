@@ -14,7 +14,11 @@ type Module struct {
 	Content         string    `db:"content" json:"content" validate:"required"`
 	Order           int64     `db:"order_number" json:"order" validate:"required"`
 	DurationMinutes int64     `db:"duration_minutes" json:"durationMinutes" validate:"required"`
-	IsCompleted     bool      `json:"isCompleted"`
+	// FreePreview modules show their content to everyone, including users who did not buy the course.
+	FreePreview bool `db:"free_preview" json:"freePreview"`
+	// Locked is set when the content is withheld because the user has no access to the course.
+	Locked      bool `json:"locked"`
+	IsCompleted bool `json:"isCompleted"`
 } // @name Module
 
 type NewModule struct {
@@ -23,6 +27,7 @@ type NewModule struct {
 	Content         string    `db:"content" json:"content" validate:"required"`
 	Order           int64     `db:"order_number" json:"order" validate:"required"`
 	DurationMinutes int64     `db:"duration_minutes" json:"durationMinutes" validate:"required"`
+	FreePreview     bool      `db:"free_preview" json:"freePreview"`
 } // @name NewModule
 
 type ModuleUpdateBody struct {
@@ -31,6 +36,7 @@ type ModuleUpdateBody struct {
 	Content         *string   `db:"content" json:"content"`
 	Order           *int64    `db:"order_number" json:"order"`
 	DurationMinutes *int64    `db:"duration_minutes" json:"durationMinutes"`
+	FreePreview     *bool     `db:"free_preview" json:"freePreview"`
 } // @name ModuleUpdateBody
 
 type ModuleFilters struct {
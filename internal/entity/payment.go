@@ -0,0 +1,53 @@
+package entity
+
+type PaymentStatus string
+
+const (
+	PaymentCreated   PaymentStatus = "created"
+	PaymentPending   PaymentStatus = "pending"
+	PaymentSucceeded PaymentStatus = "succeeded"
+	PaymentFailed    PaymentStatus = "failed"
+	PaymentCancelled PaymentStatus = "cancelled"
+	PaymentRefunded  PaymentStatus = "refunded"
+)
+
+// paymentTransitions lists the statuses a payment can move to from each status. A failed payment
+// can still succeed because providers let the buyer retry on the same order; cancelled and
+// refunded payments are final.
+var paymentTransitions = map[PaymentStatus][]PaymentStatus{
+	PaymentCreated:   {PaymentPending, PaymentSucceeded, PaymentFailed, PaymentCancelled},
+	PaymentPending:   {PaymentSucceeded, PaymentFailed, PaymentCancelled},
+	PaymentFailed:    {PaymentPending, PaymentSucceeded, PaymentCancelled},
+	PaymentSucceeded: {PaymentRefunded},
+}
+
+func (s PaymentStatus) Valid() bool {
+	switch s {
+	case PaymentCreated, PaymentPending, PaymentSucceeded, PaymentFailed, PaymentCancelled, PaymentRefunded:
+		return true
+	}
+
+	return false
+}
+
+func (s PaymentStatus) CanTransitionTo(next PaymentStatus) bool {
+	for _, status := range paymentTransitions[s] {
+		if status == next {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PreviousPaymentStatuses returns the statuses a payment can move to the given status from.
+func PreviousPaymentStatuses(next PaymentStatus) []PaymentStatus {
+	statuses := make([]PaymentStatus, 0)
+	for status := range paymentTransitions {
+		if status.CanTransitionTo(next) {
+			statuses = append(statuses, status)
+		}
+	}
+
+	return statuses
+}
@@ -0,0 +1,21 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// PaymentReview flags an order whose status could not be reconciled with the payment provider, it
+// stays open until an admin resolves it.
+type PaymentReview struct {
+	ID             uuid.UUID     `db:"id" json:"id" validate:"required"`
+	CreatedAt      time.Time     `db:"created_at" json:"createdAt" validate:"required"`
+	UpdatedAt      time.Time     `db:"updated_at" json:"updatedAt" validate:"required"`
+	OrderID        uuid.UUID     `db:"order_id" json:"orderId" validate:"required"`
+	Reason         string        `db:"reason" json:"reason" validate:"required"`
+	LocalStatus    PaymentStatus `db:"local_status" json:"localStatus" validate:"required"`
+	ProviderStatus string        `db:"provider_status" json:"providerStatus"`
+	ResolvedAt     *time.Time    `db:"resolved_at" json:"resolvedAt"`
+	ResolvedBy     *uuid.UUID    `db:"resolved_by" json:"resolvedBy"`
+	Note           string        `db:"note" json:"note"`
+} // @name PaymentReview
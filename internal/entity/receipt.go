@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Receipt is the PDF document issued for a paid order. Regenerating it keeps the number and the
+// date of payment and replaces the file. The file is private, FileURL links to the receipt endpoint
+// that redirects to it.
+type Receipt struct {
+	ID        uuid.UUID `db:"id" json:"id" validate:"required"`
+	CreatedAt time.Time `db:"created_at" json:"createdAt" validate:"required"`
+	UpdatedAt time.Time `db:"updated_at" json:"updatedAt" validate:"required"`
+	Number    string    `db:"number" json:"number" validate:"required"`
+	OrderID   uuid.UUID `db:"order_id" json:"orderId" validate:"required"`
+	UserID    uuid.UUID `db:"user_id" json:"userId" validate:"required"`
+	PaidAt    time.Time `db:"paid_at" json:"paidAt" validate:"required"`
+	FileURL   string    `db:"file_url" json:"fileUrl" validate:"required"`
+} // @name Receipt
@@ -0,0 +1,99 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type PlanInterval string
+
+const (
+	MonthlyPlan PlanInterval = "month"
+	AnnualPlan  PlanInterval = "year"
+)
+
+// Next returns the end of a billing period that starts at the given time.
+func (i PlanInterval) Next(start time.Time) time.Time {
+	if i == AnnualPlan {
+		return start.AddDate(1, 0, 0)
+	}
+
+	return start.AddDate(0, 1, 0)
+}
+
+func (i PlanInterval) Valid() bool {
+	return i == MonthlyPlan || i == AnnualPlan
+}
+
+type Plan struct {
+	ID          uuid.UUID    `db:"id" json:"id" validate:"required"`
+	CreatedAt   time.Time    `db:"created_at" json:"createdAt"`
+	Name        string       `db:"name" json:"name" validate:"required"`
+	Description string       `db:"description" json:"description"`
+	Interval    PlanInterval `db:"billing_interval" json:"interval" validate:"required"`
+	Price       int64        `db:"price" json:"price" validate:"required"`
+	Currency    string       `db:"currency" json:"currency"`
+	GraceDays   int          `db:"grace_days" json:"graceDays"`
+	Active      bool         `db:"active" json:"active"`
+} // @name Plan
+
+type NewPlan struct {
+	Name        string       `db:"name" json:"name" validate:"required"`
+	Description string       `db:"description" json:"description"`
+	Interval    PlanInterval `db:"billing_interval" json:"interval" validate:"required"`
+	Price       int64        `db:"price" json:"price" validate:"required"`
+	Currency    string       `db:"currency" json:"currency"`
+	// GraceDays is how long access continues after an unpaid period ends.
+	GraceDays int `db:"grace_days" json:"graceDays"`
+} // @name NewPlan
+
+type SubscriptionStatus string
+
+const (
+	// SubscriptionPending is waiting for the payment of the first period.
+	SubscriptionPending SubscriptionStatus = "pending"
+	SubscriptionActive  SubscriptionStatus = "active"
+	// SubscriptionPastDue is a subscription whose period ended without renewal but is still in
+	// the grace period of its plan.
+	SubscriptionPastDue SubscriptionStatus = "past_due"
+	SubscriptionExpired SubscriptionStatus = "expired"
+	// SubscriptionEnded is a subscription ended before its period was over, by a refund.
+	SubscriptionEnded SubscriptionStatus = "ended"
+)
+
+type Subscription struct {
+	ID                 uuid.UUID          `db:"id" json:"id" validate:"required"`
+	CreatedAt          time.Time          `db:"created_at" json:"createdAt"`
+	UserID             uuid.UUID          `db:"user_id" json:"userId" validate:"required"`
+	Plan               Plan               `json:"plan" validate:"required"`
+	Status             SubscriptionStatus `db:"status" json:"status" validate:"required"`
+	CurrentPeriodStart *time.Time         `db:"current_period_start" json:"currentPeriodStart"`
+	CurrentPeriodEnd   *time.Time         `db:"current_period_end" json:"currentPeriodEnd"`
+	GraceUntil         *time.Time         `json:"graceUntil"`
+	CancelAtPeriodEnd  bool               `db:"cancel_at_period_end" json:"cancelAtPeriodEnd"`
+	CancelledAt        *time.Time         `db:"cancelled_at" json:"cancelledAt"`
+} // @name Subscription
+
+// State returns the status of the subscription at the given time. Only pending, active and ended
+// are stored, past due and expired follow from the period end and the grace period of the plan;
+// a subscription cancelled at period end gets no grace period.
+func (s Subscription) State(now time.Time) SubscriptionStatus {
+	if s.Status != SubscriptionActive || s.CurrentPeriodEnd == nil {
+		return s.Status
+	}
+
+	switch {
+	case now.Before(*s.CurrentPeriodEnd):
+		return SubscriptionActive
+	case !s.CancelAtPeriodEnd && now.Before(s.CurrentPeriodEnd.AddDate(0, 0, s.Plan.GraceDays)):
+		return SubscriptionPastDue
+	default:
+		return SubscriptionExpired
+	}
+}
+
+// GrantsAccess reports whether the subscription unlocks the catalog at the given time.
+func (s Subscription) GrantsAccess(now time.Time) bool {
+	state := s.State(now)
+	return state == SubscriptionActive || state == SubscriptionPastDue
+}
@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type UploadStatus string
+
+const (
+	UploadPending UploadStatus = "pending"
+	// UploadVerifying is set while the completion checks the uploaded file.
+	UploadVerifying UploadStatus = "verifying"
+	UploadCompleted UploadStatus = "completed"
+	// UploadFailed is set when the uploaded file did not match the declared size or checksum.
+	UploadFailed  UploadStatus = "failed"
+	UploadAborted UploadStatus = "aborted"
+	UploadExpired UploadStatus = "expired"
+)
+
+// Upload is a file sent by the client straight to the storage, it becomes an attachment of the
+// course once completed. Checksum is the hex encoded SHA-256 of the file; for a file sent in parts
+// it is the SHA-256 of the concatenated digests of PartChecksums, which is what the storage keeps.
+type Upload struct {
+	ID            uuid.UUID    `db:"id" json:"id" validate:"required"`
+	CreatedAt     time.Time    `db:"created_at" json:"createdAt" validate:"required"`
+	UpdatedAt     time.Time    `db:"updated_at" json:"updatedAt" validate:"required"`
+	CreatedBy     uuid.UUID    `db:"created_by" json:"createdBy" validate:"required"`
+	CourseID      uuid.UUID    `db:"course_id" json:"courseId" validate:"required"`
+	Key           string       `db:"storage_key" json:"key" validate:"required"`
+	Filename      string       `db:"filename" json:"filename" validate:"required"`
+	ContentType   string       `db:"content_type" json:"contentType" validate:"required"`
+	Size          int64        `db:"size" json:"size" validate:"required"`
+	Checksum      string       `db:"checksum" json:"checksum" validate:"required"`
+	MultipartID   string       `db:"multipart_upload_id" json:"-"`
+	PartSize      int64        `db:"part_size" json:"partSize"`
+	PartChecksums []string     `db:"part_checksums" json:"partChecksums,omitempty"`
+	Status        UploadStatus `db:"status" json:"status" validate:"required"`
+	ExpiresAt     time.Time    `db:"expires_at" json:"expiresAt" validate:"required"`
+	CompletedAt   *time.Time   `db:"completed_at" json:"completedAt"`
+} // @name Upload
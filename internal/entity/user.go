@@ -21,6 +21,9 @@ type User struct {
 	Phone     string    `db:"phone" json:"phone" validate:"required"`
 	Password  *string   `db:"password" json:"password"`
 	Role      Role      `db:"role" json:"role"`
+	Locale    string    `db:"locale" json:"locale"`
+
+	EmailVerifiedAt *time.Time `db:"email_verified_at" json:"emailVerifiedAt"`
 } // @name User
 
 type NewUser struct {
@@ -29,6 +32,7 @@ type NewUser struct {
 	Phone    string `db:"phone" json:"phone" validate:"required"`
 	Password string `db:"password" json:"password" validate:"required"`
 	Role     Role   `db:"role" json:"role" validate:"required"`
+	Locale   string `db:"locale" json:"locale"`
 } // @name NewUser
 
 type UserUpdateBody struct {
@@ -38,6 +42,7 @@ type UserUpdateBody struct {
 	Phone    *string   `db:"phone" json:"phone"`
 	Role     *Role     `db:"role" json:"role"`
 	Password *string   `db:"password" json:"password"`
+	Locale   *string   `db:"locale" json:"locale"`
 } // @name UserUpdateBody
 
 type UserFilters struct {
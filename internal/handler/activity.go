@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/AlnurZhanibek/kazusa-server/internal/service"
 	"github.com/google/uuid"
 	"net/http"
@@ -35,6 +36,10 @@ type NewActivity struct {
 //	@Param			request		body	NewActivity	true	"new activity body"
 //	@Success		200			{boolean} boolean ok
 //	@Failure		400			{boolean} boolean ok
+//	@Failure		402			{boolean} boolean ok
+//	@Failure		403			{boolean} boolean ok
+//	@Failure		404			{boolean} boolean ok
+//	@Failure		422			{boolean} boolean ok
 //	@Router			/activity [post]
 func (h *ActivityHandler) Create(w http.ResponseWriter, r *http.Request) {
 	newActivity := new(NewActivity)
@@ -45,12 +50,15 @@ func (h *ActivityHandler) Create(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
 		return
 	}
+	if !service.IsAdmin(r.Context()) {
+		newActivity.UserID, _ = service.UserIDFromContext(r.Context())
+	}
 	if newActivity.UserID == uuid.Nil || newActivity.CourseID == uuid.Nil || newActivity.ModuleID == uuid.Nil {
 		http.Error(w, "user_id, course_id or module_id is empty!", http.StatusUnprocessableEntity)
 		return
 	}
 
-	err = h.service.Create(&service.ActivityCreateBody{
+	err = h.service.Create(r.Context(), &service.ActivityCreateBody{
 		UserID:       newActivity.UserID,
 		UserEmail:    newActivity.UserEmail,
 		UserFullname: newActivity.UserFullname,
@@ -60,7 +68,18 @@ func (h *ActivityHandler) Create(w http.ResponseWriter, r *http.Request) {
 		IsLast:       newActivity.IsLast,
 	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, service.ErrPaymentRequired):
+			http.Error(w, err.Error(), http.StatusPaymentRequired)
+		case errors.Is(err, service.ErrEnrollmentRequired):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, service.ErrCourseNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrModuleNotInCourse):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
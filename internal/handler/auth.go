@@ -7,6 +7,8 @@ import (
 	"time"
 )
 
+const refreshTokenCookie = "refresh_token"
+
 type AuthHandler struct {
 	service *service.AuthService
 }
@@ -23,8 +25,9 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	Token string `json:"token"`
-	Error string `json:"error,omitempty"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	Error        string `json:"error,omitempty"`
 }
 
 // Login example
@@ -45,35 +48,28 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&credentials)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		w.WriteHeader(http.StatusUnprocessableEntity)
 		encoder.Encode(LoginResponse{
-			Token: "",
 			Error: err.Error(),
 		})
+		return
 	}
 
-	token, err := h.service.Login(credentials.Email, credentials.Password)
-
+	tokens, err := h.service.Login(credentials.Email, credentials.Password)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		w.WriteHeader(http.StatusBadRequest)
 		encoder.Encode(LoginResponse{
-			Token: "",
 			Error: "service error",
 		})
+		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "token",
-		Value:    token,
-		Path:     "/",
-		Expires:  time.Now().Add(time.Hour * 24),
-		Secure:   true,
-		HttpOnly: true,
-	})
+	setTokenCookies(w, tokens)
 
 	encoder.Encode(LoginResponse{
-		Token: token,
-		Error: "",
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		Error:        "",
 	})
 }
 
@@ -83,11 +79,13 @@ type RegisterRequest struct {
 	Phone                string `json:"phone"`
 	Password             string `json:"password"`
 	PasswordConfirmation string `json:"passwordConfirmation"`
+	Locale               string `json:"locale"`
 }
 
 type RegisterResponse struct {
-	Token string `json:"token"`
-	Error string `json:"error,omitempty"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	Error        string `json:"error,omitempty"`
 }
 
 // Register example
@@ -110,41 +108,178 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&credentials)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		w.WriteHeader(http.StatusUnprocessableEntity)
 		encoder.Encode(RegisterResponse{
-			Token: "",
 			Error: "decode error",
 		})
+		return
 	}
 
-	token, err := h.service.Register(credentials.Name, credentials.Email, credentials.Phone, credentials.Password, credentials.PasswordConfirmation)
-
+	tokens, err := h.service.Register(r.Context(), credentials.Name, credentials.Email, credentials.Phone, credentials.Password, credentials.PasswordConfirmation, credentials.Locale)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		w.WriteHeader(http.StatusBadRequest)
 		encoder.Encode(RegisterResponse{
-			Token: "",
 			Error: "token gen error",
 		})
+		return
 	}
 
+	setTokenCookies(w, tokens)
+
+	encoder.Encode(RegisterResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		Error:        "",
+	})
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// Refresh example
+//
+//	@Summary		Refresh tokens
+//	@Description	exchange a refresh token (body or refresh_token cookie) for a new token pair
+//	@ID				token.refresh
+//	@Accept			json
+//	@Produce		json
+//	@Param			request		body	 	handler.RefreshRequest 	false 	"refresh body"
+//	@Success		200			{object}	handler.LoginResponse
+//	@Failure		401			{object}	handler.LoginResponse
+//	@Router			/token/refresh [post]
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	encoder := json.NewEncoder(w)
+
+	refreshToken := refreshTokenFromRequest(r)
+	if refreshToken == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		encoder.Encode(LoginResponse{
+			Error: "refresh token is empty",
+		})
+		return
+	}
+
+	tokens, err := h.service.Refresh(refreshToken)
+	if err != nil {
+		clearTokenCookies(w)
+		w.WriteHeader(http.StatusUnauthorized)
+		encoder.Encode(LoginResponse{
+			Error: "invalid refresh token",
+		})
+		return
+	}
+
+	setTokenCookies(w, tokens)
+
+	encoder.Encode(LoginResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		Error:        "",
+	})
+}
+
+// Logout example
+//
+//	@Summary		Logout
+//	@Description	revoke the session of the refresh token (body or refresh_token cookie)
+//	@ID				logout
+//	@Accept			json
+//	@Produce		json
+//	@Param			request		body	 	handler.RefreshRequest 	false 	"logout body"
+//	@Success		200			{boolean} 	boolean ok
+//	@Failure		500			{boolean} 	boolean ok
+//	@Router			/logout [post]
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	refreshToken := refreshTokenFromRequest(r)
+	if refreshToken != "" {
+		err := h.service.Logout(refreshToken)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	clearTokenCookies(w)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(true)
+}
+
+// LogoutAll example
+//
+//	@Summary		Logout everywhere
+//	@Description	revoke every session of the current user
+//	@ID				logout.all
+//	@Produce		json
+//	@Success		200			{boolean} 	boolean ok
+//	@Failure		500			{boolean} 	boolean ok
+//	@Router			/logout/all [post]
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID, ok := service.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	err := h.service.LogoutAll(userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	clearTokenCookies(w)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(true)
+}
+
+func refreshTokenFromRequest(r *http.Request) string {
+	body := RefreshRequest{}
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}
+	if body.RefreshToken != "" {
+		return body.RefreshToken
+	}
+
+	cookie, err := r.Cookie(refreshTokenCookie)
+	if err == nil {
+		return cookie.Value
+	}
+
+	return ""
+}
+
+func setTokenCookies(w http.ResponseWriter, tokens *service.Tokens) {
 	http.SetCookie(w, &http.Cookie{
 		Name:     "token",
-		Value:    token,
-		Expires:  time.Now().Add(time.Hour * 24),
+		Value:    tokens.AccessToken,
 		Path:     "/",
+		Expires:  tokens.AccessExpiresAt,
 		Secure:   true,
 		HttpOnly: true,
 	})
-
-	encoder.Encode(RegisterResponse{
-		Token: token,
-		Error: "",
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshTokenCookie,
+		Value:    tokens.RefreshToken,
+		Path:     "/",
+		Expires:  tokens.RefreshExpiresAt,
+		Secure:   true,
+		HttpOnly: true,
 	})
 }
 
-func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
-	http.SetCookie(w, &http.Cookie{
-		Name:  "token",
-		Value: "",
-	})
+func clearTokenCookies(w http.ResponseWriter) {
+	for _, name := range []string{"token", refreshTokenCookie} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     name,
+			Value:    "",
+			Path:     "/",
+			Expires:  time.Unix(0, 0),
+			MaxAge:   -1,
+			Secure:   true,
+			HttpOnly: true,
+		})
+	}
 }
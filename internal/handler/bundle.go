@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/AlnurZhanibek/kazusa-server/internal/service"
+	"github.com/google/uuid"
+	"net/http"
+	"strconv"
+)
+
+type BundleHandler struct {
+	service *service.BundleService
+}
+
+func NewBundleHandler(service *service.BundleService) *BundleHandler {
+	return &BundleHandler{service: service}
+}
+
+// Create bundle
+//
+//	@Summary		Create bundle
+//	@Description	create a bundle of courses sold together for its own price
+//	@ID				bundle.create
+//	@Accept			json
+//	@Produce		json
+//	@Param			request		body		entity.NewBundle	true "new bundle body"
+//	@Success		200			{string}	string id
+//	@Failure		422			{boolean} boolean ok
+//	@Router			/bundle [post]
+func (h *BundleHandler) Create(w http.ResponseWriter, r *http.Request) {
+	newBundle := entity.NewBundle{}
+
+	err := json.NewDecoder(r.Body).Decode(&newBundle)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	id, err := h.service.Create(newBundle)
+	if errors.Is(err, service.ErrInvalidBundle) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(id)
+}
+
+// Read bundles
+//
+//	@Summary		Read bundles
+//	@Description	read bundles on sale, admins get the deactivated ones too with all=true
+//	@ID				bundle.read
+//	@Produce		json
+//	@Param			offset		query		int64	true "offset"
+//	@Param			limit		query		int64	true "limit"
+//	@Param			all			query		bool	false "include deactivated bundles, admins only"
+//	@Success		200			{array}		entity.Bundle
+//	@Failure		500			{boolean} boolean ok
+//	@Router			/bundle [get]
+func (h *BundleHandler) Read(w http.ResponseWriter, r *http.Request) {
+	offset, _ := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	limit, _ := strconv.ParseInt(r.URL.Query().Get("limit"), 10, 64)
+	all, _ := strconv.ParseBool(r.URL.Query().Get("all"))
+
+	bundles, err := h.service.Read(entity.Pagination{
+		Offset: offset,
+		Limit:  limit,
+	}, all && service.IsAdmin(r.Context()))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(bundles)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Delete bundle
+//
+//	@Summary		Deactivate bundle
+//	@Description	take the bundle off sale, orders already made keep their access
+//	@ID				bundle.delete
+//	@Produce		json
+//	@Param			id			query		string	true "id"
+//	@Success		200			{boolean} boolean ok
+//	@Failure		422			{boolean} boolean ok
+//	@Router			/bundle [delete]
+func (h *BundleHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "bundle handler error: id is invalid!", http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = h.service.Delete(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(true)
+}
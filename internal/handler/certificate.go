@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/AlnurZhanibek/kazusa-server/internal/service"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type CertificateHandler struct {
+	service *service.CertificateService
+}
+
+func NewCertificateHandler(service *service.CertificateService) *CertificateHandler {
+	return &CertificateHandler{service: service}
+}
+
+// Read certificates
+//
+//	@Summary		Read my certificates
+//	@Description	read certificates of the current user
+//	@ID				certificate.read
+//	@Produce		json
+//	@Success		200			{array}		service.Certificate
+//	@Failure		401			{boolean} 	boolean ok
+//	@Router			/certificate [get]
+func (h *CertificateHandler) Read(w http.ResponseWriter, r *http.Request) {
+	userID, ok := service.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	certificates, err := h.service.Read(userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(certificates)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+type CertificateVerification struct {
+	Valid       bool      `json:"valid"`
+	Serial      string    `json:"serial"`
+	LearnerName string    `json:"learnerName,omitempty"`
+	CourseTitle string    `json:"courseTitle,omitempty"`
+	IssuedAt    time.Time `json:"issuedAt,omitempty"`
+} // @name CertificateVerification
+
+// Verify certificate
+//
+//	@Summary		Verify certificate
+//	@Description	confirm that a certificate with the serial was issued, no login required
+//	@ID				certificate.verify
+//	@Produce		json
+//	@Param			serial		path		string	true "certificate serial"
+//	@Success		200			{object}	CertificateVerification
+//	@Failure		404			{object}	CertificateVerification
+//	@Router			/certificate/verify/{serial} [get]
+func (h *CertificateHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	serial := strings.ToUpper(strings.TrimPrefix(r.URL.Path, "/certificate/verify/"))
+	if serial == "" {
+		http.Error(w, "serial is empty!", http.StatusUnprocessableEntity)
+		return
+	}
+
+	certificate, err := h.service.Verify(serial)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	verification := CertificateVerification{
+		Valid:  certificate != nil,
+		Serial: serial,
+	}
+	if certificate != nil {
+		verification.LearnerName = certificate.LearnerName
+		verification.CourseTitle = certificate.CourseTitle
+		verification.IssuedAt = certificate.IssuedAt
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if certificate == nil {
+		w.WriteHeader(http.StatusNotFound)
+	}
+	err = json.NewEncoder(w).Encode(verification)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/AlnurZhanibek/kazusa-server/internal/service"
+	"github.com/google/uuid"
+	"net/http"
+	"strconv"
+)
+
+type CouponHandler struct {
+	service *service.CouponService
+}
+
+func NewCouponHandler(service *service.CouponService) *CouponHandler {
+	return &CouponHandler{service: service}
+}
+
+// Create coupon
+//
+//	@Summary		Create coupon
+//	@Description	create a percent or fixed discount coupon for a course, or sitewide when course id is empty
+//	@ID				coupon.create
+//	@Accept			json
+//	@Produce		json
+//	@Param			request		body		entity.NewCoupon	true "new coupon body"
+//	@Success		200			{boolean} boolean ok
+//	@Failure		422			{boolean} boolean ok
+//	@Router			/coupon [post]
+func (h *CouponHandler) Create(w http.ResponseWriter, r *http.Request) {
+	newCoupon := entity.NewCoupon{}
+
+	err := json.NewDecoder(r.Body).Decode(&newCoupon)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = h.service.Create(newCoupon)
+	if errors.Is(err, service.ErrInvalidCoupon) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(true)
+}
+
+// Read coupons
+//
+//	@Summary		Read coupons
+//	@Description	read coupons with the number of paid orders that used them
+//	@ID				coupon.read
+//	@Produce		json
+//	@Param			offset		query		int64	true "offset"
+//	@Param			limit		query		int64	true "limit"
+//	@Success		200			{array}		entity.Coupon
+//	@Failure		500			{boolean} boolean ok
+//	@Router			/coupon [get]
+func (h *CouponHandler) Read(w http.ResponseWriter, r *http.Request) {
+	offset, _ := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	limit, _ := strconv.ParseInt(r.URL.Query().Get("limit"), 10, 64)
+
+	coupons, err := h.service.Read(entity.Pagination{
+		Offset: offset,
+		Limit:  limit,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(coupons)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Delete coupon
+//
+//	@Summary		Deactivate coupon
+//	@Description	deactivate coupon, it can no longer be applied
+//	@ID				coupon.delete
+//	@Produce		json
+//	@Param			id			query		string	true "id"
+//	@Success		200			{boolean} boolean ok
+//	@Failure		422			{boolean} boolean ok
+//	@Router			/coupon [delete]
+func (h *CouponHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "coupon handler error: id is invalid!", http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = h.service.Delete(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(true)
+}
+
+type CouponPreviewRequest struct {
+	CourseID uuid.UUID `json:"courseId"`
+	Code     string    `json:"code"`
+} // @name CouponPreviewRequest
+
+// Preview coupon
+//
+//	@Summary		Preview discounted price
+//	@Description	price the course for the current user with the coupon applied
+//	@ID				coupon.preview
+//	@Accept			json
+//	@Produce		json
+//	@Param			request		body		handler.CouponPreviewRequest	true "preview body"
+//	@Success		200			{object}	service.Quote
+//	@Failure		404			{boolean} boolean ok
+//	@Failure		422			{boolean} boolean ok
+//	@Router			/coupon/preview [post]
+func (h *CouponHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	userID, ok := service.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	body := CouponPreviewRequest{}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil || body.CourseID == uuid.Nil {
+		http.Error(w, "course id is empty!", http.StatusUnprocessableEntity)
+		return
+	}
+
+	quote, err := h.service.Preview(userID, body.CourseID, body.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrCourseNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case isCouponError(err):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(quote)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func isCouponError(err error) bool {
+	return errors.Is(err, service.ErrCouponNotFound) || errors.Is(err, service.ErrCouponNotApplicable) || errors.Is(err, service.ErrCouponExhausted)
+}
@@ -1,16 +1,15 @@
 package handler
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
 	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
 	"github.com/AlnurZhanibek/kazusa-server/internal/service"
-	"github.com/dgrijalva/jwt-go"
 	"github.com/google/uuid"
 	"mime/multipart"
 	"net/http"
-	"os"
 	"strconv"
+	"strings"
 )
 
 type CourseHandlerImplementation interface {
@@ -18,6 +17,7 @@ type CourseHandlerImplementation interface {
 	Read(w http.ResponseWriter, r *http.Request)
 	Update(w http.ResponseWriter, r *http.Request)
 	Delete(w http.ResponseWriter, r *http.Request)
+	Attachment(w http.ResponseWriter, r *http.Request)
 }
 
 type CourseHandler struct {
@@ -32,6 +32,7 @@ type CourseCreateBody struct {
 	Title       string
 	Description string
 	Price       int64
+	Currency    string
 	Cover       service.FileWithHeader
 	Attachments []service.FileWithHeader
 }
@@ -46,6 +47,7 @@ type CourseCreateBody struct {
 //	@Param			title formData string true "title"
 //	@Param			description formData string	true "description"
 //	@Param			price formData number true "price"
+//	@Param			currency formData string false "currency, KZT by default"
 //	@Param			cover formData file	true "cover"
 //	@Param			attachments formData file true "cover"
 //	@Success		200 {boolean} boolean ok
@@ -83,11 +85,19 @@ func (h *CourseHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	newCourse.Price = int64(priceInt)
+	newCourse.Currency = strings.ToUpper(r.FormValue("currency"))
+	if newCourse.Currency == "" {
+		newCourse.Currency = entity.DefaultCurrency
+	}
 	newCourse.Cover = cover
 	newCourse.Attachments = attachments
 
-	if newCourse.Title == "" || newCourse.Description == "" || newCourse.Price == 0 {
-		http.Error(w, "title, description or price is empty!", http.StatusUnprocessableEntity)
+	if newCourse.Title == "" || newCourse.Description == "" {
+		http.Error(w, "title or description is empty!", http.StatusUnprocessableEntity)
+		return
+	}
+	if newCourse.Price < 0 {
+		http.Error(w, "price can not be negative", http.StatusUnprocessableEntity)
 		return
 	}
 
@@ -95,6 +105,7 @@ func (h *CourseHandler) Create(w http.ResponseWriter, r *http.Request) {
 		Title:       newCourse.Title,
 		Description: newCourse.Description,
 		Price:       newCourse.Price,
+		Currency:    newCourse.Currency,
 		Cover:       newCourse.Cover,
 		Attachments: newCourse.Attachments,
 	})
@@ -124,6 +135,7 @@ func (h *CourseHandler) Create(w http.ResponseWriter, r *http.Request) {
 //	@Param			id			query		string	false "id"
 //	@Success		200			{array}	entity.Course
 //	@Failure		404			{boolean} boolean ok
+//	@Failure		422			{boolean} boolean ok
 //	@Router			/course [get]
 func (h *CourseHandler) Read(w http.ResponseWriter, r *http.Request) {
 	offset, _ := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
@@ -136,26 +148,15 @@ func (h *CourseHandler) Read(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if id != "" {
-		filters.ID = uuid.MustParse(id)
-	}
-
-	ctx := r.Context()
-
-	tokenCookie, err := r.Cookie("token")
-	if tokenCookie != nil {
-		token := new(jwt.Token)
-		token, err = jwt.ParseWithClaims(tokenCookie.Value, &service.Claims{}, func(token *jwt.Token) (interface{}, error) {
-			return []byte(os.Getenv("JWT_KEY")), nil
-		})
-		claims, ok := token.Claims.(*service.Claims)
-		if !ok || !token.Valid {
-			http.Error(w, "error parsing jwt token", http.StatusBadRequest)
-
+		var err error
+		filters.ID, err = uuid.Parse(id)
+		if err != nil {
+			http.Error(w, "course handler error: error parsing id", http.StatusUnprocessableEntity)
+			return
 		}
-		ctx = context.WithValue(ctx, "user_id", claims.UserID)
 	}
 
-	courses, err := h.service.Read(ctx, entity.Pagination{
+	courses, err := h.service.Read(r.Context(), entity.Pagination{
 		Offset: offset,
 		Limit:  limit,
 	}, filters)
@@ -254,3 +255,48 @@ func (h *CourseHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// Attachment
+//
+//	@Summary		Download course attachment
+//	@Description	redirect to a short lived download URL of the nth attachment of the course, counting from zero, for enrolled users and admins
+//	@ID				course.attachment
+//	@Param			id			path		string	true "course id"
+//	@Param			n			path		int		true "attachment number"
+//	@Success		302
+//	@Failure		402			{boolean} boolean ok
+//	@Failure		403			{boolean} boolean ok
+//	@Failure		404			{boolean} boolean ok
+//	@Failure		422			{boolean} boolean ok
+//	@Router			/course/{id}/attachments/{n} [get]
+func (h *CourseHandler) Attachment(w http.ResponseWriter, r *http.Request) {
+	courseID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "course handler error: error parsing id", http.StatusUnprocessableEntity)
+		return
+	}
+
+	n, err := strconv.Atoi(r.PathValue("n"))
+	if err != nil {
+		http.Error(w, "course handler error: error parsing attachment number", http.StatusUnprocessableEntity)
+		return
+	}
+
+	url, err := h.service.Attachment(r.Context(), courseID, n)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrCourseNotFound), errors.Is(err, service.ErrAttachmentNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrPaymentRequired):
+			http.Error(w, err.Error(), http.StatusPaymentRequired)
+		case errors.Is(err, service.ErrEnrollmentRequired):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, url, http.StatusFound)
+}
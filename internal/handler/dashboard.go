@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/AlnurZhanibek/kazusa-server/internal/service"
+	"net/http"
+)
+
+type DashboardHandler struct {
+	service *service.DashboardService
+}
+
+func NewDashboardHandler(service *service.DashboardService) *DashboardHandler {
+	return &DashboardHandler{service: service}
+}
+
+// Courses of the learner
+//
+//	@Summary		Read my courses
+//	@Description	read the paid courses of the current user with progress and the module to resume
+//	@ID				me.courses
+//	@Produce		json
+//	@Success		200			{array}		service.LearnerCourse
+//	@Failure		401			{boolean} 	boolean ok
+//	@Router			/me/courses [get]
+func (h *DashboardHandler) Courses(w http.ResponseWriter, r *http.Request) {
+	userID, ok := service.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	courses, err := h.service.Courses(userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(courses)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/AlnurZhanibek/kazusa-server/internal/service"
+	"net/http"
+)
+
+type EmailVerificationHandler struct {
+	service *service.EmailVerificationService
+}
+
+func NewEmailVerificationHandler(service *service.EmailVerificationService) *EmailVerificationHandler {
+	return &EmailVerificationHandler{service: service}
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+} // @name VerifyEmailRequest
+
+// Verify email
+//
+//	@Summary		Verify email
+//	@Description	confirm the email of a user with the token from the verification link
+//	@ID				email.verify
+//	@Accept			json
+//	@Produce		json
+//	@Param			request		body		VerifyEmailRequest	true "verify email body"
+//	@Success		200			{boolean} boolean ok
+//	@Failure		400			{boolean} boolean ok
+//	@Router			/verify-email [post]
+func (h *EmailVerificationHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	body := VerifyEmailRequest{}
+
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if body.Token == "" {
+		http.Error(w, "token is empty!", http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = h.service.Verify(body.Token)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Resend verification email
+//
+//	@Summary		Resend verification email
+//	@Description	send a new verification link to the current user, throttled per user
+//	@ID				email.verify.resend
+//	@Produce		json
+//	@Success		200			{boolean} boolean ok
+//	@Failure		409			{boolean} boolean ok
+//	@Failure		429			{boolean} boolean ok
+//	@Router			/verify-email/resend [post]
+func (h *EmailVerificationHandler) Resend(w http.ResponseWriter, r *http.Request) {
+	userID, ok := service.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	err := h.service.Resend(r.Context(), userID)
+	if errors.Is(err, service.ErrResendThrottled) {
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	}
+	if errors.Is(err, service.ErrEmailAlreadyVerified) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/AlnurZhanibek/kazusa-server/internal/repository"
+	"github.com/AlnurZhanibek/kazusa-server/internal/service"
+	"github.com/google/uuid"
+	"net/http"
+	"strconv"
+)
+
+type EnrollmentHandler struct {
+	service *service.EnrollmentService
+}
+
+func NewEnrollmentHandler(service *service.EnrollmentService) *EnrollmentHandler {
+	return &EnrollmentHandler{service: service}
+}
+
+type EnrollRequest struct {
+	CourseID uuid.UUID `json:"courseId"`
+} // @name EnrollRequest
+
+// Enroll
+//
+//	@Summary		Enroll in free course
+//	@Description	enroll the current user in a course that costs nothing, paid courses are bought with an order
+//	@ID				enrollment.create
+//	@Accept			json
+//	@Produce		json
+//	@Param			request		body		handler.EnrollRequest	true "enroll body"
+//	@Success		200			{boolean} boolean ok
+//	@Failure		402			{boolean} boolean ok
+//	@Failure		404			{boolean} boolean ok
+//	@Router			/enrollment [post]
+func (h *EnrollmentHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	userID, ok := service.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	body := EnrollRequest{}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil || body.CourseID == uuid.Nil {
+		http.Error(w, "course id is empty!", http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = h.service.EnrollFree(userID, body.CourseID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrCourseNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrCourseNotFree):
+			http.Error(w, err.Error(), http.StatusPaymentRequired)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(true)
+}
+
+// Read
+//
+//	@Summary		Read enrollments
+//	@Description	read enrollments of a user or a course, including revoked ones unless active=true
+//	@ID				enrollment.read
+//	@Produce		json
+//	@Param			offset		query		int64	true "offset"
+//	@Param			limit		query		int64	true "limit"
+//	@Param			userId		query		string	false "user id"
+//	@Param			courseId	query		string	false "course id"
+//	@Param			active		query		bool	false "only enrollments that are not revoked"
+//	@Success		200			{array}		entity.Enrollment
+//	@Failure		500			{boolean} boolean ok
+//	@Router			/enrollment [get]
+func (h *EnrollmentHandler) Read(w http.ResponseWriter, r *http.Request) {
+	offset, _ := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	limit, _ := strconv.ParseInt(r.URL.Query().Get("limit"), 10, 64)
+	active, _ := strconv.ParseBool(r.URL.Query().Get("active"))
+
+	filters := repository.EnrollmentFilters{ActiveOnly: active}
+	if userID, err := uuid.Parse(r.URL.Query().Get("userId")); err == nil {
+		filters.UserID = &userID
+	}
+	if courseID, err := uuid.Parse(r.URL.Query().Get("courseId")); err == nil {
+		filters.CourseID = &courseID
+	}
+
+	enrollments, err := h.service.Read(entity.Pagination{
+		Offset: offset,
+		Limit:  limit,
+	}, filters)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(enrollments)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Grant
+//
+//	@Summary		Enroll users
+//	@Description	enroll every user in every course as an admin grant
+//	@ID				enrollment.grant
+//	@Accept			json
+//	@Produce		json
+//	@Param			request		body		service.BulkEnrollment	true "bulk enrollment body"
+//	@Success		200			{object}	service.BulkEnrollmentResult
+//	@Failure		404			{boolean} boolean ok
+//	@Failure		422			{boolean} boolean ok
+//	@Router			/enrollment/grant [post]
+func (h *EnrollmentHandler) Grant(w http.ResponseWriter, r *http.Request) {
+	body := service.BulkEnrollment{}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	result, err := h.service.Grant(r.Context(), body)
+	h.writeBulkResult(w, result, err)
+}
+
+// Revoke
+//
+//	@Summary		Unenroll users
+//	@Description	unenroll every user from every course whatever the enrollment came from, payments are not refunded
+//	@ID				enrollment.revoke
+//	@Accept			json
+//	@Produce		json
+//	@Param			request		body		service.BulkEnrollment	true "bulk enrollment body"
+//	@Success		200			{object}	service.BulkEnrollmentResult
+//	@Failure		422			{boolean} boolean ok
+//	@Router			/enrollment/revoke [post]
+func (h *EnrollmentHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	body := service.BulkEnrollment{}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	result, err := h.service.Revoke(r.Context(), body)
+	h.writeBulkResult(w, result, err)
+}
+
+func (h *EnrollmentHandler) writeBulkResult(w http.ResponseWriter, result *service.BulkEnrollmentResult, err error) {
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidEnrollment):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		case errors.Is(err, service.ErrCourseNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(result)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
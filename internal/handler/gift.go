@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/AlnurZhanibek/kazusa-server/internal/service"
+	"github.com/google/uuid"
+	"net/http"
+	"strconv"
+)
+
+type GiftHandler struct {
+	service *service.GiftService
+}
+
+func NewGiftHandler(service *service.GiftService) *GiftHandler {
+	return &GiftHandler{service: service}
+}
+
+type RedeemRequest struct {
+	Code string `json:"code"`
+} // @name RedeemRequest
+
+// Redeem
+//
+//	@Summary		Redeem access code
+//	@Description	enroll the current user in the course of a gift or batch access code, a code can be redeemed once
+//	@ID				gift.redeem
+//	@Accept			json
+//	@Produce		json
+//	@Param			request		body		handler.RedeemRequest	true "redeem body"
+//	@Success		200			{object}	service.Redemption
+//	@Failure		404			{boolean} boolean ok
+//	@Failure		409			{boolean} boolean ok
+//	@Failure		410			{boolean} boolean ok
+//	@Router			/redeem [post]
+func (h *GiftHandler) Redeem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := service.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	body := RedeemRequest{}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil || body.Code == "" {
+		http.Error(w, "code is empty!", http.StatusUnprocessableEntity)
+		return
+	}
+
+	redemption, err := h.service.Redeem(userID, body.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrCodeNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrCodeRedeemed), errors.Is(err, service.ErrAlreadyEnrolled):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, service.ErrCodeExpired):
+			http.Error(w, err.Error(), http.StatusGone)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(redemption)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Gifts
+//
+//	@Summary		Read my gifts
+//	@Description	read the access codes of the gifts the current user bought, with whether they were redeemed
+//	@ID				gift.mine
+//	@Produce		json
+//	@Param			offset		query		int64	true "offset"
+//	@Param			limit		query		int64	true "limit"
+//	@Success		200			{array}		entity.AccessCode
+//	@Failure		500			{boolean} boolean ok
+//	@Router			/me/gifts [get]
+func (h *GiftHandler) Gifts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := service.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	offset, _ := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	limit, _ := strconv.ParseInt(r.URL.Query().Get("limit"), 10, 64)
+
+	codes, err := h.service.Gifts(entity.Pagination{Offset: offset, Limit: limit}, userID)
+	h.writeJSON(w, codes, err)
+}
+
+// CreateBatch
+//
+//	@Summary		Create code batch
+//	@Description	generate a batch of single use access codes for a course, for example for a corporate client
+//	@ID				gift.batch.create
+//	@Accept			json
+//	@Produce		json
+//	@Param			request		body		entity.NewCodeBatch	true "code batch body"
+//	@Success		200			{object}	entity.CodeBatch
+//	@Failure		404			{boolean} boolean ok
+//	@Failure		422			{boolean} boolean ok
+//	@Router			/code-batch [post]
+func (h *GiftHandler) CreateBatch(w http.ResponseWriter, r *http.Request) {
+	body := entity.NewCodeBatch{}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil || body.CourseID == uuid.Nil {
+		http.Error(w, "course id is empty!", http.StatusUnprocessableEntity)
+		return
+	}
+
+	batch, err := h.service.CreateBatch(r.Context(), body)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCodeBatch):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		case errors.Is(err, service.ErrCourseNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.writeJSON(w, batch, nil)
+}
+
+// ReadBatches
+//
+//	@Summary		Read code batches
+//	@Description	read code batches with how many of their codes were redeemed
+//	@ID				gift.batch.read
+//	@Produce		json
+//	@Param			offset		query		int64	true "offset"
+//	@Param			limit		query		int64	true "limit"
+//	@Param			courseId	query		string	false "course id"
+//	@Success		200			{array}		entity.CodeBatch
+//	@Failure		500			{boolean} boolean ok
+//	@Router			/code-batch [get]
+func (h *GiftHandler) ReadBatches(w http.ResponseWriter, r *http.Request) {
+	offset, _ := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	limit, _ := strconv.ParseInt(r.URL.Query().Get("limit"), 10, 64)
+
+	var courseID *uuid.UUID
+	if id, err := uuid.Parse(r.URL.Query().Get("courseId")); err == nil {
+		courseID = &id
+	}
+
+	batches, err := h.service.ReadBatches(entity.Pagination{Offset: offset, Limit: limit}, courseID)
+	h.writeJSON(w, batches, err)
+}
+
+// ReadCodes
+//
+//	@Summary		Read batch codes
+//	@Description	read the codes of a batch with who redeemed them
+//	@ID				gift.batch.codes
+//	@Produce		json
+//	@Param			id			query		string	true "batch id"
+//	@Param			offset		query		int64	true "offset"
+//	@Param			limit		query		int64	true "limit"
+//	@Success		200			{array}		entity.AccessCode
+//	@Failure		422			{boolean} boolean ok
+//	@Router			/code-batch/codes [get]
+func (h *GiftHandler) ReadCodes(w http.ResponseWriter, r *http.Request) {
+	batchID, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "batch id is invalid!", http.StatusUnprocessableEntity)
+		return
+	}
+
+	offset, _ := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	limit, _ := strconv.ParseInt(r.URL.Query().Get("limit"), 10, 64)
+
+	codes, err := h.service.ReadCodes(entity.Pagination{Offset: offset, Limit: limit}, batchID)
+	h.writeJSON(w, codes, err)
+}
+
+func (h *GiftHandler) writeJSON(w http.ResponseWriter, value any, err error) {
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/AlnurZhanibek/kazusa-server/internal/service"
+	"github.com/google/uuid"
+)
+
+type Policy int
+
+const (
+	// Public routes accept anonymous requests, a valid token only adds the caller to the context.
+	Public Policy = iota
+	// Authenticated routes require any logged-in user.
+	Authenticated
+	// AdminOnly routes require the admin role.
+	AdminOnly
+	// SelfOrAdmin routes require the "id" query parameter to match the caller, unless the caller is an admin.
+	SelfOrAdmin
+)
+
+var errNoToken = errors.New("auth middleware: token is missing")
+
+// Policies maps "METHOD /path" to the access policy of the route. Like http.ServeMux patterns,
+// a path ending in a slash matches every path under it. Requests that do not match any entry are rejected.
+type Policies map[string]Policy
+
+func (p Policies) lookup(method string, path string) (Policy, bool) {
+	policy, ok := p[method+" "+path]
+	if ok {
+		return policy, true
+	}
+
+	for path != "/" && path != "" {
+		path = strings.TrimSuffix(path, "/")
+		path = path[:strings.LastIndex(path, "/")+1]
+
+		policy, ok = p[method+" "+path]
+		if ok {
+			return policy, true
+		}
+	}
+
+	return 0, false
+}
+
+type AuthMiddleware struct {
+	service  *service.AuthService
+	policies Policies
+}
+
+func NewAuthMiddleware(service *service.AuthService, policies Policies) *AuthMiddleware {
+	return &AuthMiddleware{service: service, policies: policies}
+}
+
+func (m *AuthMiddleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		policy, ok := m.policies.lookup(r.Method, r.URL.Path)
+		if !ok {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		claims, err := m.claims(r)
+		if err != nil && policy != Public {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if !allowed(policy, claims, r) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		ctx := r.Context()
+		if claims != nil {
+			ctx = service.ContextWithClaims(ctx, claims)
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (m *AuthMiddleware) claims(r *http.Request) (*service.Claims, error) {
+	token := tokenFromRequest(r)
+	if token == "" {
+		return nil, errNoToken
+	}
+
+	return m.service.ParseToken(token)
+}
+
+func allowed(policy Policy, claims *service.Claims, r *http.Request) bool {
+	switch policy {
+	case Public:
+		return true
+	case Authenticated:
+		return claims != nil
+	case AdminOnly:
+		return claims != nil && claims.Role == entity.AdminRole
+	case SelfOrAdmin:
+		if claims == nil {
+			return false
+		}
+		if claims.Role == entity.AdminRole {
+			return true
+		}
+		id, err := uuid.Parse(r.URL.Query().Get("id"))
+		return err == nil && id == claims.UserID
+	}
+
+	return false
+}
+
+func tokenFromRequest(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+
+	cookie, err := r.Cookie("token")
+	if err == nil {
+		return cookie.Value
+	}
+
+	return ""
+}
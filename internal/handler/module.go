@@ -1,14 +1,12 @@
 package handler
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
 	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
 	"github.com/AlnurZhanibek/kazusa-server/internal/service"
-	"github.com/dgrijalva/jwt-go"
 	"github.com/google/uuid"
 	"net/http"
-	"os"
 	"strconv"
 )
 
@@ -71,7 +69,7 @@ func (h *ModuleHandler) Create(w http.ResponseWriter, r *http.Request) {
 // Read module
 //
 //	@Summary		Read modules
-//	@Description	read modules
+//	@Description	read modules, the content of modules other than free previews is only returned to enrolled users and admins
 //	@ID				module.read
 //	@Accept			json
 //	@Produce		json
@@ -80,7 +78,10 @@ func (h *ModuleHandler) Create(w http.ResponseWriter, r *http.Request) {
 //	@Param			offset		query		int64		false 	"offset"
 //	@Param			limit		query		int64		false 	"limit"
 //	@Success		200			{array}		entity.Module
+//	@Failure		402			{boolean} 	boolean ok
+//	@Failure		403			{boolean} 	boolean ok
 //	@Failure		404			{boolean} 	boolean ok
+//	@Failure		422			{boolean} 	boolean ok
 //	@Router			/module [get]
 func (h *ModuleHandler) Read(w http.ResponseWriter, r *http.Request) {
 	offset, _ := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
@@ -107,33 +108,34 @@ func (h *ModuleHandler) Read(w http.ResponseWriter, r *http.Request) {
 		pagination.Limit = limit
 	}
 
+	var err error
+
 	if id != "" {
-		filters.ID = uuid.MustParse(id)
+		filters.ID, err = uuid.Parse(id)
+		if err != nil {
+			http.Error(w, "module handler error: error parsing id", http.StatusUnprocessableEntity)
+			return
+		}
 	}
 
 	if courseID != "" {
-		filters.CourseID = uuid.MustParse(courseID)
-	}
-
-	ctx := r.Context()
-
-	tokenCookie, err := r.Cookie("token")
-	if tokenCookie != nil {
-		token := new(jwt.Token)
-		token, err = jwt.ParseWithClaims(tokenCookie.Value, &service.Claims{}, func(token *jwt.Token) (interface{}, error) {
-			return []byte(os.Getenv("JWT_KEY")), nil
-		})
-		claims, ok := token.Claims.(*service.Claims)
-		if !ok || !token.Valid {
-			http.Error(w, "error parsing jwt token", http.StatusBadRequest)
-
+		filters.CourseID, err = uuid.Parse(courseID)
+		if err != nil {
+			http.Error(w, "module handler error: error parsing course_id", http.StatusUnprocessableEntity)
+			return
 		}
-		ctx = context.WithValue(ctx, "user_id", claims.UserID)
 	}
 
-	modules, err := h.service.Read(ctx, pagination, filters)
+	modules, err := h.service.Read(r.Context(), pagination, filters)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, service.ErrPaymentRequired):
+			http.Error(w, err.Error(), http.StatusPaymentRequired)
+		case errors.Is(err, service.ErrEnrollmentRequired):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/AlnurZhanibek/kazusa-server/internal/service"
+	"net/http"
+)
+
+type PasswordHandler struct {
+	service *service.PasswordService
+}
+
+func NewPasswordHandler(service *service.PasswordService) *PasswordHandler {
+	return &PasswordHandler{service: service}
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+} // @name ForgotPasswordRequest
+
+// Forgot password
+//
+//	@Summary		Request a password reset
+//	@Description	send a single-use password reset link to the email, responds ok even if the email is unknown
+//	@ID				password.forgot
+//	@Accept			json
+//	@Produce		json
+//	@Param			request		body		ForgotPasswordRequest	true "forgot password body"
+//	@Success		200			{boolean} boolean ok
+//	@Failure		400			{boolean} boolean ok
+//	@Router			/password/forgot [post]
+func (h *PasswordHandler) Forgot(w http.ResponseWriter, r *http.Request) {
+	body := ForgotPasswordRequest{}
+
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if body.Email == "" {
+		http.Error(w, "email is empty!", http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = h.service.Forgot(r.Context(), body.Email)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+type ResetPasswordRequest struct {
+	Token                string `json:"token"`
+	Password             string `json:"password"`
+	PasswordConfirmation string `json:"passwordConfirmation"`
+} // @name ResetPasswordRequest
+
+// Reset password
+//
+//	@Summary		Reset password
+//	@Description	set a new password with a reset token, revokes every session of the user
+//	@ID				password.reset
+//	@Accept			json
+//	@Produce		json
+//	@Param			request		body		ResetPasswordRequest	true "reset password body"
+//	@Success		200			{boolean} boolean ok
+//	@Failure		400			{boolean} boolean ok
+//	@Router			/password/reset [post]
+func (h *PasswordHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	body := ResetPasswordRequest{}
+
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if body.Token == "" || body.Password == "" {
+		http.Error(w, "token or password is empty!", http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = h.service.Reset(body.Token, body.Password, body.PasswordConfirmation)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
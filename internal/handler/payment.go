@@ -1,8 +1,8 @@
 package handler
 
 import (
-	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"github.com/AlnurZhanibek/kazusa-server/internal/service"
 	"github.com/google/uuid"
 	"log"
@@ -19,123 +19,326 @@ func NewPaymentHandler(service *service.PaymentService) *PaymentHandler {
 	}
 }
 
-type PaymentCreateBody struct {
-	UserID   uuid.UUID `json:"userId"`
-	CourseID uuid.UUID `json:"courseId"`
-	OrderID  uuid.UUID `json:"orderId"`
+type OrderCreateBody struct {
+	CourseID   uuid.UUID `json:"courseId"`
+	CouponCode string    `json:"couponCode"`
+} // @name OrderCreateBody
+
+type OrderResponse struct {
+	Order *service.Order `json:"order,omitempty"`
+	Error string         `json:"error,omitempty"`
+} // @name OrderResponse
+
+// CreateOrder example
+//
+//	@Summary		Create order
+//	@Description	start a purchase of the course by the current user and get the checkout URL of the payment provider
+//	@ID				order.create
+//	@Accept			json
+//	@Produce		json
+//	@Param			request		body		handler.OrderCreateBody	true "order body"
+//	@Success		200			{object}	handler.OrderResponse
+//	@Failure		403			{object}	handler.OrderResponse
+//	@Failure		404			{object}	handler.OrderResponse
+//	@Failure		409			{object}	handler.OrderResponse
+//	@Failure		422			{object}	handler.OrderResponse
+//	@Router			/order [post]
+func (h *PaymentHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
+	encoder := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, ok := service.UserIDFromContext(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		encoder.Encode(OrderResponse{Error: "unauthorized"})
+		return
+	}
+
+	body := OrderCreateBody{}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil || body.CourseID == uuid.Nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		encoder.Encode(OrderResponse{Error: "course id is empty!"})
+		return
+	}
+
+	order, err := h.service.CreateOrder(r.Context(), userID, body.CourseID, body.CouponCode)
+	if err != nil {
+		switch {
+		case isCouponError(err):
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		case errors.Is(err, service.ErrEmailNotVerified):
+			w.WriteHeader(http.StatusForbidden)
+		case errors.Is(err, service.ErrCourseNotFound):
+			w.WriteHeader(http.StatusNotFound)
+		case errors.Is(err, service.ErrCourseAlreadyPaid):
+			w.WriteHeader(http.StatusConflict)
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+		encoder.Encode(OrderResponse{Error: err.Error()})
+		return
+	}
+
+	encoder.Encode(OrderResponse{Order: order})
 }
 
-type CreateResponse struct {
-	OK    bool   `json:"ok"`
-	Error string `json:"error,omitempty"`
+type GiftOrderCreateBody struct {
+	CourseID   uuid.UUID `json:"courseId"`
+	CouponCode string    `json:"couponCode"`
+	// RecipientEmail is where the access code is sent once the order is paid, leave empty to hand
+	// the code over yourself, it is listed at /me/gifts.
+	RecipientEmail string `json:"recipientEmail"`
+} // @name GiftOrderCreateBody
+
+// CreateGiftOrder example
+//
+//	@Summary		Create gift order
+//	@Description	buy the course as a gift, once paid an access code is issued that enrolls whoever redeems it
+//	@ID				order.gift
+//	@Accept			json
+//	@Produce		json
+//	@Param			request		body		handler.GiftOrderCreateBody	true "gift order body"
+//	@Success		200			{object}	handler.OrderResponse
+//	@Failure		403			{object}	handler.OrderResponse
+//	@Failure		404			{object}	handler.OrderResponse
+//	@Failure		422			{object}	handler.OrderResponse
+//	@Router			/order/gift [post]
+func (h *PaymentHandler) CreateGiftOrder(w http.ResponseWriter, r *http.Request) {
+	encoder := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, ok := service.UserIDFromContext(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		encoder.Encode(OrderResponse{Error: "unauthorized"})
+		return
+	}
+
+	body := GiftOrderCreateBody{}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil || body.CourseID == uuid.Nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		encoder.Encode(OrderResponse{Error: "course id is empty!"})
+		return
+	}
+
+	order, err := h.service.CreateGiftOrder(r.Context(), userID, body.CourseID, body.CouponCode, body.RecipientEmail)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidGiftRecipient) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		} else {
+			w.WriteHeader(cartErrorStatus(err, http.StatusBadGateway))
+		}
+		encoder.Encode(OrderResponse{Error: err.Error()})
+		return
+	}
+
+	encoder.Encode(OrderResponse{Order: order})
 }
 
-func (h *PaymentHandler) Create(w http.ResponseWriter, r *http.Request) {
-	body := new(PaymentCreateBody)
+type CheckoutRequest struct {
+	// CourseIDs are the courses in the cart, leave empty when buying a bundle.
+	CourseIDs  []uuid.UUID `json:"courseIds"`
+	BundleID   *uuid.UUID  `json:"bundleId"`
+	CouponCode string      `json:"couponCode"`
+} // @name CheckoutRequest
+
+type CartQuoteResponse struct {
+	Quote *service.CartQuote `json:"quote,omitempty"`
+	Error string             `json:"error,omitempty"`
+} // @name CartQuoteResponse
 
+// Checkout example
+//
+//	@Summary		Checkout cart
+//	@Description	create one order for several courses or a bundle and get the checkout URL of the payment provider
+//	@ID				order.checkout
+//	@Accept			json
+//	@Produce		json
+//	@Param			request		body		handler.CheckoutRequest	true "cart body"
+//	@Success		200			{object}	handler.OrderResponse
+//	@Failure		403			{object}	handler.OrderResponse
+//	@Failure		404			{object}	handler.OrderResponse
+//	@Failure		409			{object}	handler.OrderResponse
+//	@Failure		422			{object}	handler.OrderResponse
+//	@Router			/checkout [post]
+func (h *PaymentHandler) Checkout(w http.ResponseWriter, r *http.Request) {
+	encoder := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, ok := service.UserIDFromContext(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		encoder.Encode(OrderResponse{Error: "unauthorized"})
+		return
+	}
+
+	body := CheckoutRequest{}
 	err := json.NewDecoder(r.Body).Decode(&body)
 	if err != nil {
-		json.NewEncoder(w).Encode(CreateResponse{
-			OK:    false,
-			Error: err.Error(),
-		})
-		w.WriteHeader(http.StatusBadRequest)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		encoder.Encode(OrderResponse{Error: err.Error()})
 		return
 	}
 
-	err = h.service.Create(&service.PaymentCreateBody{
-		UserID:   body.UserID,
-		CourseID: body.CourseID,
-		OrderID:  body.OrderID,
-	})
+	order, err := h.service.Checkout(r.Context(), userID, service.Cart{CourseIDs: body.CourseIDs, BundleID: body.BundleID}, body.CouponCode)
 	if err != nil {
-		json.NewEncoder(w).Encode(CreateResponse{
-			OK:    false,
-			Error: err.Error(),
-		})
-		w.WriteHeader(http.StatusBadRequest)
+		w.WriteHeader(cartErrorStatus(err, http.StatusBadGateway))
+		encoder.Encode(OrderResponse{Error: err.Error()})
 		return
 	}
 
-	json.NewEncoder(w).Encode(CreateResponse{
-		OK:    true,
-		Error: "",
-	})
-	return
+	encoder.Encode(OrderResponse{Order: order})
 }
 
-type PaymentConfirmBody struct {
-	Data string `json:"data"`
-}
-type PaymentData struct {
-	OrderID uuid.UUID `json:"order_id"`
-	Status  string    `json:"operation_status"`
-}
-type ConfirmResponse struct {
-	OK    bool   `json:"ok"`
-	Error string `json:"error,omitempty"`
-}
+// PreviewCheckout example
+//
+//	@Summary		Preview cart
+//	@Description	price the cart for the current user without creating an order
+//	@ID				order.checkout.preview
+//	@Accept			json
+//	@Produce		json
+//	@Param			request		body		handler.CheckoutRequest	true "cart body"
+//	@Success		200			{object}	handler.CartQuoteResponse
+//	@Failure		404			{object}	handler.CartQuoteResponse
+//	@Failure		409			{object}	handler.CartQuoteResponse
+//	@Failure		422			{object}	handler.CartQuoteResponse
+//	@Router			/checkout/preview [post]
+func (h *PaymentHandler) PreviewCheckout(w http.ResponseWriter, r *http.Request) {
+	encoder := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
 
-func (h *PaymentHandler) Confirm(w http.ResponseWriter, r *http.Request) {
-	body := new(PaymentConfirmBody)
+	userID, ok := service.UserIDFromContext(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		encoder.Encode(CartQuoteResponse{Error: "unauthorized"})
+		return
+	}
 
+	body := CheckoutRequest{}
 	err := json.NewDecoder(r.Body).Decode(&body)
 	if err != nil {
-		json.NewEncoder(w).Encode(CreateResponse{
-			OK:    false,
-			Error: err.Error(),
-		})
-		w.WriteHeader(http.StatusBadRequest)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		encoder.Encode(CartQuoteResponse{Error: err.Error()})
 		return
 	}
 
-	data, err := base64.StdEncoding.DecodeString(body.Data)
+	quote, err := h.service.QuoteCart(userID, service.Cart{CourseIDs: body.CourseIDs, BundleID: body.BundleID}, body.CouponCode)
 	if err != nil {
-		log.Printf("error decoding base64 data %v", err)
-		json.NewEncoder(w).Encode(ConfirmResponse{
-			OK:    false,
-			Error: err.Error(),
-		})
-		w.WriteHeader(http.StatusBadRequest)
+		w.WriteHeader(cartErrorStatus(err, http.StatusInternalServerError))
+		encoder.Encode(CartQuoteResponse{Error: err.Error()})
 		return
 	}
-	log.Print(data)
 
-	paymentData := new(PaymentData)
-	err = json.Unmarshal(data, paymentData)
+	encoder.Encode(CartQuoteResponse{Quote: quote})
+}
+
+func cartErrorStatus(err error, fallback int) int {
+	switch {
+	case isCouponError(err), errors.Is(err, service.ErrInvalidCart), errors.Is(err, service.ErrCartCurrencyMismatch):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, service.ErrEmailNotVerified):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrCourseNotFound), errors.Is(err, service.ErrBundleNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrCourseAlreadyPaid):
+		return http.StatusConflict
+	default:
+		return fallback
+	}
+}
+
+type RefundRequest struct {
+	OrderID uuid.UUID `json:"orderId"`
+	// Amount to refund, the whole remaining amount when zero.
+	Amount int64  `json:"amount"`
+	Reason string `json:"reason"`
+} // @name RefundRequest
+
+// Refund example
+//
+//	@Summary		Refund payment
+//	@Description	refund the whole or a part of a payment, a fully refunded payment no longer gives access to the course
+//	@ID				payment.refund
+//	@Accept			json
+//	@Produce		json
+//	@Param			request		body		handler.RefundRequest	true "refund body"
+//	@Success		200			{object}	service.Refund
+//	@Failure		404			{boolean} 	boolean ok
+//	@Failure		409			{boolean} 	boolean ok
+//	@Failure		422			{boolean} 	boolean ok
+//	@Router			/payment/refund [post]
+func (h *PaymentHandler) Refund(w http.ResponseWriter, r *http.Request) {
+	body := RefundRequest{}
+	err := json.NewDecoder(r.Body).Decode(&body)
 	if err != nil {
-		json.NewEncoder(w).Encode(ConfirmResponse{
-			OK:    false,
-			Error: err.Error(),
-		})
-		w.WriteHeader(http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if body.OrderID == uuid.Nil || body.Reason == "" {
+		http.Error(w, "order id or reason is empty!", http.StatusUnprocessableEntity)
 		return
 	}
 
-	if paymentData.Status != "success" {
-		log.Printf("payment confirmation for %v was not successful: %v", paymentData.OrderID, paymentData.Status)
-		json.NewEncoder(w).Encode(ConfirmResponse{
-			OK:    false,
-			Error: "payment confirmation was not successful",
-		})
-		w.WriteHeader(http.StatusBadRequest)
+	refund, err := h.service.Refund(r.Context(), body.OrderID, body.Amount, body.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPaymentNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrInvalidPaymentTransition):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, service.ErrInvalidRefundAmount):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		default:
+			http.Error(w, err.Error(), http.StatusBadGateway)
+		}
 		return
 	}
 
-	err = h.service.Confirm(paymentData.OrderID)
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(refund)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Confirm handles the status callbacks of the payment provider. The provider retries a callback
+// until it is acknowledged, so callbacks that were already processed, arrive out of order, are about
+// unknown orders or were flagged for review are acknowledged as well; only callbacks that fail
+// verification or could not be processed for now are refused.
+func (h *PaymentHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	provider := h.service.Provider()
+
+	callback, err := provider.ParseCallback(r)
 	if err != nil {
-		json.NewEncoder(w).Encode(ConfirmResponse{
-			OK:    false,
-			Error: err.Error(),
-		})
-		w.WriteHeader(http.StatusBadRequest)
+		log.Printf("payment callback rejected: %v", err)
+		provider.CallbackResponse(w, r, err)
+		return
+	}
+
+	err = h.service.ApplyCallback(callback)
+	switch {
+	case errors.Is(err, service.ErrPaymentNotFound):
+		log.Printf("payment callback for unknown order %v acknowledged: %v", callback.OrderID, err)
+		err = nil
+	case errors.Is(err, service.ErrInvalidPaymentTransition), errors.Is(err, service.ErrPaymentAmountMismatch):
+		log.Printf("payment callback for %v acknowledged without changes: %v", callback.OrderID, err)
+		err = nil
+	case err != nil:
+		log.Printf("payment callback for %v failed: %v", callback.OrderID, err)
+	}
+
+	provider.CallbackResponse(w, r, err)
+}
+
+// SimulatorCheckout serves the checkout page of the payment simulator.
+func (h *PaymentHandler) SimulatorCheckout(w http.ResponseWriter, r *http.Request) {
+	page, ok := h.service.Provider().(http.Handler)
+	if !ok {
+		http.NotFound(w, r)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(CreateResponse{
-		OK:    true,
-		Error: "",
-	})
-	return
+	page.ServeHTTP(w, r)
 }
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/AlnurZhanibek/kazusa-server/internal/service"
+	"github.com/google/uuid"
+	"net/http"
+	"strconv"
+)
+
+type ReceiptHandler struct {
+	service *service.ReceiptService
+}
+
+func NewReceiptHandler(service *service.ReceiptService) *ReceiptHandler {
+	return &ReceiptHandler{service: service}
+}
+
+// Payments
+//
+//	@Summary		Read my payments
+//	@Description	read the paid and refunded orders of the current user with their receipts
+//	@ID				receipt.payments
+//	@Produce		json
+//	@Param			offset		query		int64	true "offset"
+//	@Param			limit		query		int64	true "limit"
+//	@Success		200			{array}		service.PaymentRecord
+//	@Failure		401			{boolean} boolean ok
+//	@Router			/me/payments [get]
+func (h *ReceiptHandler) Payments(w http.ResponseWriter, r *http.Request) {
+	userID, ok := service.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	offset, _ := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	limit, _ := strconv.ParseInt(r.URL.Query().Get("limit"), 10, 64)
+
+	payments, err := h.service.Payments(entity.Pagination{
+		Offset: offset,
+		Limit:  limit,
+	}, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(payments)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Download
+//
+//	@Summary		Download receipt
+//	@Description	redirect to the receipt PDF of an order of the current user, admins can download any receipt
+//	@ID				receipt.download
+//	@Param			orderId		query		string	true "order id"
+//	@Success		302
+//	@Failure		404			{boolean} boolean ok
+//	@Router			/me/payments/receipt [get]
+func (h *ReceiptHandler) Download(w http.ResponseWriter, r *http.Request) {
+	orderID, err := uuid.Parse(r.URL.Query().Get("orderId"))
+	if err != nil {
+		http.Error(w, "order id is invalid!", http.StatusUnprocessableEntity)
+		return
+	}
+
+	fileURL, err := h.service.Download(r.Context(), orderID)
+	if err != nil {
+		if errors.Is(err, service.ErrReceiptNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	http.Redirect(w, r, fileURL, http.StatusFound)
+}
+
+type ReceiptRegenerateRequest struct {
+	OrderID uuid.UUID `json:"orderId"`
+} // @name ReceiptRegenerateRequest
+
+// Regenerate
+//
+//	@Summary		Regenerate receipt
+//	@Description	render the receipt of a paid order again keeping its number, orders without a receipt get one
+//	@ID				receipt.regenerate
+//	@Accept			json
+//	@Produce		json
+//	@Param			request		body		handler.ReceiptRegenerateRequest	true "regenerate body"
+//	@Success		200			{object}	entity.Receipt
+//	@Failure		404			{boolean} boolean ok
+//	@Failure		409			{boolean} boolean ok
+//	@Router			/payment/receipt [post]
+func (h *ReceiptHandler) Regenerate(w http.ResponseWriter, r *http.Request) {
+	body := ReceiptRegenerateRequest{}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil || body.OrderID == uuid.Nil {
+		http.Error(w, "order id is empty!", http.StatusUnprocessableEntity)
+		return
+	}
+
+	receipt, err := h.service.Regenerate(r.Context(), body.OrderID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPaymentNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrOrderNotPaid):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(receipt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
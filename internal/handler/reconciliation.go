@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/AlnurZhanibek/kazusa-server/internal/service"
+	"github.com/google/uuid"
+	"net/http"
+	"strconv"
+)
+
+type ReconciliationHandler struct {
+	service *service.ReconciliationService
+}
+
+func NewReconciliationHandler(service *service.ReconciliationService) *ReconciliationHandler {
+	return &ReconciliationHandler{service: service}
+}
+
+// Reviews
+//
+//	@Summary		Read payment reviews
+//	@Description	read orders flagged by reconciliation because their status disagrees with the payment provider
+//	@ID				payment.review.read
+//	@Produce		json
+//	@Param			offset		query		int64	true "offset"
+//	@Param			limit		query		int64	true "limit"
+//	@Param			open		query		bool	false "only reviews that are not resolved"
+//	@Success		200			{array}		entity.PaymentReview
+//	@Failure		500			{boolean} boolean ok
+//	@Router			/payment/review [get]
+func (h *ReconciliationHandler) Reviews(w http.ResponseWriter, r *http.Request) {
+	offset, _ := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	limit, _ := strconv.ParseInt(r.URL.Query().Get("limit"), 10, 64)
+	open, _ := strconv.ParseBool(r.URL.Query().Get("open"))
+
+	reviews, err := h.service.Reviews(entity.Pagination{
+		Offset: offset,
+		Limit:  limit,
+	}, open)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(reviews)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+type ReviewResolveRequest struct {
+	OrderID uuid.UUID `json:"orderId"`
+	Note    string    `json:"note"`
+} // @name ReviewResolveRequest
+
+// Resolve
+//
+//	@Summary		Resolve payment review
+//	@Description	close the review of an order once it has been sorted out, the order is not reconciled again
+//	@ID				payment.review.resolve
+//	@Accept			json
+//	@Produce		json
+//	@Param			request		body		handler.ReviewResolveRequest	true "resolve body"
+//	@Success		200			{boolean} boolean ok
+//	@Failure		404			{boolean} boolean ok
+//	@Router			/payment/review/resolve [post]
+func (h *ReconciliationHandler) Resolve(w http.ResponseWriter, r *http.Request) {
+	body := ReviewResolveRequest{}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil || body.OrderID == uuid.Nil {
+		http.Error(w, "order id is empty!", http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = h.service.Resolve(r.Context(), body.OrderID, body.Note)
+	if err != nil {
+		if errors.Is(err, service.ErrReviewNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(true)
+}
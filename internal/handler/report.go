@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/AlnurZhanibek/kazusa-server/internal/service"
+	"github.com/google/uuid"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ReportHandler struct {
+	service *service.ReportService
+}
+
+func NewReportHandler(service *service.ReportService) *ReportHandler {
+	return &ReportHandler{service: service}
+}
+
+// Revenue
+//
+//	@Summary		Revenue report
+//	@Description	sum orders by day, week or month and currency, net of refunds; format=csv downloads the report as CSV
+//	@ID				report.revenue
+//	@Produce		json
+//	@Produce		text/csv
+//	@Param			from		query		string	false "first date, YYYY-MM-DD, 30 days before the last date by default"
+//	@Param			to			query		string	false "last date, YYYY-MM-DD, today by default"
+//	@Param			courseId	query		string	false "course id, only the part of orders paid for the course is counted"
+//	@Param			status		query		string	false "comma separated payment statuses, succeeded and refunded by default"
+//	@Param			groupBy		query		string	false "day, week or month"
+//	@Param			format		query		string	false "json or csv"
+//	@Success		200			{object}	service.RevenueReport
+//	@Failure		422			{boolean} boolean ok
+//	@Router			/reports/revenue [get]
+func (h *ReportHandler) Revenue(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	query := service.RevenueQuery{GroupBy: params.Get("groupBy")}
+
+	var err error
+	query.From, err = parseReportDate(params.Get("from"))
+	if err != nil {
+		http.Error(w, "from should be a YYYY-MM-DD date", http.StatusUnprocessableEntity)
+		return
+	}
+	query.To, err = parseReportDate(params.Get("to"))
+	if err != nil {
+		http.Error(w, "to should be a YYYY-MM-DD date", http.StatusUnprocessableEntity)
+		return
+	}
+	if params.Get("courseId") != "" {
+		courseID, err := uuid.Parse(params.Get("courseId"))
+		if err != nil {
+			http.Error(w, "course id is invalid!", http.StatusUnprocessableEntity)
+			return
+		}
+		query.CourseID = &courseID
+	}
+	for _, status := range strings.Split(params.Get("status"), ",") {
+		if status = strings.TrimSpace(status); status != "" {
+			query.Statuses = append(query.Statuses, entity.PaymentStatus(status))
+		}
+	}
+
+	report, err := h.service.Revenue(query)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidReport) {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if params.Get("format") == "csv" {
+		writeRevenueCSV(w, report)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(report)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func parseReportDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &date, nil
+}
+
+// writeRevenueCSV writes a row per period and currency followed by a total row per currency.
+func writeRevenueCSV(w http.ResponseWriter, report *service.RevenueReport) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"revenue-%v-%v.csv\"", report.From, report.To))
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"period", "currency", "orders", "gross", "discount", "refunded", "net"})
+
+	write := func(period string, row service.RevenueRow) {
+		writer.Write([]string{
+			period,
+			row.Currency,
+			strconv.FormatInt(row.Orders, 10),
+			strconv.FormatInt(row.Gross, 10),
+			strconv.FormatInt(row.Discount, 10),
+			strconv.FormatInt(row.Refunded, 10),
+			strconv.FormatInt(row.Net, 10),
+		})
+	}
+	for _, row := range report.Rows {
+		write(row.Period, row)
+	}
+	for _, row := range report.Totals {
+		write("total", row)
+	}
+
+	writer.Flush()
+}
@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/AlnurZhanibek/kazusa-server/internal/service"
+	"github.com/google/uuid"
+	"net/http"
+	"strconv"
+)
+
+type SubscriptionHandler struct {
+	service        *service.SubscriptionService
+	paymentService *service.PaymentService
+}
+
+func NewSubscriptionHandler(service *service.SubscriptionService, paymentService *service.PaymentService) *SubscriptionHandler {
+	return &SubscriptionHandler{service: service, paymentService: paymentService}
+}
+
+// CreatePlan
+//
+//	@Summary		Create plan
+//	@Description	create a monthly or annual subscription plan that unlocks every course
+//	@ID				plan.create
+//	@Accept			json
+//	@Produce		json
+//	@Param			request		body		entity.NewPlan	true "new plan body"
+//	@Success		200			{string}	string id
+//	@Failure		422			{boolean} boolean ok
+//	@Router			/plan [post]
+func (h *SubscriptionHandler) CreatePlan(w http.ResponseWriter, r *http.Request) {
+	newPlan := entity.NewPlan{}
+
+	err := json.NewDecoder(r.Body).Decode(&newPlan)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	id, err := h.service.CreatePlan(newPlan)
+	if errors.Is(err, service.ErrInvalidPlan) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(id)
+}
+
+// ReadPlans
+//
+//	@Summary		Read plans
+//	@Description	read subscription plans on sale, admins get the deactivated ones too with all=true
+//	@ID				plan.read
+//	@Produce		json
+//	@Param			offset		query		int64	true "offset"
+//	@Param			limit		query		int64	true "limit"
+//	@Param			all			query		bool	false "include deactivated plans, admins only"
+//	@Success		200			{array}		entity.Plan
+//	@Failure		500			{boolean} boolean ok
+//	@Router			/plan [get]
+func (h *SubscriptionHandler) ReadPlans(w http.ResponseWriter, r *http.Request) {
+	offset, _ := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	limit, _ := strconv.ParseInt(r.URL.Query().Get("limit"), 10, 64)
+	all, _ := strconv.ParseBool(r.URL.Query().Get("all"))
+
+	plans, err := h.service.ReadPlans(entity.Pagination{
+		Offset: offset,
+		Limit:  limit,
+	}, all && service.IsAdmin(r.Context()))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(plans)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// DeletePlan
+//
+//	@Summary		Deactivate plan
+//	@Description	take the plan off sale, subscribers keep access until their paid period ends
+//	@ID				plan.delete
+//	@Produce		json
+//	@Param			id			query		string	true "id"
+//	@Success		200			{boolean} boolean ok
+//	@Failure		422			{boolean} boolean ok
+//	@Router			/plan [delete]
+func (h *SubscriptionHandler) DeletePlan(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "subscription handler error: id is invalid!", http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = h.service.DeletePlan(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(true)
+}
+
+type SubscribeRequest struct {
+	PlanID     uuid.UUID `json:"planId"`
+	CouponCode string    `json:"couponCode"`
+} // @name SubscribeRequest
+
+// Subscribe
+//
+//	@Summary		Subscribe
+//	@Description	create an order for a period of the plan, it renews the current subscription of the user to the same plan
+//	@ID				subscription.create
+//	@Accept			json
+//	@Produce		json
+//	@Param			request		body		handler.SubscribeRequest	true "subscribe body"
+//	@Success		200			{object}	handler.OrderResponse
+//	@Failure		403			{object}	handler.OrderResponse
+//	@Failure		404			{object}	handler.OrderResponse
+//	@Failure		409			{object}	handler.OrderResponse
+//	@Failure		422			{object}	handler.OrderResponse
+//	@Router			/subscription [post]
+func (h *SubscriptionHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	encoder := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, ok := service.UserIDFromContext(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		encoder.Encode(OrderResponse{Error: "unauthorized"})
+		return
+	}
+
+	body := SubscribeRequest{}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil || body.PlanID == uuid.Nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		encoder.Encode(OrderResponse{Error: "plan id is empty!"})
+		return
+	}
+
+	order, err := h.paymentService.Subscribe(r.Context(), userID, body.PlanID, body.CouponCode)
+	if err != nil {
+		switch {
+		case isCouponError(err):
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		case errors.Is(err, service.ErrEmailNotVerified):
+			w.WriteHeader(http.StatusForbidden)
+		case errors.Is(err, service.ErrPlanNotFound):
+			w.WriteHeader(http.StatusNotFound)
+		case errors.Is(err, service.ErrAlreadySubscribed):
+			w.WriteHeader(http.StatusConflict)
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+		encoder.Encode(OrderResponse{Error: err.Error()})
+		return
+	}
+
+	encoder.Encode(OrderResponse{Order: order})
+}
+
+// Current
+//
+//	@Summary		Current subscription
+//	@Description	get the latest subscription of the current user with its status
+//	@ID				subscription.current
+//	@Produce		json
+//	@Success		200			{object}	entity.Subscription
+//	@Failure		404			{boolean} boolean ok
+//	@Router			/me/subscription [get]
+func (h *SubscriptionHandler) Current(w http.ResponseWriter, r *http.Request) {
+	userID, ok := service.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	subscription, err := h.service.Current(userID)
+	h.writeSubscription(w, subscription, err)
+}
+
+// Cancel
+//
+//	@Summary		Cancel subscription
+//	@Description	cancel the subscription of the current user at the end of the paid period
+//	@ID				subscription.cancel
+//	@Produce		json
+//	@Success		200			{object}	entity.Subscription
+//	@Failure		404			{boolean} boolean ok
+//	@Router			/subscription [delete]
+func (h *SubscriptionHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	userID, ok := service.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	subscription, err := h.service.Cancel(userID)
+	h.writeSubscription(w, subscription, err)
+}
+
+func (h *SubscriptionHandler) writeSubscription(w http.ResponseWriter, subscription *entity.Subscription, err error) {
+	if errors.Is(err, service.ErrSubscriptionNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(subscription)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/AlnurZhanibek/kazusa-server/internal/service"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+type UploadHandler struct {
+	service *service.UploadService
+}
+
+func NewUploadHandler(service *service.UploadService) *UploadHandler {
+	return &UploadHandler{service: service}
+}
+
+// Create
+//
+//	@Summary		Start upload
+//	@Description	start an upload of a course attachment sent straight to the storage, the response holds a presigned PUT URL or, for large files, presigned URLs of the parts
+//	@ID				upload.create
+//	@Accept			json
+//	@Produce		json
+//	@Param			request		body		service.NewUpload	true "new upload body"
+//	@Success		200			{object}	service.UploadSession
+//	@Failure		404			{boolean} boolean ok
+//	@Failure		422			{boolean} boolean ok
+//	@Router			/upload [post]
+func (h *UploadHandler) Create(w http.ResponseWriter, r *http.Request) {
+	body := service.NewUpload{}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	session, err := h.service.Create(r.Context(), body)
+	if err != nil {
+		writeUploadError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(session)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Complete
+//
+//	@Summary		Complete upload
+//	@Description	verify the size and checksum of the uploaded file and add it to the attachments of the course, a file that does not match is deleted
+//	@ID				upload.complete
+//	@Accept			json
+//	@Produce		json
+//	@Param			request		body		service.UploadCompletion	true "upload completion body"
+//	@Success		200			{object}	entity.Upload
+//	@Failure		404			{boolean} boolean ok
+//	@Failure		409			{boolean} boolean ok
+//	@Failure		422			{boolean} boolean ok
+//	@Router			/upload/complete [post]
+func (h *UploadHandler) Complete(w http.ResponseWriter, r *http.Request) {
+	body := service.UploadCompletion{}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil || body.ID == uuid.Nil {
+		http.Error(w, "upload id is empty!", http.StatusUnprocessableEntity)
+		return
+	}
+
+	upload, err := h.service.Complete(r.Context(), body)
+	if err != nil {
+		writeUploadError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(upload)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Abort
+//
+//	@Summary		Abort upload
+//	@Description	drop a pending upload and whatever was uploaded for it
+//	@ID				upload.abort
+//	@Produce		json
+//	@Param			id			query		string	true "upload id"
+//	@Success		200			{boolean} boolean ok
+//	@Failure		404			{boolean} boolean ok
+//	@Failure		422			{boolean} boolean ok
+//	@Router			/upload [delete]
+func (h *UploadHandler) Abort(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "upload handler error: error parsing id", http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = h.service.Abort(r.Context(), id)
+	if err != nil {
+		writeUploadError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(true)
+}
+
+func writeUploadError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrUploadNotFound), errors.Is(err, service.ErrCourseNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrUploadMismatch):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrInvalidUpload):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -77,6 +77,7 @@ func (h *UserHandler) Create(w http.ResponseWriter, r *http.Request) {
 //	@Param			id			query		string	false "id"
 //	@Success		200			{array}	entity.User
 //	@Failure		404			{boolean} boolean ok
+//	@Failure		422			{boolean} boolean ok
 //	@Router			/user [get]
 func (h *UserHandler) Read(w http.ResponseWriter, r *http.Request) {
 	offset, _ := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
@@ -102,7 +103,11 @@ func (h *UserHandler) Read(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if id != "" {
-		parsedID := uuid.MustParse(id)
+		parsedID, err := uuid.Parse(id)
+		if err != nil {
+			http.Error(w, "user handler error: error parsing id", http.StatusUnprocessableEntity)
+			return
+		}
 		filters.ID = &parsedID
 	}
 
@@ -0,0 +1,48 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileMailer writes every message as an .eml file into a directory instead of sending it.
+// It is meant for local development and tests.
+type FileMailer struct {
+	dir string
+}
+
+func NewFileMailer(dir string) (*FileMailer, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("file mailer: creating %v: %v", dir, err)
+	}
+
+	return &FileMailer{dir: dir}, nil
+}
+
+func (m *FileMailer) Send(ctx context.Context, message Message) error {
+	body, err := build("noreply@localhost", message)
+	if err != nil {
+		return fmt.Errorf("file mailer: %v", err)
+	}
+
+	recipient := strings.NewReplacer("@", "_at_", "/", "_", "\\", "_").Replace(message.To)
+	name := fmt.Sprintf("%v-%v-%v.eml", time.Now().Format("20060102T150405"), recipient, uuid.NewString()[:8])
+	path := filepath.Join(m.dir, name)
+
+	err = os.WriteFile(path, body, 0o644)
+	if err != nil {
+		return fmt.Errorf("file mailer: writing %v: %v", path, err)
+	}
+
+	log.Printf("mail to %v (%v) written to %v", message.To, message.Subject, path)
+
+	return nil
+}
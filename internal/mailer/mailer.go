@@ -0,0 +1,40 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// New returns the mailer selected by MAIL_BACKEND: "smtp" or "file" (default).
+func New() (Mailer, error) {
+	switch os.Getenv("MAIL_BACKEND") {
+	case "smtp":
+		return NewSMTPMailer(SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USER"),
+			Password: os.Getenv("SMTP_PASS"),
+			From:     os.Getenv("MAIL_FROM"),
+		})
+	case "", "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "mail"
+		}
+		return NewFileMailer(dir)
+	default:
+		return nil, fmt.Errorf("mailer: unknown backend %q", os.Getenv("MAIL_BACKEND"))
+	}
+}
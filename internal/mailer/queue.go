@@ -0,0 +1,101 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/AlnurZhanibek/kazusa-server/internal/repository"
+)
+
+const (
+	queuePollInterval = 10 * time.Second
+	queueBatchSize    = 20
+	queueMaxAttempts  = 8
+	queueLockTTL      = 2 * time.Minute
+)
+
+// Queue stores outgoing messages in the database and sends them from a background worker,
+// so a failing mail server never fails the request that produced the message.
+type Queue struct {
+	repo   *repository.MailQueueRepository
+	mailer Mailer
+}
+
+func NewQueue(repo *repository.MailQueueRepository, mailer Mailer) *Queue {
+	return &Queue{repo: repo, mailer: mailer}
+}
+
+func (q *Queue) Send(ctx context.Context, message Message) error {
+	err := q.repo.Create(&repository.MailCreateBody{
+		Recipient: message.To,
+		Subject:   message.Subject,
+		TextBody:  message.Text,
+		HTMLBody:  message.HTML,
+	})
+	if err != nil {
+		return fmt.Errorf("mail queue: %v", err)
+	}
+
+	return nil
+}
+
+// Run delivers due messages until the context is cancelled.
+func (q *Queue) Run(ctx context.Context) {
+	ticker := time.NewTicker(queuePollInterval)
+	defer ticker.Stop()
+
+	for {
+		q.flush(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (q *Queue) flush(ctx context.Context) {
+	mails, err := q.repo.ReadDue(queueMaxAttempts, queueBatchSize)
+	if err != nil {
+		log.Printf("mail queue: %v", err)
+		return
+	}
+
+	for _, mail := range mails {
+		claimed, err := q.repo.Claim(mail.ID, time.Now().Add(queueLockTTL))
+		if err != nil {
+			log.Printf("mail queue: %v", err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		sendCtx, cancel := context.WithTimeout(ctx, time.Minute)
+		err = q.mailer.Send(sendCtx, Message{
+			To:      mail.Recipient,
+			Subject: mail.Subject,
+			Text:    mail.TextBody,
+			HTML:    mail.HTMLBody.String,
+		})
+		cancel()
+
+		if err != nil {
+			log.Printf("mail queue: attempt %v for %v failed: %v", mail.Attempts+1, mail.ID, err)
+			err = q.repo.MarkFailed(mail.ID, time.Now().Add(backoff(mail.Attempts+1)), err.Error())
+		} else {
+			err = q.repo.MarkSent(mail.ID)
+		}
+		if err != nil {
+			log.Printf("mail queue: %v", err)
+		}
+	}
+}
+
+// backoff grows quadratically: 1, 4, 9, 16... minutes after the failed attempt.
+func backoff(attempts int64) time.Duration {
+	return time.Duration(attempts*attempts) * time.Minute
+}
@@ -0,0 +1,116 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"time"
+)
+
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+type SMTPMailer struct {
+	config SMTPConfig
+	auth   smtp.Auth
+}
+
+func NewSMTPMailer(config SMTPConfig) (*SMTPMailer, error) {
+	if config.Host == "" || config.From == "" {
+		return nil, fmt.Errorf("smtp mailer: host and from address are required")
+	}
+	if config.Port == "" {
+		config.Port = "587"
+	}
+
+	var auth smtp.Auth
+	if config.Username != "" {
+		auth = smtp.PlainAuth("", config.Username, config.Password, config.Host)
+	}
+
+	return &SMTPMailer{config: config, auth: auth}, nil
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, message Message) error {
+	body, err := build(m.config.From, message)
+	if err != nil {
+		return fmt.Errorf("smtp mailer: %v", err)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- smtp.SendMail(net.JoinHostPort(m.config.Host, m.config.Port), m.auth, m.config.From, []string{message.To}, body)
+	}()
+
+	select {
+	case err = <-errCh:
+		if err != nil {
+			return fmt.Errorf("smtp mailer: sending to %v: %v", message.To, err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("smtp mailer: sending to %v: %v", message.To, ctx.Err())
+	}
+}
+
+// build renders the message as a multipart/alternative MIME document with a text and an HTML part.
+func build(from string, message Message) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	writer := multipart.NewWriter(buf)
+
+	fmt.Fprintf(buf, "From: %v\r\n", from)
+	fmt.Fprintf(buf, "To: %v\r\n", message.To)
+	fmt.Fprintf(buf, "Subject: %v\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(buf, "Date: %v\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(buf, "Content-Type: multipart/alternative; boundary=%v\r\n\r\n", writer.Boundary())
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=utf-8", message.Text},
+		{"text/html; charset=utf-8", message.HTML},
+	}
+	for _, part := range parts {
+		if part.content == "" {
+			continue
+		}
+
+		w, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		qp := quotedprintable.NewWriter(w)
+		_, err = qp.Write([]byte(part.content))
+		if err != nil {
+			return nil, err
+		}
+		err = qp.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := writer.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
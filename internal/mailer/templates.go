@@ -0,0 +1,107 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+var Locales = []string{"kk", "ru", "en"}
+
+const DefaultLocale = "ru"
+
+// Templates renders localized emails. Every template has a .txt file that defines the "subject"
+// and the plain text body, and an .html file that defines the "content" placed into layout.html.
+type Templates struct {
+	text map[string]*texttemplate.Template
+	html map[string]*htmltemplate.Template
+}
+
+func NewTemplates() (*Templates, error) {
+	t := &Templates{
+		text: make(map[string]*texttemplate.Template),
+		html: make(map[string]*htmltemplate.Template),
+	}
+
+	for _, locale := range Locales {
+		entries, err := templateFS.ReadDir("templates/" + locale)
+		if err != nil {
+			return nil, fmt.Errorf("mailer templates: reading %v: %v", locale, err)
+		}
+
+		for _, entry := range entries {
+			path := "templates/" + locale + "/" + entry.Name()
+
+			switch {
+			case strings.HasSuffix(entry.Name(), ".txt"):
+				tmpl, err := texttemplate.ParseFS(templateFS, path)
+				if err != nil {
+					return nil, fmt.Errorf("mailer templates: parsing %v: %v", path, err)
+				}
+				t.text[locale+"/"+strings.TrimSuffix(entry.Name(), ".txt")] = tmpl
+			case strings.HasSuffix(entry.Name(), ".html"):
+				tmpl, err := htmltemplate.ParseFS(templateFS, "templates/layout.html", path)
+				if err != nil {
+					return nil, fmt.Errorf("mailer templates: parsing %v: %v", path, err)
+				}
+				t.html[locale+"/"+strings.TrimSuffix(entry.Name(), ".html")] = tmpl
+			}
+		}
+	}
+
+	return t, nil
+}
+
+// Render builds a message from the named template, falling back to DefaultLocale
+// when the template is not translated to the requested locale.
+func (t *Templates) Render(name string, locale string, to string, data any) (*Message, error) {
+	key := locale + "/" + name
+	if _, ok := t.text[key]; !ok {
+		key = DefaultLocale + "/" + name
+	}
+
+	textTemplate, ok := t.text[key]
+	if !ok {
+		return nil, fmt.Errorf("mailer templates: template %v not found", name)
+	}
+
+	subject := &bytes.Buffer{}
+	err := textTemplate.ExecuteTemplate(subject, "subject", data)
+	if err != nil {
+		return nil, fmt.Errorf("mailer templates: rendering %v subject: %v", key, err)
+	}
+
+	text := &bytes.Buffer{}
+	err = textTemplate.Execute(text, data)
+	if err != nil {
+		return nil, fmt.Errorf("mailer templates: rendering %v text: %v", key, err)
+	}
+
+	message := &Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()),
+	}
+
+	htmlTemplate, ok := t.html[key]
+	if ok {
+		html := &bytes.Buffer{}
+		err = htmlTemplate.ExecuteTemplate(html, "layout", map[string]any{
+			"Locale":  strings.SplitN(key, "/", 2)[0],
+			"Subject": message.Subject,
+			"Data":    data,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("mailer templates: rendering %v html: %v", key, err)
+		}
+		message.HTML = html.String()
+	}
+
+	return message, nil
+}
@@ -0,0 +1,321 @@
+package payment
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/google/uuid"
+)
+
+const (
+	FreedomPayProviderName  = "freedompay"
+	freedomPayDefaultAPIURL = "https://api.freedompay.kz"
+)
+
+// freedomPayStatuses maps the pg_payment_status values of Freedom Pay to payment statuses.
+var freedomPayStatuses = map[string]entity.PaymentStatus{
+	"success":    entity.PaymentSucceeded,
+	"ok":         entity.PaymentSucceeded,
+	"pending":    entity.PaymentPending,
+	"partial":    entity.PaymentPending,
+	"failed":     entity.PaymentFailed,
+	"incomplete": entity.PaymentFailed,
+	"revoked":    entity.PaymentRefunded,
+	"refunded":   entity.PaymentRefunded,
+}
+
+type FreedomPayConfig struct {
+	APIURL     string
+	MerchantID string
+	SecretKey  string
+}
+
+// FreedomPayProvider talks to Freedom Pay (formerly PayBox). Requests are form encoded and
+// responses are XML; both are signed with pg_sig, the MD5 of the script name, the parameter
+// values sorted by parameter name and the secret key joined with semicolons.
+type FreedomPayProvider struct {
+	config     Config
+	apiURL     string
+	merchantID string
+	secretKey  string
+	client     *http.Client
+}
+
+func NewFreedomPayProvider(config Config, freedomPayConfig FreedomPayConfig) (*FreedomPayProvider, error) {
+	if freedomPayConfig.MerchantID == "" || freedomPayConfig.SecretKey == "" {
+		return nil, fmt.Errorf("freedompay: merchant id and secret key are required")
+	}
+
+	apiURL := freedomPayConfig.APIURL
+	if apiURL == "" {
+		apiURL = freedomPayDefaultAPIURL
+	}
+
+	return &FreedomPayProvider{
+		config:     config,
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		merchantID: freedomPayConfig.MerchantID,
+		secretKey:  freedomPayConfig.SecretKey,
+		client:     &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (p *FreedomPayProvider) Name() string {
+	return FreedomPayProviderName
+}
+
+func (p *FreedomPayProvider) CreateCheckout(ctx context.Context, order Order) (*Checkout, error) {
+	response, err := p.call(ctx, "init_payment.php", map[string]string{
+		"pg_order_id":           order.ID.String(),
+		"pg_amount":             strconv.FormatInt(order.Amount, 10),
+		"pg_currency":           order.Currency,
+		"pg_description":        order.Description,
+		"pg_user_contact_email": order.Email,
+		"pg_result_url":         p.config.callbackURL(),
+		"pg_success_url":        p.config.ReturnURL,
+		"pg_failure_url":        p.config.ReturnURL,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if response["pg_redirect_url"] == "" {
+		return nil, fmt.Errorf("freedompay: redirect url is empty")
+	}
+
+	return &Checkout{URL: response["pg_redirect_url"], ProviderPaymentID: response["pg_payment_id"]}, nil
+}
+
+// ParseCallback handles the request Freedom Pay sends to pg_result_url. The signature of the
+// request is made with the last segment of the callback path as the script name.
+func (p *FreedomPayProvider) ParseCallback(r *http.Request) (*Callback, error) {
+	err := r.ParseForm()
+	if err != nil {
+		return nil, fmt.Errorf("freedompay: %w: %v", ErrMalformedCallback, err)
+	}
+
+	params := make(map[string]string, len(r.Form))
+	for key := range r.Form {
+		params[key] = r.Form.Get(key)
+	}
+
+	expected := p.sign(path.Base(r.URL.Path), params)
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(strings.ToLower(params["pg_sig"]))) != 1 {
+		return nil, fmt.Errorf("freedompay: %w", ErrInvalidSignature)
+	}
+
+	orderID, err := uuid.Parse(params["pg_order_id"])
+	if err != nil {
+		return nil, fmt.Errorf("freedompay: %w: %v", ErrMalformedCallback, err)
+	}
+
+	amount, err := parseFreedomPayAmount(params["pg_amount"])
+	if err != nil {
+		return nil, fmt.Errorf("freedompay: %w: %v", ErrMalformedCallback, err)
+	}
+
+	status := entity.PaymentFailed
+	if params["pg_result"] == "1" {
+		status = entity.PaymentSucceeded
+	}
+
+	return &Callback{
+		OrderID:           orderID,
+		ProviderPaymentID: params["pg_payment_id"],
+		Status:            status,
+		Amount:            amount,
+		Payload:           r.Form.Encode(),
+	}, nil
+}
+
+// CallbackResponse answers with the signed XML Freedom Pay expects. Errors are reported with
+// pg_status "error" so Freedom Pay retries later; "rejected" is never used because it makes
+// Freedom Pay cancel a payment the buyer has already made.
+func (p *FreedomPayProvider) CallbackResponse(w http.ResponseWriter, r *http.Request, err error) {
+	params := map[string]string{
+		"pg_status": "ok",
+		"pg_salt":   salt(),
+	}
+	if err != nil {
+		params["pg_status"] = "error"
+		params["pg_description"] = err.Error()
+	}
+	params["pg_sig"] = p.sign(path.Base(r.URL.Path), params)
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	io.WriteString(w, xml.Header)
+	io.WriteString(w, "<response>")
+	for _, key := range sortedKeys(params) {
+		io.WriteString(w, "<"+key+">")
+		xml.EscapeText(w, []byte(params[key]))
+		io.WriteString(w, "</"+key+">")
+	}
+	io.WriteString(w, "</response>")
+}
+
+func (p *FreedomPayProvider) QueryStatus(ctx context.Context, reference Reference) (entity.PaymentStatus, error) {
+	params := map[string]string{"pg_order_id": reference.OrderID.String()}
+	if reference.ProviderPaymentID != "" {
+		params["pg_payment_id"] = reference.ProviderPaymentID
+	}
+
+	response, err := p.call(ctx, "get_status2.php", params)
+	if err != nil {
+		return "", err
+	}
+
+	status, ok := freedomPayStatuses[response["pg_payment_status"]]
+	if !ok {
+		return entity.PaymentStatus(response["pg_payment_status"]), nil
+	}
+
+	return status, nil
+}
+
+func (p *FreedomPayProvider) Refund(ctx context.Context, reference Reference, amount int64) error {
+	if reference.ProviderPaymentID == "" {
+		return fmt.Errorf("freedompay: payment id of order %v is unknown", reference.OrderID)
+	}
+
+	_, err := p.call(ctx, "revoke.php", map[string]string{
+		"pg_payment_id":    reference.ProviderPaymentID,
+		"pg_refund_amount": strconv.FormatInt(amount, 10),
+	})
+
+	return err
+}
+
+func (p *FreedomPayProvider) Cancel(ctx context.Context, reference Reference) error {
+	if reference.ProviderPaymentID == "" {
+		return fmt.Errorf("freedompay: payment id of order %v is unknown", reference.OrderID)
+	}
+
+	_, err := p.call(ctx, "cancel.php", map[string]string{
+		"pg_payment_id": reference.ProviderPaymentID,
+	})
+
+	return err
+}
+
+// call signs the parameters, posts them to the script and returns the fields of the verified response.
+func (p *FreedomPayProvider) call(ctx context.Context, script string, params map[string]string) (map[string]string, error) {
+	params["pg_merchant_id"] = p.merchantID
+	params["pg_salt"] = salt()
+	params["pg_sig"] = p.sign(script, params)
+
+	form := url.Values{}
+	for key, value := range params {
+		form.Set(key, value)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL+"/"+script, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("freedompay: %v", err)
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := p.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("freedompay: %v", err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("freedompay: %v", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("freedompay: %w: status %v: %s", ErrProviderRejected, response.StatusCode, body)
+	}
+
+	fields, err := parseFreedomPayResponse(body)
+	if err != nil {
+		return nil, fmt.Errorf("freedompay: %v", err)
+	}
+	if p.sign(script, fields) != strings.ToLower(fields["pg_sig"]) {
+		return nil, fmt.Errorf("freedompay: response %w", ErrInvalidSignature)
+	}
+	if fields["pg_status"] != "ok" {
+		return nil, fmt.Errorf("freedompay: %w: %v %v", ErrProviderRejected, fields["pg_error_code"], fields["pg_error_description"])
+	}
+
+	return fields, nil
+}
+
+// sign computes pg_sig of the parameters, pg_sig itself is left out.
+func (p *FreedomPayProvider) sign(script string, params map[string]string) string {
+	values := []string{script}
+	for _, key := range sortedKeys(params) {
+		if key == "pg_sig" {
+			continue
+		}
+		values = append(values, params[key])
+	}
+	values = append(values, p.secretKey)
+
+	sum := md5.Sum([]byte(strings.Join(values, ";")))
+	return hex.EncodeToString(sum[:])
+}
+
+func parseFreedomPayResponse(body []byte) (map[string]string, error) {
+	response := struct {
+		Fields []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:",any"`
+	}{}
+
+	err := xml.Unmarshal(body, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]string, len(response.Fields))
+	for _, field := range response.Fields {
+		fields[field.XMLName.Local] = strings.TrimSpace(field.Value)
+	}
+
+	return fields, nil
+}
+
+func parseFreedomPayAmount(amount string) (int64, error) {
+	value, err := strconv.ParseFloat(amount, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid pg_amount %q", amount)
+	}
+	if value < 0 || math.IsInf(value, 0) || math.IsNaN(value) {
+		return 0, fmt.Errorf("invalid pg_amount %q", amount)
+	}
+
+	return int64(math.Round(value)), nil
+}
+
+func sortedKeys(params map[string]string) []string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func salt() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
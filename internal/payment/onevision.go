@@ -0,0 +1,297 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/google/uuid"
+)
+
+const (
+	OneVisionProviderName  = "onevision"
+	oneVisionDefaultAPIURL = "https://api.onevisionpay.com"
+)
+
+// oneVisionStatuses maps the operation_status values of OneVision to payment statuses.
+var oneVisionStatuses = map[string]entity.PaymentStatus{
+	"success":   entity.PaymentSucceeded,
+	"pending":   entity.PaymentPending,
+	"process":   entity.PaymentPending,
+	"error":     entity.PaymentFailed,
+	"failed":    entity.PaymentFailed,
+	"cancel":    entity.PaymentCancelled,
+	"cancelled": entity.PaymentCancelled,
+	"refund":    entity.PaymentRefunded,
+}
+
+type OneVisionConfig struct {
+	APIURL    string
+	APIKey    string
+	SecretKey string
+	// SignatureMethod and PublicKey configure how callbacks are verified, see NewSignatureVerifier.
+	SignatureMethod string
+	PublicKey       string
+}
+
+// OneVisionProvider talks to OneVision. Every request and callback is a JSON envelope with
+// base64 encoded JSON data and its signature, requests are signed with HMAC-SHA512 of the data.
+type OneVisionProvider struct {
+	config   Config
+	apiURL   string
+	apiKey   string
+	signer   *HMACVerifier
+	verifier SignatureVerifier
+	client   *http.Client
+}
+
+func NewOneVisionProvider(config Config, oneVisionConfig OneVisionConfig) (*OneVisionProvider, error) {
+	signer, err := NewHMACVerifier(sha512.New, oneVisionConfig.SecretKey)
+	if err != nil {
+		return nil, fmt.Errorf("onevision: %v", err)
+	}
+
+	verifier, err := NewSignatureVerifier(oneVisionConfig.SignatureMethod, oneVisionConfig.SecretKey, oneVisionConfig.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("onevision: %v", err)
+	}
+
+	apiURL := oneVisionConfig.APIURL
+	if apiURL == "" {
+		apiURL = oneVisionDefaultAPIURL
+	}
+
+	return &OneVisionProvider{
+		config:   config,
+		apiURL:   strings.TrimSuffix(apiURL, "/"),
+		apiKey:   oneVisionConfig.APIKey,
+		signer:   signer,
+		verifier: verifier,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (p *OneVisionProvider) Name() string {
+	return OneVisionProviderName
+}
+
+type oneVisionEnvelope struct {
+	APIKey   string `json:"api_key,omitempty"`
+	Data     string `json:"data"`
+	Sign     string `json:"sign"`
+	Success  *bool  `json:"success,omitempty"`
+	ErrorMsg string `json:"error_msg,omitempty"`
+}
+
+type oneVisionPayment struct {
+	OrderID         uuid.UUID `json:"order_id"`
+	PaymentID       string    `json:"payment_id"`
+	OperationStatus string    `json:"operation_status"`
+	Amount          int64     `json:"amount"`
+	PaymentPageURL  string    `json:"payment_page_url"`
+}
+
+func (p *OneVisionProvider) CreateCheckout(ctx context.Context, order Order) (*Checkout, error) {
+	response := oneVisionPayment{}
+	err := p.post(ctx, "/payment/create", map[string]any{
+		"amount":         order.Amount,
+		"currency":       order.Currency,
+		"order_id":       order.ID,
+		"description":    order.Description,
+		"payment_type":   "pay",
+		"payment_method": "ecom",
+		"user_email":     order.Email,
+		"callback_url":   p.config.callbackURL(),
+		"success_url":    p.config.ReturnURL,
+		"failure_url":    p.config.ReturnURL,
+	}, &response)
+	if err != nil {
+		return nil, err
+	}
+	if response.PaymentPageURL == "" {
+		return nil, fmt.Errorf("onevision: payment page url is empty")
+	}
+
+	return &Checkout{URL: response.PaymentPageURL, ProviderPaymentID: response.PaymentID}, nil
+}
+
+func (p *OneVisionProvider) ParseCallback(r *http.Request) (*Callback, error) {
+	callback, err := parseSignedJSONCallback(r, p.verifier)
+	if err != nil {
+		return nil, fmt.Errorf("onevision: %w", err)
+	}
+
+	return callback, nil
+}
+
+// CallbackResponse acknowledges the callback with a 200 response and {"ok": true}, OneVision
+// retries the callback on any other response.
+func (p *OneVisionProvider) CallbackResponse(w http.ResponseWriter, r *http.Request, err error) {
+	writeJSONCallbackResponse(w, err)
+}
+
+func (p *OneVisionProvider) QueryStatus(ctx context.Context, reference Reference) (entity.PaymentStatus, error) {
+	response := oneVisionPayment{}
+	err := p.post(ctx, "/payment/status", map[string]any{
+		"order_id":   reference.OrderID,
+		"payment_id": reference.ProviderPaymentID,
+	}, &response)
+	if err != nil {
+		return "", err
+	}
+
+	return oneVisionStatus(response.OperationStatus), nil
+}
+
+func (p *OneVisionProvider) Refund(ctx context.Context, reference Reference, amount int64) error {
+	return p.post(ctx, "/payment/refund", map[string]any{
+		"order_id":   reference.OrderID,
+		"payment_id": reference.ProviderPaymentID,
+		"amount":     amount,
+	}, nil)
+}
+
+func (p *OneVisionProvider) Cancel(ctx context.Context, reference Reference) error {
+	return p.post(ctx, "/payment/cancel", map[string]any{
+		"order_id":   reference.OrderID,
+		"payment_id": reference.ProviderPaymentID,
+	}, nil)
+}
+
+func (p *OneVisionProvider) post(ctx context.Context, path string, params any, out any) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("onevision: %v", err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(data)
+
+	body, err := json.Marshal(oneVisionEnvelope{
+		APIKey: p.apiKey,
+		Data:   encoded,
+		Sign:   p.signer.Sign([]byte(encoded)),
+	})
+	if err != nil {
+		return fmt.Errorf("onevision: %v", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("onevision: %v", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := p.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("onevision: %v", err)
+	}
+	defer response.Body.Close()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("onevision: %v", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("onevision: %w: status %v: %s", ErrProviderRejected, response.StatusCode, responseBody)
+	}
+
+	envelope := oneVisionEnvelope{}
+	err = json.Unmarshal(responseBody, &envelope)
+	if err != nil {
+		return fmt.Errorf("onevision: %v", err)
+	}
+	if envelope.Success != nil && !*envelope.Success {
+		return fmt.Errorf("onevision: %w: %v", ErrProviderRejected, envelope.ErrorMsg)
+	}
+	if out == nil || envelope.Data == "" {
+		return nil
+	}
+
+	err = p.verifier.Verify([]byte(envelope.Data), envelope.Sign)
+	if err != nil {
+		return fmt.Errorf("onevision: response %w", err)
+	}
+
+	data, err = base64.StdEncoding.DecodeString(envelope.Data)
+	if err != nil {
+		return fmt.Errorf("onevision: %v", err)
+	}
+
+	err = json.Unmarshal(data, out)
+	if err != nil {
+		return fmt.Errorf("onevision: %v", err)
+	}
+
+	return nil
+}
+
+// parseSignedJSONCallback reads a callback in the OneVision format: a JSON body with base64 encoded
+// JSON data and the signature of the encoded data.
+func parseSignedJSONCallback(r *http.Request, verifier SignatureVerifier) (*Callback, error) {
+	envelope := oneVisionEnvelope{}
+	err := json.NewDecoder(r.Body).Decode(&envelope)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedCallback, err)
+	}
+
+	err = verifier.Verify([]byte(envelope.Data), envelope.Sign)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(envelope.Data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedCallback, err)
+	}
+
+	payment := oneVisionPayment{}
+	err = json.Unmarshal(data, &payment)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedCallback, err)
+	}
+
+	return &Callback{
+		OrderID:           payment.OrderID,
+		ProviderPaymentID: payment.PaymentID,
+		Status:            oneVisionStatus(payment.OperationStatus),
+		Amount:            payment.Amount,
+		Payload:           string(data),
+	}, nil
+}
+
+func oneVisionStatus(operationStatus string) entity.PaymentStatus {
+	status, ok := oneVisionStatuses[operationStatus]
+	if !ok {
+		return entity.PaymentStatus(operationStatus)
+	}
+
+	return status
+}
+
+// writeJSONCallbackResponse writes {"ok": true} for accepted callbacks and {"ok": false, "error": ...}
+// with a 401 or 400 response otherwise.
+func writeJSONCallbackResponse(w http.ResponseWriter, err error) {
+	response := struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error,omitempty"`
+	}{OK: err == nil}
+	if err != nil {
+		response.Error = err.Error()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if errors.Is(err, ErrInvalidSignature) {
+		w.WriteHeader(http.StatusUnauthorized)
+	} else if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,122 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrMalformedCallback = errors.New("malformed callback")
+	ErrProviderRejected  = errors.New("rejected by payment provider")
+)
+
+// Order is what a provider needs to know to charge the buyer.
+type Order struct {
+	ID          uuid.UUID
+	Amount      int64
+	Currency    string
+	Description string
+	Email       string
+}
+
+type Checkout struct {
+	// URL is the payment page the buyer has to be sent to.
+	URL string
+	// ProviderPaymentID is the id of the payment on the provider side, if the provider assigns it
+	// when the checkout is created.
+	ProviderPaymentID string
+}
+
+// Callback is a status notification sent by the provider.
+type Callback struct {
+	OrderID           uuid.UUID
+	ProviderPaymentID string
+	Status            entity.PaymentStatus
+	// Amount is the paid amount reported by the provider, zero if the provider does not send it. A
+	// succeeded callback without it is not applied.
+	Amount int64
+	// Payload is the raw callback, kept in the payment history.
+	Payload string
+}
+
+// Reference identifies a payment on the provider side.
+type Reference struct {
+	OrderID           uuid.UUID
+	ProviderPaymentID string
+}
+
+// Provider is a payment gateway adapter.
+type Provider interface {
+	Name() string
+	CreateCheckout(ctx context.Context, order Order) (*Checkout, error)
+	// ParseCallback verifies the signature of a callback request and extracts the payment status from it.
+	ParseCallback(r *http.Request) (*Callback, error)
+	// CallbackResponse writes the response the provider expects for a callback, err is nil when the
+	// callback was accepted. Providers retry callbacks until they are accepted.
+	CallbackResponse(w http.ResponseWriter, r *http.Request, err error)
+	QueryStatus(ctx context.Context, reference Reference) (entity.PaymentStatus, error)
+	// Refund returns the amount to the buyer, the amount can be less than what was paid.
+	Refund(ctx context.Context, reference Reference, amount int64) error
+	// Cancel closes the checkout of an unpaid order, so the buyer can no longer pay it.
+	Cancel(ctx context.Context, reference Reference) error
+}
+
+type Config struct {
+	// BaseURL is the public URL of this server, the provider sends callbacks to BaseURL/payment/confirm.
+	BaseURL string
+	// ReturnURL is where the buyer is sent back to after the checkout.
+	ReturnURL string
+}
+
+func (c Config) callbackURL() string {
+	return strings.TrimSuffix(c.BaseURL, "/") + "/payment/confirm"
+}
+
+// New returns the provider selected by PAYMENT_PROVIDER: "onevision", "freedompay" or "simulator".
+// The simulator marks any order as paid on request, so it also has to be enabled with
+// PAYMENT_SIMULATOR=true, and a server without a provider configured does not start.
+func New() (Provider, error) {
+	config := Config{
+		BaseURL:   os.Getenv("PAYMENT_BASE_URL"),
+		ReturnURL: os.Getenv("PAYMENT_RETURN_URL"),
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = os.Getenv("APP_URL")
+	}
+	if config.ReturnURL == "" {
+		config.ReturnURL = os.Getenv("APP_URL")
+	}
+
+	switch os.Getenv("PAYMENT_PROVIDER") {
+	case "":
+		return nil, errors.New("payment: PAYMENT_PROVIDER is not set")
+	case OneVisionProviderName:
+		return NewOneVisionProvider(config, OneVisionConfig{
+			APIURL:          os.Getenv("ONEVISION_API_URL"),
+			APIKey:          os.Getenv("ONEVISION_API_KEY"),
+			SecretKey:       os.Getenv("PAYMENT_SECRET_KEY"),
+			SignatureMethod: os.Getenv("PAYMENT_SIGNATURE_METHOD"),
+			PublicKey:       os.Getenv("PAYMENT_PUBLIC_KEY"),
+		})
+	case FreedomPayProviderName:
+		return NewFreedomPayProvider(config, FreedomPayConfig{
+			APIURL:     os.Getenv("FREEDOMPAY_API_URL"),
+			MerchantID: os.Getenv("FREEDOMPAY_MERCHANT_ID"),
+			SecretKey:  os.Getenv("FREEDOMPAY_SECRET_KEY"),
+		})
+	case SimulatorProviderName:
+		if os.Getenv("PAYMENT_SIMULATOR") != "true" {
+			return nil, errors.New("payment: the simulator has to be enabled with PAYMENT_SIMULATOR=true")
+		}
+		return NewSimulator(config)
+	default:
+		return nil, fmt.Errorf("payment: unknown provider %q", os.Getenv("PAYMENT_PROVIDER"))
+	}
+}
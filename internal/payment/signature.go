@@ -0,0 +1,111 @@
+package payment
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// SignatureVerifier checks that a payload was signed by the payment provider.
+type SignatureVerifier interface {
+	Verify(payload []byte, signature string) error
+}
+
+// NewSignatureVerifier creates a verifier for the signature method: hmac-sha512 (default) and
+// hmac-sha256 use the secret, rsa-sha256 uses the PEM encoded public key.
+func NewSignatureVerifier(method string, secret string, publicKeyPEM string) (SignatureVerifier, error) {
+	switch method {
+	case "", "hmac-sha512":
+		return NewHMACVerifier(sha512.New, secret)
+	case "hmac-sha256":
+		return NewHMACVerifier(sha256.New, secret)
+	case "rsa-sha256":
+		return NewRSAVerifier([]byte(publicKeyPEM))
+	default:
+		return nil, fmt.Errorf("unknown payment signature method %q", method)
+	}
+}
+
+// HMACVerifier checks hex encoded HMAC signatures.
+type HMACVerifier struct {
+	hash   func() hash.Hash
+	secret []byte
+}
+
+func NewHMACVerifier(hash func() hash.Hash, secret string) (*HMACVerifier, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("payment secret key is empty")
+	}
+
+	return &HMACVerifier{hash: hash, secret: []byte(secret)}, nil
+}
+
+func (v *HMACVerifier) Sign(payload []byte) string {
+	return hex.EncodeToString(v.sum(payload))
+}
+
+func (v *HMACVerifier) Verify(payload []byte, signature string) error {
+	actual, err := hex.DecodeString(strings.TrimSpace(signature))
+	if err != nil || !hmac.Equal(v.sum(payload), actual) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func (v *HMACVerifier) sum(payload []byte) []byte {
+	mac := hmac.New(v.hash, v.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// RSAVerifier checks base64 encoded RSA PKCS #1 v1.5 signatures over the SHA-256 digest of the payload.
+type RSAVerifier struct {
+	key *rsa.PublicKey
+}
+
+func NewRSAVerifier(publicKeyPEM []byte) (*RSAVerifier, error) {
+	block, _ := pem.Decode(publicKeyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("payment public key is not PEM encoded")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("payment public key parse error: %v", err)
+	}
+
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("payment public key is not an RSA key")
+	}
+
+	return &RSAVerifier{key: rsaKey}, nil
+}
+
+func (v *RSAVerifier) Verify(payload []byte, signature string) error {
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	digest := sha256.Sum256(payload)
+	err = rsa.VerifyPKCS1v15(v.key, crypto.SHA256, digest[:], decoded)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
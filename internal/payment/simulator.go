@@ -0,0 +1,237 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/google/uuid"
+)
+
+const SimulatorProviderName = "simulator"
+
+// simulatorOperations maps the buttons of the checkout page to the OneVision operation statuses
+// the simulator reports.
+var simulatorOperations = map[string]string{
+	"pay":     "success",
+	"decline": "error",
+	"cancel":  "cancel",
+}
+
+var simulatorPage = template.Must(template.New("checkout").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Payment simulator</title>
+<style>body{font-family:sans-serif;max-width:480px;margin:48px auto;color:#222}button{margin-right:8px;padding:8px 16px}</style>
+</head>
+<body>
+<h1>Payment simulator</h1>
+{{if .Order}}
+<p>{{.Order.Description}}</p>
+<p><b>{{.Order.Amount}} {{.Order.Currency}}</b></p>
+<p>Order {{.Order.ID}}, status: {{.Status}}</p>
+{{if .Result}}
+<p>{{.Result}}</p>
+{{if .ReturnURL}}<p><a href="{{.ReturnURL}}">Back to the shop</a></p>{{end}}
+{{else}}
+<form method="post">
+<input type="hidden" name="order_id" value="{{.Order.ID}}">
+<button name="action" value="pay">Pay</button>
+<button name="action" value="decline">Decline</button>
+<button name="action" value="cancel">Cancel</button>
+</form>
+{{end}}
+{{else}}
+<p>Order not found.</p>
+{{end}}
+</body>
+</html>
+`))
+
+type simulatedOrder struct {
+	order     Order
+	paymentID string
+	status    entity.PaymentStatus
+	refunded  int64
+}
+
+// Simulator is an in-process provider for local development and QA. Its checkout page served at
+// /payment/simulator/checkout lets the buyer pay, decline or cancel, and the outcome is passed as a
+// callback with a OneVision payload straight to the function set with SetCallback, so purchases
+// are applied the same way as with a real provider. Orders are kept in memory.
+type Simulator struct {
+	config   Config
+	mu       sync.Mutex
+	orders   map[uuid.UUID]*simulatedOrder
+	callback func(callback *Callback) error
+}
+
+func NewSimulator(config Config) (*Simulator, error) {
+	return &Simulator{config: config, orders: make(map[uuid.UUID]*simulatedOrder)}, nil
+}
+
+// SetCallback sets the function the simulator delivers callbacks to.
+func (s *Simulator) SetCallback(callback func(callback *Callback) error) {
+	s.callback = callback
+}
+
+func (s *Simulator) Name() string {
+	return SimulatorProviderName
+}
+
+func (s *Simulator) CreateCheckout(ctx context.Context, order Order) (*Checkout, error) {
+	paymentID := "sim-" + uuid.NewString()
+
+	s.mu.Lock()
+	s.orders[order.ID] = &simulatedOrder{order: order, paymentID: paymentID, status: entity.PaymentPending}
+	s.mu.Unlock()
+
+	query := url.Values{}
+	query.Set("order_id", order.ID.String())
+
+	return &Checkout{
+		URL:               fmt.Sprintf("%v/payment/simulator/checkout?%v", strings.TrimSuffix(s.config.BaseURL, "/"), query.Encode()),
+		ProviderPaymentID: paymentID,
+	}, nil
+}
+
+// ParseCallback rejects every request, the simulator delivers its callbacks in process.
+func (s *Simulator) ParseCallback(r *http.Request) (*Callback, error) {
+	return nil, fmt.Errorf("simulator: %w: callbacks are not accepted over http", ErrMalformedCallback)
+}
+
+func (s *Simulator) CallbackResponse(w http.ResponseWriter, r *http.Request, err error) {
+	writeJSONCallbackResponse(w, err)
+}
+
+func (s *Simulator) QueryStatus(ctx context.Context, reference Reference) (entity.PaymentStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[reference.OrderID]
+	if !ok {
+		return "", fmt.Errorf("simulator: %w: order %v is unknown", ErrProviderRejected, reference.OrderID)
+	}
+
+	return order.status, nil
+}
+
+func (s *Simulator) Refund(ctx context.Context, reference Reference, amount int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[reference.OrderID]
+	if !ok || order.status != entity.PaymentSucceeded {
+		return fmt.Errorf("simulator: %w: order %v is not paid", ErrProviderRejected, reference.OrderID)
+	}
+	if amount <= 0 || order.refunded+amount > order.order.Amount {
+		return fmt.Errorf("simulator: %w: refund amount %v is more than the paid amount", ErrProviderRejected, amount)
+	}
+
+	order.refunded += amount
+	if order.refunded == order.order.Amount {
+		order.status = entity.PaymentRefunded
+	}
+
+	return nil
+}
+
+func (s *Simulator) Cancel(ctx context.Context, reference Reference) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[reference.OrderID]
+	if !ok {
+		return fmt.Errorf("simulator: %w: order %v is unknown", ErrProviderRejected, reference.OrderID)
+	}
+	if order.status != entity.PaymentCreated && order.status != entity.PaymentPending {
+		return fmt.Errorf("simulator: %w: order %v is %v", ErrProviderRejected, reference.OrderID, order.status)
+	}
+
+	order.status = entity.PaymentCancelled
+
+	return nil
+}
+
+// ServeHTTP serves the checkout page.
+func (s *Simulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	orderID, _ := uuid.Parse(r.FormValue("order_id"))
+
+	s.mu.Lock()
+	order, ok := s.orders[orderID]
+	s.mu.Unlock()
+
+	data := map[string]any{}
+	if ok {
+		data["Order"] = order.order
+		data["Status"] = order.status
+	}
+
+	if ok && r.Method == http.MethodPost {
+		operation, known := simulatorOperations[r.FormValue("action")]
+		if !known {
+			http.Error(w, "unknown action", http.StatusUnprocessableEntity)
+			return
+		}
+
+		data["Result"] = s.complete(order, operation)
+		data["Status"] = order.status
+		data["ReturnURL"] = s.config.ReturnURL
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+	}
+	err := simulatorPage.Execute(w, data)
+	if err != nil {
+		log.Printf("simulator: rendering checkout page: %v", err)
+	}
+}
+
+// complete records the outcome of the checkout and delivers it as a callback, it returns a
+// description of what happened for the checkout page.
+func (s *Simulator) complete(order *simulatedOrder, operation string) string {
+	s.mu.Lock()
+	if order.status == entity.PaymentCancelled {
+		s.mu.Unlock()
+		return "The checkout was cancelled, the order can no longer be paid."
+	}
+	order.status = oneVisionStatus(operation)
+	s.mu.Unlock()
+
+	if s.callback == nil {
+		return "Callback is not set, the order was not updated."
+	}
+
+	data, err := json.Marshal(map[string]any{
+		"order_id":         order.order.ID,
+		"payment_id":       order.paymentID,
+		"operation_status": operation,
+		"amount":           order.order.Amount,
+	})
+	if err != nil {
+		return fmt.Sprintf("Callback failed: %v", err)
+	}
+
+	err = s.callback(&Callback{
+		OrderID:           order.order.ID,
+		ProviderPaymentID: order.paymentID,
+		Status:            order.status,
+		Amount:            order.order.Amount,
+		Payload:           string(data),
+	})
+	if err != nil {
+		return fmt.Sprintf("Payment %v, callback failed: %v", order.status, err)
+	}
+
+	return fmt.Sprintf("Payment %v, callback applied", order.status)
+}
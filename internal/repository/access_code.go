@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+const (
+	CODE_BATCH_INSERT_STATEMENT  = "insert into code_batches(id, course_id, label, quantity, expires_at, created_by) values(uuid_to_bin(?), uuid_to_bin(?), ?, ?, ?, uuid_to_bin(?))"
+	CODE_BATCH_SELECT_STATEMENT  = "select b.id, b.created_at, b.course_id, b.label, b.quantity, b.expires_at, b.created_by, (select count(*) from access_codes c where c.batch_id = b.id and c.redeemed_at is not null) from code_batches b"
+	ACCESS_CODE_INSERT_STATEMENT = "insert into access_codes(id, code, course_id, batch_id, order_id, expires_at) values(uuid_to_bin(?), ?, uuid_to_bin(?), uuid_to_bin(?), uuid_to_bin(?), ?)"
+	ACCESS_CODE_SELECT_STATEMENT = "select c.id, c.created_at, c.code, c.course_id, c.batch_id, c.order_id, c.expires_at, c.redeemed_by, c.redeemed_at, c.voided_at from access_codes c"
+	ACCESS_CODE_CLAIM_STATEMENT  = "update access_codes set redeemed_by = uuid_to_bin(?), redeemed_at = current_timestamp " +
+		"where id = uuid_to_bin(?) and redeemed_at is null and voided_at is null and (expires_at is null or expires_at > current_timestamp)"
+	ACCESS_CODE_VOID_STATEMENT = "update access_codes set voided_at = current_timestamp where order_id = uuid_to_bin(?) and voided_at is null"
+)
+
+type AccessCodeRepository struct {
+	db *sql.DB
+}
+
+func NewAccessCodeRepository(db *sql.DB) *AccessCodeRepository {
+	return &AccessCodeRepository{db: db}
+}
+
+// CreateBatch adds the batch together with its codes.
+func (r *AccessCodeRepository) CreateBatch(batch entity.NewCodeBatch, createdBy uuid.UUID, codes []string) (uuid.UUID, error) {
+	newID := uuid.New()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("access code repo error when adding new batch: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(CODE_BATCH_INSERT_STATEMENT, newID, batch.CourseID, batch.Label, len(codes), batch.ExpiresAt, createdBy)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("access code repo error when adding new batch: %v", err)
+	}
+
+	for _, code := range codes {
+		_, err = tx.Exec(ACCESS_CODE_INSERT_STATEMENT, uuid.New(), code, batch.CourseID, newID, nil, batch.ExpiresAt)
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("access code repo error when adding code to batch: %v", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("access code repo error when adding new batch: %v", err)
+	}
+
+	return newID, nil
+}
+
+// CreateGift adds the code bought with the order.
+func (r *AccessCodeRepository) CreateGift(code string, courseID uuid.UUID, orderID uuid.UUID) error {
+	_, err := r.db.Exec(ACCESS_CODE_INSERT_STATEMENT, uuid.New(), code, courseID, nil, orderID, nil)
+	if err != nil {
+		return fmt.Errorf("access code repo error when adding gift code: %v", err)
+	}
+
+	return nil
+}
+
+func (r *AccessCodeRepository) ReadBatches(pagination entity.Pagination, courseID *uuid.UUID) ([]entity.CodeBatch, error) {
+	statement := CODE_BATCH_SELECT_STATEMENT
+	args := make([]any, 0, 3)
+
+	if courseID != nil {
+		statement += " where b.course_id = uuid_to_bin(?)"
+		args = append(args, *courseID)
+	}
+
+	if pagination.Limit == 0 {
+		pagination.Limit = 1
+	}
+	statement += " order by b.created_at desc limit ? offset ?"
+	args = append(args, pagination.Limit, pagination.Offset)
+
+	rows, err := r.db.Query(statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("access code repo error on reading batches: %v", err)
+	}
+	defer rows.Close()
+
+	batches := make([]entity.CodeBatch, 0)
+	for rows.Next() {
+		batch := entity.CodeBatch{}
+		var expiresAt sql.NullTime
+		var createdBy uuid.NullUUID
+
+		err = rows.Scan(&batch.ID, &batch.CreatedAt, &batch.CourseID, &batch.Label, &batch.Quantity, &expiresAt, &createdBy, &batch.Redeemed)
+		if err != nil {
+			return nil, fmt.Errorf("access code repo error on scanning a batch: %v", err)
+		}
+
+		if expiresAt.Valid {
+			batch.ExpiresAt = &expiresAt.Time
+		}
+		if createdBy.Valid {
+			batch.CreatedBy = &createdBy.UUID
+		}
+
+		batches = append(batches, batch)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("access code repo error on rows when reading batches: %v", err)
+	}
+
+	return batches, nil
+}
+
+type AccessCodeFilters struct {
+	Code    *string
+	BatchID *uuid.UUID
+	// BuyerID selects the codes of the gift orders of the user.
+	BuyerID *uuid.UUID
+}
+
+func (r *AccessCodeRepository) Read(pagination entity.Pagination, filters AccessCodeFilters) ([]entity.AccessCode, error) {
+	statement := ACCESS_CODE_SELECT_STATEMENT
+	args := make([]any, 0, 5)
+
+	if filters.BuyerID != nil {
+		statement += " join course_payments p on p.order_id = c.order_id"
+	}
+	if filters.Code != nil || filters.BatchID != nil || filters.BuyerID != nil {
+		statement += " where "
+	}
+	if filters.Code != nil {
+		statement += "c.code = ? and "
+		args = append(args, *filters.Code)
+	}
+	if filters.BatchID != nil {
+		statement += "c.batch_id = uuid_to_bin(?) and "
+		args = append(args, *filters.BatchID)
+	}
+	if filters.BuyerID != nil {
+		statement += "p.user_id = uuid_to_bin(?) and "
+		args = append(args, *filters.BuyerID)
+	}
+	statement = strings.TrimSuffix(statement, " and ")
+
+	if pagination.Limit == 0 {
+		pagination.Limit = 1
+	}
+	statement += " order by c.created_at desc limit ? offset ?"
+	args = append(args, pagination.Limit, pagination.Offset)
+
+	rows, err := r.db.Query(statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("access code repo error on reading codes: %v", err)
+	}
+	defer rows.Close()
+
+	codes := make([]entity.AccessCode, 0)
+	for rows.Next() {
+		code := entity.AccessCode{}
+		var batchID, orderID, redeemedBy uuid.NullUUID
+		var expiresAt, redeemedAt, voidedAt sql.NullTime
+
+		err = rows.Scan(&code.ID, &code.CreatedAt, &code.Code, &code.CourseID, &batchID, &orderID, &expiresAt, &redeemedBy, &redeemedAt, &voidedAt)
+		if err != nil {
+			return nil, fmt.Errorf("access code repo error on scanning a code: %v", err)
+		}
+
+		if batchID.Valid {
+			code.BatchID = &batchID.UUID
+		}
+		if orderID.Valid {
+			code.OrderID = &orderID.UUID
+		}
+		if redeemedBy.Valid {
+			code.RedeemedBy = &redeemedBy.UUID
+		}
+		code.ExpiresAt = nullTime(expiresAt)
+		code.RedeemedAt = nullTime(redeemedAt)
+		code.VoidedAt = nullTime(voidedAt)
+
+		codes = append(codes, code)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("access code repo error on rows when reading codes: %v", err)
+	}
+
+	return codes, nil
+}
+
+// Find returns the code, or nil if there is none.
+func (r *AccessCodeRepository) Find(code string) (*entity.AccessCode, error) {
+	codes, err := r.Read(entity.Pagination{Limit: 1}, AccessCodeFilters{Code: &code})
+	if err != nil {
+		return nil, err
+	}
+	if len(codes) == 0 {
+		return nil, nil
+	}
+
+	return &codes[0], nil
+}
+
+// Claim marks the code as redeemed by the user if it is still redeemable and reports whether it did.
+// The check and the update are a single statement so a code can only be redeemed once.
+func (r *AccessCodeRepository) Claim(id uuid.UUID, userID uuid.UUID) (bool, error) {
+	result, err := r.db.Exec(ACCESS_CODE_CLAIM_STATEMENT, userID, id)
+	if err != nil {
+		return false, fmt.Errorf("access code repo error when redeeming code: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("access code repo error when redeeming code: %v", err)
+	}
+
+	return affected == 1, nil
+}
+
+// VoidOrder voids the code bought with the order.
+func (r *AccessCodeRepository) VoidOrder(orderID uuid.UUID) error {
+	_, err := r.db.Exec(ACCESS_CODE_VOID_STATEMENT, orderID)
+	if err != nil {
+		return fmt.Errorf("access code repo error when voiding code: %v", err)
+	}
+
+	return nil
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+
+	return &t.Time
+}
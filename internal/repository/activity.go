@@ -8,8 +8,9 @@ import (
 )
 
 const (
-	ACTIVITY_INSERT_STATEMENT = "insert into user_activity(id, user_id, course_id, module_id) values(uuid_to_bin(?), uuid_to_bin(?), uuid_to_bin(?), uuid_to_bin(?))"
-	ACTIVITY_SELECT_STATEMENT = "select id, user_id, course_id, module_id from user_activity"
+	ACTIVITY_INSERT_STATEMENT   = "insert into user_activity(id, user_id, course_id, module_id) values(uuid_to_bin(?), uuid_to_bin(?), uuid_to_bin(?), uuid_to_bin(?))"
+	ACTIVITY_SELECT_STATEMENT   = "select id, user_id, course_id, module_id from user_activity"
+	ACTIVITY_PROGRESS_STATEMENT = "select count(distinct a.module_id), (select count(*) from modules where course_id = uuid_to_bin(?)) from user_activity a join modules m on m.id = a.module_id and m.course_id = a.course_id where a.user_id = uuid_to_bin(?) and a.course_id = uuid_to_bin(?)"
 )
 
 type ActivityRepository struct {
@@ -99,3 +100,15 @@ func (r *ActivityRepository) Read(filters *ActivityFilters) ([]*Activity, error)
 
 	return activities, nil
 }
+
+// Progress returns how many distinct modules of the course the user has completed and how many modules the course has.
+func (r *ActivityRepository) Progress(userID uuid.UUID, courseID uuid.UUID) (int64, int64, error) {
+	var completed, total int64
+
+	err := r.db.QueryRow(ACTIVITY_PROGRESS_STATEMENT, courseID, userID, courseID).Scan(&completed, &total)
+	if err != nil {
+		return 0, 0, fmt.Errorf("activity repo error on progress: %v", err)
+	}
+
+	return completed, total, nil
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+)
+
+const AUDIT_INSERT_STATEMENT = "insert into audit_log(id, actor_id, action, entity_type, entity_id, details) values(uuid_to_bin(?), uuid_to_bin(?), ?, ?, ?, ?)"
+
+type AuditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+type AuditEntry struct {
+	ActorID    uuid.UUID
+	Action     string
+	EntityType string
+	EntityID   string
+	Details    map[string]any
+}
+
+func (r *AuditRepository) Create(entry *AuditEntry) error {
+	newID := uuid.New()
+
+	details, err := json.Marshal(entry.Details)
+	if err != nil {
+		return fmt.Errorf("audit repo error when encoding details: %v", err)
+	}
+
+	_, err = r.db.Exec(AUDIT_INSERT_STATEMENT, newID, entry.ActorID, entry.Action, entry.EntityType, entry.EntityID, details)
+	if err != nil {
+		return fmt.Errorf("audit repo error when adding new entry: %v", err)
+	}
+
+	return nil
+}
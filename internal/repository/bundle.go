@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/google/uuid"
+	"strings"
+)
+
+const (
+	BUNDLE_INSERT_STATEMENT        = "insert into bundles(id, title, description, price, currency) values(uuid_to_bin(?), ?, ?, ?, ?)"
+	BUNDLE_COURSE_INSERT_STATEMENT = "insert into bundle_courses(bundle_id, course_id) values(uuid_to_bin(?), uuid_to_bin(?))"
+	BUNDLE_SELECT_STATEMENT        = "select id, created_at, title, description, price, currency, active from bundles"
+	BUNDLE_COURSES_STATEMENT       = "select bundle_id, course_id from bundle_courses where bundle_id in (%v)"
+	BUNDLE_DEACTIVATE_STATEMENT    = "update bundles set active = 0 where id = uuid_to_bin(?)"
+)
+
+type BundleRepository struct {
+	db *sql.DB
+}
+
+func NewBundleRepository(db *sql.DB) *BundleRepository {
+	return &BundleRepository{db: db}
+}
+
+// Create adds the bundle together with its courses.
+func (r *BundleRepository) Create(bundle entity.NewBundle) (uuid.UUID, error) {
+	newID := uuid.New()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("bundle repo error when adding new bundle: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(BUNDLE_INSERT_STATEMENT, newID, bundle.Title, bundle.Description, bundle.Price, bundle.Currency)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("bundle repo error when adding new bundle: %v", err)
+	}
+
+	for _, courseID := range bundle.CourseIDs {
+		_, err = tx.Exec(BUNDLE_COURSE_INSERT_STATEMENT, newID, courseID)
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("bundle repo error when adding course to bundle: %v", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("bundle repo error when adding new bundle: %v", err)
+	}
+
+	return newID, nil
+}
+
+type BundleFilters struct {
+	ID         *uuid.UUID
+	ActiveOnly bool
+}
+
+func (r *BundleRepository) Read(pagination entity.Pagination, filters BundleFilters) ([]entity.Bundle, error) {
+	statement := BUNDLE_SELECT_STATEMENT
+	args := make([]any, 0, 3)
+
+	if filters.ID != nil || filters.ActiveOnly {
+		statement += " where "
+	}
+	if filters.ID != nil {
+		statement += "id = uuid_to_bin(?) and "
+		args = append(args, *filters.ID)
+	}
+	if filters.ActiveOnly {
+		statement += "active = 1 and "
+	}
+	statement = strings.TrimSuffix(statement, " and ")
+
+	if pagination.Limit == 0 {
+		pagination.Limit = 1
+	}
+	statement += " order by created_at desc limit ? offset ?"
+	args = append(args, pagination.Limit, pagination.Offset)
+
+	rows, err := r.db.Query(statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("bundle repo error on reading bundles: %v", err)
+	}
+	defer rows.Close()
+
+	bundles := make([]entity.Bundle, 0)
+	for rows.Next() {
+		bundle := entity.Bundle{CourseIDs: make([]uuid.UUID, 0)}
+
+		err = rows.Scan(&bundle.ID, &bundle.CreatedAt, &bundle.Title, &bundle.Description, &bundle.Price, &bundle.Currency, &bundle.Active)
+		if err != nil {
+			return nil, fmt.Errorf("bundle repo error on scanning a bundle: %v", err)
+		}
+
+		bundles = append(bundles, bundle)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("bundle repo error on rows when reading: %v", err)
+	}
+
+	err = r.readCourses(bundles)
+	if err != nil {
+		return nil, err
+	}
+
+	return bundles, nil
+}
+
+// readCourses fills in the course ids of the bundles.
+func (r *BundleRepository) readCourses(bundles []entity.Bundle) error {
+	if len(bundles) == 0 {
+		return nil
+	}
+
+	index := make(map[uuid.UUID]int, len(bundles))
+	args := make([]any, 0, len(bundles))
+	for i, bundle := range bundles {
+		index[bundle.ID] = i
+		args = append(args, bundle.ID)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("uuid_to_bin(?), ", len(bundles)), ", ")
+
+	rows, err := r.db.Query(fmt.Sprintf(BUNDLE_COURSES_STATEMENT, placeholders), args...)
+	if err != nil {
+		return fmt.Errorf("bundle repo error on reading bundle courses: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bundleID, courseID uuid.UUID
+
+		err = rows.Scan(&bundleID, &courseID)
+		if err != nil {
+			return fmt.Errorf("bundle repo error on scanning a bundle course: %v", err)
+		}
+
+		i := index[bundleID]
+		bundles[i].CourseIDs = append(bundles[i].CourseIDs, courseID)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return fmt.Errorf("bundle repo error on rows when reading bundle courses: %v", err)
+	}
+
+	return nil
+}
+
+func (r *BundleRepository) Deactivate(id uuid.UUID) error {
+	_, err := r.db.Exec(BUNDLE_DEACTIVATE_STATEMENT, id)
+	if err != nil {
+		return fmt.Errorf("bundle repo error when deactivating bundle: %v", err)
+	}
+
+	return nil
+}
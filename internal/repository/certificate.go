@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	certificateInsertStatement = "insert into certificates(id, serial, user_id, course_id, learner_name, course_title, file_url) values(uuid_to_bin(?), ?, uuid_to_bin(?), uuid_to_bin(?), ?, ?, ?)"
+	certificateSelectStatement = "select id, created_at, serial, user_id, course_id, learner_name, course_title, file_url from certificates"
+)
+
+type CertificateRepository struct {
+	db *sql.DB
+}
+
+func NewCertificateRepository(db *sql.DB) *CertificateRepository {
+	return &CertificateRepository{db: db}
+}
+
+type CertificateCreateBody struct {
+	Serial      string
+	UserID      uuid.UUID
+	CourseID    uuid.UUID
+	LearnerName string
+	CourseTitle string
+	FileURL     string
+}
+
+func (r *CertificateRepository) Create(certificate *CertificateCreateBody) error {
+	newID := uuid.New()
+
+	_, err := r.db.Exec(certificateInsertStatement, newID, certificate.Serial, certificate.UserID, certificate.CourseID, certificate.LearnerName, certificate.CourseTitle, certificate.FileURL)
+	if err != nil {
+		return fmt.Errorf("certificate repo error when adding new certificate: %v", err)
+	}
+
+	return nil
+}
+
+type Certificate struct {
+	ID          uuid.UUID `db:"id"`
+	CreatedAt   time.Time `db:"created_at"`
+	Serial      string    `db:"serial"`
+	UserID      uuid.UUID `db:"user_id"`
+	CourseID    uuid.UUID `db:"course_id"`
+	LearnerName string    `db:"learner_name"`
+	CourseTitle string    `db:"course_title"`
+	FileURL     string    `db:"file_url"`
+}
+
+type CertificateFilters struct {
+	Serial   *string
+	UserID   *uuid.UUID
+	CourseID *uuid.UUID
+}
+
+func (r *CertificateRepository) Read(filters *CertificateFilters) ([]Certificate, error) {
+	if filters == nil || (filters.Serial == nil && filters.UserID == nil && filters.CourseID == nil) {
+		return nil, fmt.Errorf("certificate repo error on read: at least one filter should be passed")
+	}
+
+	certificates := make([]Certificate, 0)
+
+	statement := certificateSelectStatement
+	statement += " where "
+	args := make([]any, 0, 3)
+
+	if filters.Serial != nil {
+		statement += "serial = ? and "
+		args = append(args, *filters.Serial)
+	}
+	if filters.UserID != nil {
+		statement += "user_id = uuid_to_bin(?) and "
+		args = append(args, *filters.UserID)
+	}
+	if filters.CourseID != nil {
+		statement += "course_id = uuid_to_bin(?) and "
+		args = append(args, *filters.CourseID)
+	}
+	statement = strings.TrimSuffix(statement, " and ")
+	statement += " order by created_at desc"
+
+	rows, err := r.db.Query(statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("certificate repo error on read: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		certificate := Certificate{}
+
+		err = rows.Scan(&certificate.ID, &certificate.CreatedAt, &certificate.Serial, &certificate.UserID, &certificate.CourseID, &certificate.LearnerName, &certificate.CourseTitle, &certificate.FileURL)
+		if err != nil {
+			return nil, fmt.Errorf("certificate repo error on scanning a certificate: %v", err)
+		}
+
+		certificates = append(certificates, certificate)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("certificate repo error on rows when reading: %v", err)
+	}
+
+	return certificates, nil
+}
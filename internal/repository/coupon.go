@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/google/uuid"
+	"strings"
+)
+
+const (
+	// COUPON_REDEEMING_STATUSES are the statuses of orders that hold a redemption of their coupon,
+	// placed orders reserve one until they fail or are cancelled.
+	COUPON_REDEEMING_STATUSES   = "'created', 'pending', 'succeeded'"
+	COUPON_INSERT_STATEMENT     = "insert into coupons(id, code, discount_type, discount_value, course_id, starts_at, ends_at, max_redemptions, max_per_user) values(uuid_to_bin(?), ?, ?, ?, uuid_to_bin(?), ?, ?, ?, ?)"
+	COUPON_SELECT_STATEMENT     = "select c.id, c.created_at, c.code, c.discount_type, c.discount_value, c.course_id, c.starts_at, c.ends_at, c.max_redemptions, c.max_per_user, c.active, (select count(*) from course_payments p where p.coupon_id = c.id and p.status in (" + COUPON_REDEEMING_STATUSES + ")) from coupons c"
+	COUPON_DEACTIVATE_STATEMENT = "update coupons set active = 0 where id = uuid_to_bin(?)"
+	COUPON_USER_STATEMENT       = "select count(*) from course_payments where coupon_id = uuid_to_bin(?) and user_id = uuid_to_bin(?) and status in (" + COUPON_REDEEMING_STATUSES + ")"
+)
+
+type CouponRepository struct {
+	db *sql.DB
+}
+
+func NewCouponRepository(db *sql.DB) *CouponRepository {
+	return &CouponRepository{db: db}
+}
+
+func (r *CouponRepository) Create(coupon entity.NewCoupon) error {
+	newID := uuid.New()
+
+	_, err := r.db.Exec(COUPON_INSERT_STATEMENT, newID, coupon.Code, coupon.DiscountType, coupon.DiscountValue, nullableUUID(coupon.CourseID), coupon.StartsAt, coupon.EndsAt, coupon.MaxRedemptions, coupon.MaxPerUser)
+	if err != nil {
+		return fmt.Errorf("coupon repo error when adding new coupon: %v", err)
+	}
+
+	return nil
+}
+
+type CouponFilters struct {
+	ID   *uuid.UUID
+	Code *string
+}
+
+func (r *CouponRepository) Read(pagination entity.Pagination, filters CouponFilters) ([]entity.Coupon, error) {
+	statement := COUPON_SELECT_STATEMENT
+	args := make([]any, 0, 4)
+
+	if filters.ID != nil || filters.Code != nil {
+		statement += " where "
+	}
+	if filters.ID != nil {
+		statement += "c.id = uuid_to_bin(?) and "
+		args = append(args, *filters.ID)
+	}
+	if filters.Code != nil {
+		statement += "c.code = ? and "
+		args = append(args, *filters.Code)
+	}
+	statement = strings.TrimSuffix(statement, " and ")
+
+	if pagination.Limit == 0 {
+		pagination.Limit = 1
+	}
+	statement += " order by c.created_at desc limit ? offset ?"
+	args = append(args, pagination.Limit, pagination.Offset)
+
+	rows, err := r.db.Query(statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("coupon repo error on reading coupons: %v", err)
+	}
+	defer rows.Close()
+
+	coupons := make([]entity.Coupon, 0)
+	for rows.Next() {
+		coupon := entity.Coupon{}
+		var courseID uuid.NullUUID
+		var startsAt, endsAt sql.NullTime
+		var maxRedemptions, maxPerUser sql.NullInt64
+
+		err = rows.Scan(&coupon.ID, &coupon.CreatedAt, &coupon.Code, &coupon.DiscountType, &coupon.DiscountValue, &courseID, &startsAt, &endsAt, &maxRedemptions, &maxPerUser, &coupon.Active, &coupon.Redemptions)
+		if err != nil {
+			return nil, fmt.Errorf("coupon repo error on scanning a coupon: %v", err)
+		}
+
+		if courseID.Valid {
+			coupon.CourseID = &courseID.UUID
+		}
+		if startsAt.Valid {
+			coupon.StartsAt = &startsAt.Time
+		}
+		if endsAt.Valid {
+			coupon.EndsAt = &endsAt.Time
+		}
+		if maxRedemptions.Valid {
+			coupon.MaxRedemptions = &maxRedemptions.Int64
+		}
+		if maxPerUser.Valid {
+			coupon.MaxPerUser = &maxPerUser.Int64
+		}
+
+		coupons = append(coupons, coupon)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("coupon repo error on rows when reading: %v", err)
+	}
+
+	return coupons, nil
+}
+
+func (r *CouponRepository) Deactivate(id uuid.UUID) error {
+	_, err := r.db.Exec(COUPON_DEACTIVATE_STATEMENT, id)
+	if err != nil {
+		return fmt.Errorf("coupon repo error when deactivating coupon: %v", err)
+	}
+
+	return nil
+}
+
+// UserRedemptions returns how many orders of the user hold a redemption of the coupon.
+func (r *CouponRepository) UserRedemptions(couponID uuid.UUID, userID uuid.UUID) (int64, error) {
+	var count int64
+
+	err := r.db.QueryRow(COUPON_USER_STATEMENT, couponID, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("coupon repo error on reading redemptions: %v", err)
+	}
+
+	return count, nil
+}
@@ -3,18 +3,26 @@ package repository
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
 	"github.com/google/uuid"
+	"slices"
 	"strings"
 	"time"
 )
 
 const (
-	courseInsertStatement = "insert into courses(id, title, description, price, cover_url, attachment_urls) values(uuid_to_bin(?), ?, ?, ?, ?, ?)"
-	courseSelectStatement = "select id, created_at, updated_at, title, description, price, cover_url, attachment_urls from courses"
+	courseInsertStatement = "insert into courses(id, title, description, price, currency, cover_url, attachment_urls) values(uuid_to_bin(?), ?, ?, ?, ?, ?, ?)"
+	courseSelectStatement = "select id, created_at, updated_at, title, description, price, currency, cover_url, attachment_urls from courses"
 	courseUpdateStatement = "update courses set "
 	courseDeleteStatement = "delete from courses where id = uuid_to_bin(?)"
+
+	courseAttachmentsLockStatement   = "select attachment_urls from courses where id = uuid_to_bin(?) for update"
+	courseAttachmentsUpdateStatement = "update courses set attachment_urls = ? where id = uuid_to_bin(?)"
+	// courseLegacyAttachmentsStatement selects the courses with attachments stored as public URLs.
+	courseLegacyAttachmentsStatement = courseSelectStatement + " where json_search(attachment_urls, 'one', 'http%', null, '$.attachment_urls') is not null"
+	courseAttachmentUsedStatement    = "select exists(select 1 from courses where json_contains(attachment_urls, json_quote(?), '$.attachment_urls'))"
 )
 
 type CourseRepositoryImplementation interface {
@@ -35,24 +43,35 @@ func NewCourseRepo(db *sql.DB) *CourseRepository {
 }
 
 type CourseCreateBody struct {
-	Title          string
-	Description    string
-	Price          int64
-	CoverURL       string
-	AttachmentURLs []string
+	// ID is chosen by the caller, so files can be stored under the prefix of the course before it is created.
+	ID          uuid.UUID
+	Title       string
+	Description string
+	Price       int64
+	Currency    string
+	CoverURL    string
+	// Attachments are the storage keys of the private attachments of the course.
+	Attachments []string
+}
+
+// courseAttachments is how the attachments are stored in the attachment_urls column. Courses created
+// before attachments were private hold public URLs instead of keys until they are migrated.
+type courseAttachments struct {
+	AttachmentURLs []string `json:"attachment_urls"`
 }
 
 func (r *CourseRepository) Create(course CourseCreateBody) (bool, error) {
-	newID := uuid.New()
+	newID := course.ID
+	if newID == uuid.Nil {
+		newID = uuid.New()
+	}
 
-	attachmentsURLs := struct {
-		AttachmentURLs []string `json:"attachment_urls"`
-	}{
-		AttachmentURLs: course.AttachmentURLs,
+	attachmentsURLsJSON, err := json.Marshal(courseAttachments{AttachmentURLs: course.Attachments})
+	if err != nil {
+		return false, fmt.Errorf("course repo error when encoding attachments: %v", err)
 	}
-	attachmentsURLsJSON, err := json.Marshal(attachmentsURLs)
 
-	_, err = r.db.Exec(courseInsertStatement, newID, course.Title, course.Description, course.Price, course.CoverURL, attachmentsURLsJSON)
+	_, err = r.db.Exec(courseInsertStatement, newID, course.Title, course.Description, course.Price, course.Currency, course.CoverURL, attachmentsURLsJSON)
 	if err != nil {
 		return false, fmt.Errorf("course repo error when adding new course: %v", err)
 	}
@@ -67,10 +86,153 @@ type Course struct {
 	Title          string         `db:"title"`
 	Description    string         `db:"description"`
 	Price          int64          `db:"price"`
+	Currency       string         `db:"currency"`
 	CoverURL       string         `db:"cover_url"`
 	AttachmentURLs sql.NullString `db:"attachment_urls"`
 }
 
+// Attachments returns the stored attachments of the course in upload order.
+func (c Course) Attachments() ([]string, error) {
+	if !c.AttachmentURLs.Valid || c.AttachmentURLs.String == "" {
+		return nil, nil
+	}
+
+	attachments := courseAttachments{}
+	err := json.Unmarshal([]byte(c.AttachmentURLs.String), &attachments)
+	if err != nil {
+		return nil, fmt.Errorf("course repo error when decoding attachments: %v", err)
+	}
+
+	return attachments.AttachmentURLs, nil
+}
+
+// AddAttachment appends the storage key to the attachments of the course, it reports false if
+// there is no course with the id.
+func (r *CourseRepository) AddAttachment(id uuid.UUID, key string) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("course repo error when adding attachment: %v", err)
+	}
+	defer tx.Rollback()
+
+	course := Course{ID: id}
+	err = tx.QueryRow(courseAttachmentsLockStatement, id).Scan(&course.AttachmentURLs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("course repo error when adding attachment: %v", err)
+	}
+
+	attachments, err := course.Attachments()
+	if err != nil {
+		return false, err
+	}
+
+	attachmentsJSON, err := json.Marshal(courseAttachments{AttachmentURLs: append(attachments, key)})
+	if err != nil {
+		return false, fmt.Errorf("course repo error when encoding attachments: %v", err)
+	}
+
+	_, err = tx.Exec(courseAttachmentsUpdateStatement, attachmentsJSON, id)
+	if err != nil {
+		return false, fmt.Errorf("course repo error when adding attachment: %v", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, fmt.Errorf("course repo error when adding attachment: %v", err)
+	}
+
+	return true, nil
+}
+
+// ReplaceAttachment swaps an attachment of the course for another one in place, it reports false if
+// the course no longer has the attachment.
+func (r *CourseRepository) ReplaceAttachment(id uuid.UUID, from string, to string) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("course repo error when replacing attachment: %v", err)
+	}
+	defer tx.Rollback()
+
+	course := Course{ID: id}
+	err = tx.QueryRow(courseAttachmentsLockStatement, id).Scan(&course.AttachmentURLs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("course repo error when replacing attachment: %v", err)
+	}
+
+	attachments, err := course.Attachments()
+	if err != nil {
+		return false, err
+	}
+
+	n := slices.Index(attachments, from)
+	if n < 0 {
+		return false, nil
+	}
+	attachments[n] = to
+
+	attachmentsJSON, err := json.Marshal(courseAttachments{AttachmentURLs: attachments})
+	if err != nil {
+		return false, fmt.Errorf("course repo error when encoding attachments: %v", err)
+	}
+
+	_, err = tx.Exec(courseAttachmentsUpdateStatement, attachmentsJSON, id)
+	if err != nil {
+		return false, fmt.Errorf("course repo error when replacing attachment: %v", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, fmt.Errorf("course repo error when replacing attachment: %v", err)
+	}
+
+	return true, nil
+}
+
+// LegacyAttachments returns the courses that still have attachments stored as public URLs.
+func (r *CourseRepository) LegacyAttachments() ([]Course, error) {
+	rows, err := r.db.Query(courseLegacyAttachmentsStatement)
+	if err != nil {
+		return nil, fmt.Errorf("course repo error on reading legacy attachments: %v", err)
+	}
+	defer rows.Close()
+
+	courses := make([]Course, 0)
+	for rows.Next() {
+		course := Course{}
+
+		err = rows.Scan(&course.ID, &course.CreatedAt, &course.UpdatedAt, &course.Title, &course.Description, &course.Price, &course.Currency, &course.CoverURL, &course.AttachmentURLs)
+		if err != nil {
+			return nil, fmt.Errorf("course repo error on scanning a course: %v", err)
+		}
+
+		courses = append(courses, course)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("course repo error on rows when reading legacy attachments: %v", err)
+	}
+
+	return courses, nil
+}
+
+// AttachmentUsed reports whether any course has the attachment.
+func (r *CourseRepository) AttachmentUsed(attachment string) (bool, error) {
+	var used bool
+	err := r.db.QueryRow(courseAttachmentUsedStatement, attachment).Scan(&used)
+	if err != nil {
+		return false, fmt.Errorf("course repo error when checking attachment: %v", err)
+	}
+
+	return used, nil
+}
+
 func (r *CourseRepository) Read(pagination entity.Pagination, filters entity.CourseFilters) ([]Course, error) {
 	courses := make([]Course, 0, pagination.Limit)
 
@@ -103,7 +265,7 @@ func (r *CourseRepository) Read(pagination entity.Pagination, filters entity.Cou
 	for rows.Next() {
 		course := Course{}
 
-		err = rows.Scan(&course.ID, &course.CreatedAt, &course.UpdatedAt, &course.Title, &course.Description, &course.Price, &course.CoverURL, &course.AttachmentURLs)
+		err = rows.Scan(&course.ID, &course.CreatedAt, &course.UpdatedAt, &course.Title, &course.Description, &course.Price, &course.Currency, &course.CoverURL, &course.AttachmentURLs)
 		if err != nil {
 			return nil, fmt.Errorf("course repo error on scanning a course: %v", err)
 		}
@@ -142,6 +304,11 @@ func (r *CourseRepository) Update(body entity.CourseUpdateBody) (bool, error) {
 		args = append(args, body.Price)
 	}
 
+	if body.Currency != nil {
+		statement += "currency = ?, "
+		args = append(args, body.Currency)
+	}
+
 	if len(args) == 0 {
 		return false, fmt.Errorf("course repo error when updating course: update body is empty")
 	}
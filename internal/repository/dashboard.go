@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/google/uuid"
+)
+
+const (
+	// DASHBOARD_PAID_COURSES_STATEMENT selects the ids of the courses the user is enrolled in,
+	// enrollments that came with a subscription only count while the subscription grants access.
+	DASHBOARD_PAID_COURSES_STATEMENT = "select course_id from enrollments where user_id = uuid_to_bin(?) and revoked_at is null and (source <> ? or ?)"
+
+	DASHBOARD_COURSES_STATEMENT = "select c.id, c.title, coalesce(c.cover_url, ''), count(m.id), coalesce(sum(m.duration_minutes), 0), count(done.module_id), coalesce(sum(if(done.module_id is null, 0, m.duration_minutes)), 0), max(recent.last_activity_at) " +
+		"from courses c " +
+		"left join modules m on m.course_id = c.id " +
+		"left join (select distinct module_id from user_activity where user_id = uuid_to_bin(?)) done on done.module_id = m.id " +
+		"left join (select course_id, max(created_at) as last_activity_at from user_activity where user_id = uuid_to_bin(?) group by course_id) recent on recent.course_id = c.id " +
+		"where c.id in (" + DASHBOARD_PAID_COURSES_STATEMENT + ") " +
+		"group by c.id " +
+		"order by max(recent.last_activity_at) is null, max(recent.last_activity_at) desc, c.title"
+	DASHBOARD_NEXT_MODULES_STATEMENT = "select m.course_id, m.id, m.name, m.order_number " +
+		"from modules m " +
+		"where m.course_id in (" + DASHBOARD_PAID_COURSES_STATEMENT + ") " +
+		"and m.id not in (select module_id from user_activity where user_id = uuid_to_bin(?)) " +
+		"order by m.course_id, m.order_number is null, m.order_number"
+)
+
+type DashboardRepository struct {
+	db *sql.DB
+}
+
+func NewDashboardRepository(db *sql.DB) *DashboardRepository {
+	return &DashboardRepository{db: db}
+}
+
+type DashboardCourse struct {
+	CourseID         uuid.UUID    `db:"id"`
+	Title            string       `db:"title"`
+	CoverURL         string       `db:"cover_url"`
+	TotalModules     int64        `db:"total_modules"`
+	TotalMinutes     int64        `db:"total_minutes"`
+	CompletedModules int64        `db:"completed_modules"`
+	CompletedMinutes int64        `db:"completed_minutes"`
+	LastActivityAt   sql.NullTime `db:"last_activity_at"`
+}
+
+// Courses returns the progress of the user in every course they have paid for, most recently studied first.
+// Courses opened with a subscription are only included if subscribed is set.
+func (r *DashboardRepository) Courses(userID uuid.UUID, subscribed bool) ([]DashboardCourse, error) {
+	rows, err := r.db.Query(DASHBOARD_COURSES_STATEMENT, userID, userID, userID, entity.SubscriptionEnrollment, subscribed)
+	if err != nil {
+		return nil, fmt.Errorf("dashboard repo error on reading courses: %v", err)
+	}
+	defer rows.Close()
+
+	courses := make([]DashboardCourse, 0)
+	for rows.Next() {
+		course := DashboardCourse{}
+		err = rows.Scan(&course.CourseID, &course.Title, &course.CoverURL, &course.TotalModules, &course.TotalMinutes, &course.CompletedModules, &course.CompletedMinutes, &course.LastActivityAt)
+		if err != nil {
+			return nil, fmt.Errorf("dashboard repo error on scanning a course: %v", err)
+		}
+		courses = append(courses, course)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("dashboard repo error on rows when reading courses: %v", err)
+	}
+
+	return courses, nil
+}
+
+type DashboardModule struct {
+	CourseID uuid.UUID     `db:"course_id"`
+	ID       uuid.UUID     `db:"id"`
+	Name     string        `db:"name"`
+	Order    sql.NullInt64 `db:"order_number"`
+}
+
+// NextModules returns the first module the user has not completed yet for each course they have paid for,
+// keyed by course id. Completed courses have no entry, subscribed is the same as for Courses.
+func (r *DashboardRepository) NextModules(userID uuid.UUID, subscribed bool) (map[uuid.UUID]DashboardModule, error) {
+	rows, err := r.db.Query(DASHBOARD_NEXT_MODULES_STATEMENT, userID, entity.SubscriptionEnrollment, subscribed, userID)
+	if err != nil {
+		return nil, fmt.Errorf("dashboard repo error on reading next modules: %v", err)
+	}
+	defer rows.Close()
+
+	modules := make(map[uuid.UUID]DashboardModule)
+	for rows.Next() {
+		module := DashboardModule{}
+		err = rows.Scan(&module.CourseID, &module.ID, &module.Name, &module.Order)
+		if err != nil {
+			return nil, fmt.Errorf("dashboard repo error on scanning a module: %v", err)
+		}
+		if _, ok := modules[module.CourseID]; !ok {
+			modules[module.CourseID] = module
+		}
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("dashboard repo error on rows when reading next modules: %v", err)
+	}
+
+	return modules, nil
+}
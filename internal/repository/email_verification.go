@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	emailVerificationInsertStatement = "insert into email_verifications(id, user_id, token_hash, expires_at) values(uuid_to_bin(?), uuid_to_bin(?), ?, ?)"
+	emailVerificationSelectStatement = "select id, user_id, expires_at, used_at from email_verifications where token_hash = ?"
+	emailVerificationUseStatement    = "update email_verifications set used_at = current_timestamp where id = uuid_to_bin(?) and used_at is null"
+	emailVerificationStatsStatement  = "select count(*), max(created_at) from email_verifications where user_id = uuid_to_bin(?) and created_at > ?"
+)
+
+type EmailVerificationRepository struct {
+	db *sql.DB
+}
+
+func NewEmailVerificationRepository(db *sql.DB) *EmailVerificationRepository {
+	return &EmailVerificationRepository{db: db}
+}
+
+type EmailVerificationCreateBody struct {
+	UserID    uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+func (r *EmailVerificationRepository) Create(verification *EmailVerificationCreateBody) error {
+	newID := uuid.New()
+
+	_, err := r.db.Exec(emailVerificationInsertStatement, newID, verification.UserID, verification.TokenHash, verification.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("email verification repo error when adding new verification: %v", err)
+	}
+
+	return nil
+}
+
+type EmailVerification struct {
+	ID        uuid.UUID    `db:"id"`
+	UserID    uuid.UUID    `db:"user_id"`
+	ExpiresAt time.Time    `db:"expires_at"`
+	UsedAt    sql.NullTime `db:"used_at"`
+}
+
+func (r *EmailVerificationRepository) ReadByTokenHash(tokenHash string) (*EmailVerification, error) {
+	verification := EmailVerification{}
+
+	row := r.db.QueryRow(emailVerificationSelectStatement, tokenHash)
+	err := row.Scan(&verification.ID, &verification.UserID, &verification.ExpiresAt, &verification.UsedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("email verification repo error on read: %v", err)
+	}
+
+	return &verification, nil
+}
+
+// Use marks the verification as consumed and reports whether this call was the one that did it.
+func (r *EmailVerificationRepository) Use(id uuid.UUID) (bool, error) {
+	result, err := r.db.Exec(emailVerificationUseStatement, id)
+	if err != nil {
+		return false, fmt.Errorf("email verification repo error when using verification: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("email verification repo error when using verification: %v", err)
+	}
+
+	return affected == 1, nil
+}
+
+type EmailVerificationStats struct {
+	Count      int64
+	LastSentAt sql.NullTime
+}
+
+// Stats returns how many verifications were sent to the user since the given moment and when the last one was sent.
+func (r *EmailVerificationRepository) Stats(userID uuid.UUID, since time.Time) (*EmailVerificationStats, error) {
+	stats := EmailVerificationStats{}
+
+	err := r.db.QueryRow(emailVerificationStatsStatement, userID, since).Scan(&stats.Count, &stats.LastSentAt)
+	if err != nil {
+		return nil, fmt.Errorf("email verification repo error on stats: %v", err)
+	}
+
+	return &stats, nil
+}
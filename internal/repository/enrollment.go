@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/google/uuid"
+	"strings"
+)
+
+const (
+	ENROLLMENT_UPSERT_STATEMENT = "insert into enrollments(id, user_id, course_id, source, order_id, granted_by) values(uuid_to_bin(?), uuid_to_bin(?), uuid_to_bin(?), ?, uuid_to_bin(?), uuid_to_bin(?)) " +
+		"on duplicate key update order_id = if(revoked_at is null, coalesce(order_id, values(order_id)), values(order_id)), revoked_at = null, granted_by = values(granted_by)"
+	ENROLLMENT_SELECT_STATEMENT       = "select id, created_at, user_id, course_id, source, order_id, granted_by, revoked_at from enrollments"
+	ENROLLMENT_ACTIVE_STATEMENT       = "select count(*) from enrollments where user_id = uuid_to_bin(?) and course_id = uuid_to_bin(?) and revoked_at is null and source <> ?"
+	ENROLLMENT_REVOKE_STATEMENT       = "update enrollments set revoked_at = current_timestamp where user_id = uuid_to_bin(?) and course_id = uuid_to_bin(?) and revoked_at is null"
+	ENROLLMENT_REVOKE_ORDER_STATEMENT = "update enrollments set revoked_at = current_timestamp where order_id = uuid_to_bin(?) and revoked_at is null"
+)
+
+type EnrollmentRepository struct {
+	db *sql.DB
+}
+
+func NewEnrollmentRepository(db *sql.DB) *EnrollmentRepository {
+	return &EnrollmentRepository{db: db}
+}
+
+type EnrollmentCreateBody struct {
+	UserID    uuid.UUID
+	CourseID  uuid.UUID
+	Source    entity.EnrollmentSource
+	OrderID   *uuid.UUID
+	GrantedBy *uuid.UUID
+}
+
+// Create enrolls the user in the course, an enrollment from the same source that was revoked is
+// restored. An active enrollment keeps the order it was first bought with, so a refund of that
+// order still revokes it; a restored one takes the new order. It reports whether anything changed.
+func (r *EnrollmentRepository) Create(enrollment *EnrollmentCreateBody) (bool, error) {
+	result, err := r.db.Exec(ENROLLMENT_UPSERT_STATEMENT, uuid.New(), enrollment.UserID, enrollment.CourseID, enrollment.Source, nullableUUID(enrollment.OrderID), nullableUUID(enrollment.GrantedBy))
+	if err != nil {
+		return false, fmt.Errorf("enrollment repo error when adding enrollment: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("enrollment repo error when adding enrollment: %v", err)
+	}
+
+	return affected > 0, nil
+}
+
+// Active reports whether the user has an enrollment in the course that is not revoked and does
+// not come from the excluded source.
+func (r *EnrollmentRepository) Active(userID uuid.UUID, courseID uuid.UUID, excluded entity.EnrollmentSource) (bool, error) {
+	var count int64
+
+	err := r.db.QueryRow(ENROLLMENT_ACTIVE_STATEMENT, userID, courseID, excluded).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("enrollment repo error on reading enrollment: %v", err)
+	}
+
+	return count > 0, nil
+}
+
+// Revoke revokes every enrollment of the user in the course and reports whether there was any.
+func (r *EnrollmentRepository) Revoke(userID uuid.UUID, courseID uuid.UUID) (bool, error) {
+	result, err := r.db.Exec(ENROLLMENT_REVOKE_STATEMENT, userID, courseID)
+	if err != nil {
+		return false, fmt.Errorf("enrollment repo error when revoking enrollment: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("enrollment repo error when revoking enrollment: %v", err)
+	}
+
+	return affected > 0, nil
+}
+
+// RevokeOrder revokes the enrollments the order gave.
+func (r *EnrollmentRepository) RevokeOrder(orderID uuid.UUID) error {
+	_, err := r.db.Exec(ENROLLMENT_REVOKE_ORDER_STATEMENT, orderID)
+	if err != nil {
+		return fmt.Errorf("enrollment repo error when revoking order enrollments: %v", err)
+	}
+
+	return nil
+}
+
+type EnrollmentFilters struct {
+	UserID     *uuid.UUID
+	CourseID   *uuid.UUID
+	ActiveOnly bool
+}
+
+func (r *EnrollmentRepository) Read(pagination entity.Pagination, filters EnrollmentFilters) ([]entity.Enrollment, error) {
+	statement := ENROLLMENT_SELECT_STATEMENT
+	args := make([]any, 0, 4)
+
+	if filters.UserID != nil || filters.CourseID != nil || filters.ActiveOnly {
+		statement += " where "
+	}
+	if filters.UserID != nil {
+		statement += "user_id = uuid_to_bin(?) and "
+		args = append(args, *filters.UserID)
+	}
+	if filters.CourseID != nil {
+		statement += "course_id = uuid_to_bin(?) and "
+		args = append(args, *filters.CourseID)
+	}
+	if filters.ActiveOnly {
+		statement += "revoked_at is null and "
+	}
+	statement = strings.TrimSuffix(statement, " and ")
+
+	if pagination.Limit == 0 {
+		pagination.Limit = 1
+	}
+	statement += " order by created_at desc limit ? offset ?"
+	args = append(args, pagination.Limit, pagination.Offset)
+
+	rows, err := r.db.Query(statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("enrollment repo error on reading enrollments: %v", err)
+	}
+	defer rows.Close()
+
+	enrollments := make([]entity.Enrollment, 0)
+	for rows.Next() {
+		enrollment := entity.Enrollment{}
+		var orderID, grantedBy uuid.NullUUID
+		var revokedAt sql.NullTime
+
+		err = rows.Scan(&enrollment.ID, &enrollment.CreatedAt, &enrollment.UserID, &enrollment.CourseID, &enrollment.Source, &orderID, &grantedBy, &revokedAt)
+		if err != nil {
+			return nil, fmt.Errorf("enrollment repo error on scanning an enrollment: %v", err)
+		}
+
+		if orderID.Valid {
+			enrollment.OrderID = &orderID.UUID
+		}
+		if grantedBy.Valid {
+			enrollment.GrantedBy = &grantedBy.UUID
+		}
+		if revokedAt.Valid {
+			enrollment.RevokedAt = &revokedAt.Time
+		}
+
+		enrollments = append(enrollments, enrollment)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("enrollment repo error on rows when reading: %v", err)
+	}
+
+	return enrollments, nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	FILE_INSERT_STATEMENT  = "insert into files(id, storage_key, owner_type, owner_id, purpose) values(uuid_to_bin(?), ?, ?, uuid_to_bin(?), ?)"
+	FILE_RELEASE_STATEMENT = "update files set released_at = current_timestamp where storage_key = ? and released_at is null"
+	// FILE_GARBAGE_STATEMENT selects released files and files of courses that do not exist, either
+	// deleted or never created because the request failed after the upload. Both only after the
+	// grace period, so a course still being created keeps its files.
+	FILE_GARBAGE_STATEMENT = "select id, storage_key from files where released_at < ? " +
+		"or (owner_type = 'course' and created_at < ? and not exists (select 1 from courses where courses.id = files.owner_id)) limit ?"
+	FILE_DELETE_STATEMENT = "delete from files where id = uuid_to_bin(?)"
+)
+
+// File is a tracked object that is no longer needed.
+type File struct {
+	ID  uuid.UUID
+	Key string
+}
+
+type FileCreateBody struct {
+	Key       string
+	OwnerType string
+	OwnerID   uuid.UUID
+	Purpose   string
+}
+
+type FileRepository struct {
+	db *sql.DB
+}
+
+func NewFileRepository(db *sql.DB) *FileRepository {
+	return &FileRepository{db: db}
+}
+
+func (r *FileRepository) Create(file *FileCreateBody) error {
+	_, err := r.db.Exec(FILE_INSERT_STATEMENT, uuid.New(), file.Key, file.OwnerType, file.OwnerID, file.Purpose)
+	if err != nil {
+		return fmt.Errorf("file repo error when adding new file: %v", err)
+	}
+
+	return nil
+}
+
+// Release marks the file as no longer used, it is deleted by the garbage collection.
+func (r *FileRepository) Release(key string) error {
+	_, err := r.db.Exec(FILE_RELEASE_STATEMENT, key)
+	if err != nil {
+		return fmt.Errorf("file repo error when releasing file: %v", err)
+	}
+
+	return nil
+}
+
+// Garbage returns files that can be deleted, see FILE_GARBAGE_STATEMENT.
+func (r *FileRepository) Garbage(before time.Time, limit int) ([]File, error) {
+	rows, err := r.db.Query(FILE_GARBAGE_STATEMENT, before, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("file repo error on reading garbage: %v", err)
+	}
+	defer rows.Close()
+
+	files := make([]File, 0)
+	for rows.Next() {
+		file := File{}
+
+		err = rows.Scan(&file.ID, &file.Key)
+		if err != nil {
+			return nil, fmt.Errorf("file repo error on scanning a file: %v", err)
+		}
+
+		files = append(files, file)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("file repo error on rows when reading garbage: %v", err)
+	}
+
+	return files, nil
+}
+
+func (r *FileRepository) Delete(id uuid.UUID) error {
+	_, err := r.db.Exec(FILE_DELETE_STATEMENT, id)
+	if err != nil {
+		return fmt.Errorf("file repo error when deleting file: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+const (
+	LOCK_GET_STATEMENT     = "select coalesce(get_lock(?, 0), 0)"
+	LOCK_HELD_STATEMENT    = "select coalesce(is_used_lock(?) = connection_id(), 0)"
+	LOCK_RELEASE_STATEMENT = "do release_lock(?)"
+)
+
+// LeaderLock is a MySQL named lock that elects one replica to run a background job. Named locks
+// belong to a connection, so the lock keeps one connection out of the pool while it is held and is
+// released by MySQL if that connection dies.
+type LeaderLock struct {
+	db   *sql.DB
+	name string
+	conn *sql.Conn
+}
+
+func NewLeaderLock(db *sql.DB, name string) *LeaderLock {
+	return &LeaderLock{db: db, name: name}
+}
+
+// Acquire reports whether this process holds the lock, taking it when it is free. It is meant to
+// be called before every run of the job, a lock lost with its connection is taken again once free.
+func (l *LeaderLock) Acquire(ctx context.Context) (bool, error) {
+	if l.conn != nil {
+		var held bool
+		err := l.conn.QueryRowContext(ctx, LOCK_HELD_STATEMENT, l.name).Scan(&held)
+		if err == nil && held {
+			return true, nil
+		}
+		l.conn.Close()
+		l.conn = nil
+	}
+
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("leader lock error on connecting: %v", err)
+	}
+
+	var acquired bool
+	err = conn.QueryRowContext(ctx, LOCK_GET_STATEMENT, l.name).Scan(&acquired)
+	if err != nil || !acquired {
+		conn.Close()
+		if err != nil {
+			return false, fmt.Errorf("leader lock error on acquiring %v: %v", l.name, err)
+		}
+		return false, nil
+	}
+	l.conn = conn
+
+	return true, nil
+}
+
+// Release gives the lock up so another replica can take it.
+func (l *LeaderLock) Release() {
+	if l.conn == nil {
+		return
+	}
+
+	l.conn.ExecContext(context.Background(), LOCK_RELEASE_STATEMENT, l.name)
+	l.conn.Close()
+	l.conn = nil
+}
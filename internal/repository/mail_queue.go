@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	mailQueueInsertStatement = "insert into mail_queue(id, recipient, subject, text_body, html_body) values(uuid_to_bin(?), ?, ?, ?, ?)"
+	mailQueueDueStatement    = "select id, recipient, subject, text_body, html_body, attempts from mail_queue where sent_at is null and attempts < ? and next_attempt_at <= current_timestamp and (locked_until is null or locked_until < current_timestamp) order by next_attempt_at asc limit ?"
+	mailQueueClaimStatement  = "update mail_queue set locked_until = ? where id = uuid_to_bin(?) and sent_at is null and (locked_until is null or locked_until < current_timestamp)"
+	mailQueueSentStatement   = "update mail_queue set sent_at = current_timestamp, attempts = attempts + 1, locked_until = null, last_error = null where id = uuid_to_bin(?)"
+	mailQueueFailedStatement = "update mail_queue set attempts = attempts + 1, next_attempt_at = ?, locked_until = null, last_error = ? where id = uuid_to_bin(?)"
+)
+
+type MailQueueRepository struct {
+	db *sql.DB
+}
+
+func NewMailQueueRepository(db *sql.DB) *MailQueueRepository {
+	return &MailQueueRepository{db: db}
+}
+
+type MailCreateBody struct {
+	Recipient string
+	Subject   string
+	TextBody  string
+	HTMLBody  string
+}
+
+func (r *MailQueueRepository) Create(mail *MailCreateBody) error {
+	newID := uuid.New()
+
+	_, err := r.db.Exec(mailQueueInsertStatement, newID, mail.Recipient, mail.Subject, mail.TextBody, mail.HTMLBody)
+	if err != nil {
+		return fmt.Errorf("mail queue repo error when adding new mail: %v", err)
+	}
+
+	return nil
+}
+
+type Mail struct {
+	ID        uuid.UUID      `db:"id"`
+	Recipient string         `db:"recipient"`
+	Subject   string         `db:"subject"`
+	TextBody  string         `db:"text_body"`
+	HTMLBody  sql.NullString `db:"html_body"`
+	Attempts  int64          `db:"attempts"`
+}
+
+// ReadDue returns unsent mails whose next attempt is due and that are not locked by another worker.
+func (r *MailQueueRepository) ReadDue(maxAttempts int64, limit int64) ([]Mail, error) {
+	mails := make([]Mail, 0, limit)
+
+	rows, err := r.db.Query(mailQueueDueStatement, maxAttempts, limit)
+	if err != nil {
+		return nil, fmt.Errorf("mail queue repo error on reading due mails: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		mail := Mail{}
+
+		err = rows.Scan(&mail.ID, &mail.Recipient, &mail.Subject, &mail.TextBody, &mail.HTMLBody, &mail.Attempts)
+		if err != nil {
+			return nil, fmt.Errorf("mail queue repo error on scanning a mail: %v", err)
+		}
+
+		mails = append(mails, mail)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("mail queue repo error on rows when reading: %v", err)
+	}
+
+	return mails, nil
+}
+
+// Claim locks the mail until the given moment and reports whether the lock was acquired.
+func (r *MailQueueRepository) Claim(id uuid.UUID, until time.Time) (bool, error) {
+	result, err := r.db.Exec(mailQueueClaimStatement, until, id)
+	if err != nil {
+		return false, fmt.Errorf("mail queue repo error when claiming mail: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("mail queue repo error when claiming mail: %v", err)
+	}
+
+	return affected == 1, nil
+}
+
+func (r *MailQueueRepository) MarkSent(id uuid.UUID) error {
+	_, err := r.db.Exec(mailQueueSentStatement, id)
+	if err != nil {
+		return fmt.Errorf("mail queue repo error when marking mail sent: %v", err)
+	}
+
+	return nil
+}
+
+func (r *MailQueueRepository) MarkFailed(id uuid.UUID, nextAttemptAt time.Time, reason string) error {
+	if len(reason) > 1024 {
+		reason = reason[:1024]
+	}
+
+	_, err := r.db.Exec(mailQueueFailedStatement, nextAttemptAt, reason, id)
+	if err != nil {
+		return fmt.Errorf("mail queue repo error when marking mail failed: %v", err)
+	}
+
+	return nil
+}
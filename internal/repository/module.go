@@ -9,8 +9,8 @@ import (
 )
 
 const (
-	insertStatement = "insert into modules(id, course_id, name, content, order_number, duration_minutes) values(uuid_to_bin(?), uuid_to_bin(?), ?, ?, ?, ?)"
-	selectStatement = "select id, course_id, created_at, updated_at, name, content, order_number, duration_minutes from modules"
+	insertStatement = "insert into modules(id, course_id, name, content, order_number, duration_minutes, free_preview) values(uuid_to_bin(?), uuid_to_bin(?), ?, ?, ?, ?, ?)"
+	selectStatement = "select id, course_id, created_at, updated_at, name, content, order_number, duration_minutes, free_preview from modules"
 	updateStatement = "update modules set "
 	deleteStatement = "delete from modules where id = uuid_to_bin(?)"
 )
@@ -35,7 +35,7 @@ func NewModuleRepo(db *sql.DB) *ModuleRepository {
 func (r *ModuleRepository) Create(module entity.NewModule) (bool, error) {
 	newID := uuid.New()
 
-	_, err := r.db.Exec(insertStatement, newID, module.CourseID, module.Name, module.Content, module.Order, module.DurationMinutes)
+	_, err := r.db.Exec(insertStatement, newID, module.CourseID, module.Name, module.Content, module.Order, module.DurationMinutes, module.FreePreview)
 	if err != nil {
 		return false, fmt.Errorf("module repo error when adding new module: %v", err)
 	}
@@ -96,7 +96,7 @@ func (r *ModuleRepository) Read(filters entity.ModuleFilters, pagination entity.
 	for rows.Next() {
 		module := entity.Module{}
 
-		err = rows.Scan(&module.ID, &module.CourseID, &module.CreatedAt, &module.UpdatedAt, &module.Name, &module.Content, &module.Order, &module.DurationMinutes)
+		err = rows.Scan(&module.ID, &module.CourseID, &module.CreatedAt, &module.UpdatedAt, &module.Name, &module.Content, &module.Order, &module.DurationMinutes, &module.FreePreview)
 		if err != nil {
 			return nil, fmt.Errorf("module repo error on scanning a module: %v", err)
 		}
@@ -140,6 +140,11 @@ func (r *ModuleRepository) Update(body entity.ModuleUpdateBody) (bool, error) {
 		args = append(args, body.DurationMinutes)
 	}
 
+	if body.FreePreview != nil {
+		statement += "free_preview = ?, "
+		args = append(args, body.FreePreview)
+	}
+
 	if len(args) == 0 {
 		return false, fmt.Errorf("module repo error: update body is empty")
 	}
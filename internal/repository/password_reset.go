@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	passwordResetInsertStatement = "insert into password_resets(id, user_id, token_hash, expires_at) values(uuid_to_bin(?), uuid_to_bin(?), ?, ?)"
+	passwordResetSelectStatement = "select id, user_id, expires_at, used_at from password_resets where token_hash = ?"
+	passwordResetUseStatement    = "update password_resets set used_at = current_timestamp where id = uuid_to_bin(?) and used_at is null"
+	passwordResetUseAllStatement = "update password_resets set used_at = current_timestamp where user_id = uuid_to_bin(?) and used_at is null"
+)
+
+type PasswordResetRepository struct {
+	db *sql.DB
+}
+
+func NewPasswordResetRepository(db *sql.DB) *PasswordResetRepository {
+	return &PasswordResetRepository{db: db}
+}
+
+type PasswordResetCreateBody struct {
+	UserID    uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+func (r *PasswordResetRepository) Create(reset *PasswordResetCreateBody) error {
+	newID := uuid.New()
+
+	_, err := r.db.Exec(passwordResetInsertStatement, newID, reset.UserID, reset.TokenHash, reset.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("password reset repo error when adding new reset: %v", err)
+	}
+
+	return nil
+}
+
+type PasswordReset struct {
+	ID        uuid.UUID    `db:"id"`
+	UserID    uuid.UUID    `db:"user_id"`
+	ExpiresAt time.Time    `db:"expires_at"`
+	UsedAt    sql.NullTime `db:"used_at"`
+}
+
+func (r *PasswordResetRepository) ReadByTokenHash(tokenHash string) (*PasswordReset, error) {
+	reset := PasswordReset{}
+
+	row := r.db.QueryRow(passwordResetSelectStatement, tokenHash)
+	err := row.Scan(&reset.ID, &reset.UserID, &reset.ExpiresAt, &reset.UsedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("password reset repo error on read: %v", err)
+	}
+
+	return &reset, nil
+}
+
+// Use marks the reset as consumed and reports whether this call was the one that did it.
+func (r *PasswordResetRepository) Use(id uuid.UUID) (bool, error) {
+	result, err := r.db.Exec(passwordResetUseStatement, id)
+	if err != nil {
+		return false, fmt.Errorf("password reset repo error when using reset: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("password reset repo error when using reset: %v", err)
+	}
+
+	return affected == 1, nil
+}
+
+func (r *PasswordResetRepository) UseAll(userID uuid.UUID) error {
+	_, err := r.db.Exec(passwordResetUseAllStatement, userID)
+	if err != nil {
+		return fmt.Errorf("password reset repo error when invalidating resets: %v", err)
+	}
+
+	return nil
+}
@@ -4,14 +4,31 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
 	"github.com/google/uuid"
-	"log"
+	"strings"
+	"time"
 )
 
 const (
-	PAYMENT_INSERT_STATEMENT  = "insert into course_payments(id, user_id, course_id, order_id) values(uuid_to_bin(?), uuid_to_bin(?), uuid_to_bin(?), uuid_to_bin(?))"
-	PAYMENT_SELECT_STATEMENT  = "select id, user_id, course_id from course_payments"
-	CONFIRM_PAYMENT_STATEMENT = "update course_payments set confirmed=1 where order_id=uuid_to_bin(?)"
+	PAYMENT_INSERT_STATEMENT         = "insert into course_payments(id, user_id, course_id, bundle_id, subscription_id, order_id, status, amount, currency, provider, coupon_id, discount_amount, gift, gift_recipient_email) values(uuid_to_bin(?), uuid_to_bin(?), uuid_to_bin(?), uuid_to_bin(?), uuid_to_bin(?), uuid_to_bin(?), ?, ?, ?, ?, uuid_to_bin(?), ?, ?, nullif(?, ''))"
+	PAYMENT_ITEM_INSERT_STATEMENT    = "insert into payment_items(id, order_id, course_id, price, amount) values(uuid_to_bin(?), uuid_to_bin(?), uuid_to_bin(?), ?, ?)"
+	PAYMENT_ITEM_SELECT_STATEMENT    = "select course_id, price, amount from payment_items where order_id = uuid_to_bin(?)"
+	PAYMENT_SELECT_STATEMENT         = "select id, user_id, course_id, bundle_id, subscription_id, order_id, status, amount, discount_amount, refunded_amount, currency, provider, provider_payment_id, checkout_url, gift, gift_recipient_email, created_at, updated_at from course_payments"
+	PAYMENT_CHECKOUT_STATEMENT       = "update course_payments set checkout_url = ?, provider_payment_id = nullif(?, ''), status = ? where order_id = uuid_to_bin(?) and status = ?"
+	PAYMENT_REFUND_STATEMENT         = "update course_payments set refunded_amount = refunded_amount + ? where order_id = uuid_to_bin(?) and status = ? and refunded_amount + ? <= amount"
+	PAYMENT_REFUND_RELEASE_STATEMENT = "update course_payments set refunded_amount = refunded_amount - ? where order_id = uuid_to_bin(?) and refunded_amount >= ?"
+	PAYMENT_PROVIDER_ID_STATEMENT    = "update course_payments set provider_payment_id = ? where order_id = uuid_to_bin(?) and provider_payment_id is null"
+	PAYMENT_TRANSITION_STATEMENT     = "update course_payments set status = ? where order_id = uuid_to_bin(?) and status in (%v)"
+	PAYMENT_EVENT_INSERT_STATEMENT   = "insert into payment_events(id, order_id, from_status, to_status, applied, payload, payload_hash) values(uuid_to_bin(?), uuid_to_bin(?), ?, ?, ?, ?, ?)"
+	// PAYMENT_STALE_STATEMENT leaves out orders flagged for review, they wait for an admin instead of
+	// taking up the batch.
+	PAYMENT_STALE_STATEMENT         = PAYMENT_SELECT_STATEMENT + " where status in (?, ?) and created_at < ? and provider = ? and order_id not in (select order_id from payment_reviews) order by created_at limit ?"
+	PAYMENT_EVENT_APPLIED_STATEMENT = "select count(*) from payment_events where payload_hash = ? and applied = 1"
+	PAYMENT_PAID_AT_STATEMENT       = "select created_at from payment_events where order_id = uuid_to_bin(?) and to_status = ? and applied = 1 order by created_at limit 1"
+	PAYMENT_COUPON_LOCK_STATEMENT   = "select max_redemptions, max_per_user from coupons where id = uuid_to_bin(?) for update"
+	// PAYMENT_COUPON_USES_STATEMENT counts the orders holding a redemption of the coupon, in total and of the user.
+	PAYMENT_COUPON_USES_STATEMENT = "select count(*), coalesce(sum(user_id = uuid_to_bin(?)), 0) from course_payments where coupon_id = uuid_to_bin(?) and status in (" + COUPON_REDEEMING_STATUSES + ")"
 )
 
 type PaymentRepository struct {
@@ -23,73 +40,396 @@ func NewPaymentRepository(db *sql.DB) *PaymentRepository {
 }
 
 type PaymentCreateBody struct {
-	UserID   uuid.UUID `db:"user_id"`
+	UserID uuid.UUID `db:"user_id"`
+	// CourseID is set for orders of a single course, BundleID for orders of a bundle,
+	// SubscriptionID for subscription orders and none for orders of several courses; Items always
+	// lists every course the order gives access to.
+	CourseID       *uuid.UUID `db:"course_id"`
+	BundleID       *uuid.UUID `db:"bundle_id"`
+	SubscriptionID *uuid.UUID `db:"subscription_id"`
+	OrderID        uuid.UUID  `db:"order_id"`
+	Amount         int64      `db:"amount"`
+	Currency       string     `db:"currency"`
+	Provider       string     `db:"provider"`
+	CouponID       *uuid.UUID `db:"coupon_id"`
+	Discount       int64      `db:"discount_amount"`
+	// Gift orders give an access code instead of enrolling the buyer.
+	Gift               bool          `db:"gift"`
+	GiftRecipientEmail string        `db:"gift_recipient_email"`
+	Items              []PaymentItem `db:"-"`
+}
+
+// PaymentItem is a course bought with an order, Price is its list price and Amount the part of
+// the order amount that falls on it.
+type PaymentItem struct {
 	CourseID uuid.UUID `db:"course_id"`
-	OrderID  uuid.UUID `db:"order_id"`
+	Price    int64     `db:"price"`
+	Amount   int64     `db:"amount"`
 }
 
-func (r *PaymentRepository) Create(payment *PaymentCreateBody) error {
+// Create adds the payment of a new order together with its items and reports whether it did. An
+// order with a coupon reserves a redemption of it: the coupon row is locked while its uses are
+// counted, and the order is not added if the coupon has reached its total or per user limit.
+func (r *PaymentRepository) Create(payment *PaymentCreateBody) (bool, error) {
 	newID := uuid.New()
 
-	_, err := r.db.Exec(PAYMENT_INSERT_STATEMENT, newID, payment.UserID, payment.CourseID, payment.OrderID)
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("payment repo error when adding new payment: %v", err)
+	}
+	defer tx.Rollback()
+
+	if payment.CouponID != nil {
+		var maxRedemptions, maxPerUser sql.NullInt64
+		err = tx.QueryRow(PAYMENT_COUPON_LOCK_STATEMENT, *payment.CouponID).Scan(&maxRedemptions, &maxPerUser)
+		if err != nil {
+			return false, fmt.Errorf("payment repo error when locking coupon: %v", err)
+		}
+
+		var uses, userUses int64
+		err = tx.QueryRow(PAYMENT_COUPON_USES_STATEMENT, payment.UserID, *payment.CouponID).Scan(&uses, &userUses)
+		if err != nil {
+			return false, fmt.Errorf("payment repo error when counting coupon uses: %v", err)
+		}
+		if (maxRedemptions.Valid && uses >= maxRedemptions.Int64) || (maxPerUser.Valid && userUses >= maxPerUser.Int64) {
+			return false, nil
+		}
+	}
+
+	_, err = tx.Exec(PAYMENT_INSERT_STATEMENT, newID, payment.UserID, nullableUUID(payment.CourseID), nullableUUID(payment.BundleID), nullableUUID(payment.SubscriptionID), payment.OrderID, entity.PaymentCreated, payment.Amount, payment.Currency, payment.Provider, nullableUUID(payment.CouponID), payment.Discount, payment.Gift, payment.GiftRecipientEmail)
+	if err != nil {
+		return false, fmt.Errorf("payment repo error when adding new payment: %v", err)
+	}
+
+	for _, item := range payment.Items {
+		_, err = tx.Exec(PAYMENT_ITEM_INSERT_STATEMENT, uuid.New(), payment.OrderID, item.CourseID, item.Price, item.Amount)
+		if err != nil {
+			return false, fmt.Errorf("payment repo error when adding payment item: %v", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, fmt.Errorf("payment repo error when adding new payment: %v", err)
+	}
+
+	return true, nil
+}
+
+// Items returns the courses bought with the order.
+func (r *PaymentRepository) Items(orderID uuid.UUID) ([]PaymentItem, error) {
+	rows, err := r.db.Query(PAYMENT_ITEM_SELECT_STATEMENT, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("payment repo error on reading items: %v", err)
+	}
+	defer rows.Close()
+
+	items := make([]PaymentItem, 0)
+	for rows.Next() {
+		item := PaymentItem{}
+
+		err = rows.Scan(&item.CourseID, &item.Price, &item.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("payment repo error on scanning an item: %v", err)
+		}
+
+		items = append(items, item)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("payment repo error on rows when reading items: %v", err)
+	}
+
+	return items, nil
+}
+
+// nullableUUID turns a missing id into nil, so uuid_to_bin stores NULL.
+func nullableUUID(id *uuid.UUID) any {
+	if id == nil {
+		return nil
+	}
+
+	return *id
+}
+
+// SetCheckout stores the checkout of a just created order and marks it as pending.
+func (r *PaymentRepository) SetCheckout(orderID uuid.UUID, checkoutURL string, providerPaymentID string) error {
+	_, err := r.db.Exec(PAYMENT_CHECKOUT_STATEMENT, checkoutURL, providerPaymentID, entity.PaymentPending, orderID, entity.PaymentCreated)
 	if err != nil {
-		return fmt.Errorf("payment repo error when adding new course: %v", err)
+		return fmt.Errorf("payment repo error when setting checkout: %v", err)
 	}
 
 	return nil
 }
 
-func (r *PaymentRepository) Confirm(orderID uuid.UUID) error {
-	_, err := r.db.Exec(CONFIRM_PAYMENT_STATEMENT, orderID)
+// AddRefund adds the amount to what was refunded of a succeeded payment and reports whether it
+// did, it never lets the refunded amount exceed the paid amount.
+func (r *PaymentRepository) AddRefund(orderID uuid.UUID, amount int64) (bool, error) {
+	result, err := r.db.Exec(PAYMENT_REFUND_STATEMENT, amount, orderID, entity.PaymentSucceeded, amount)
 	if err != nil {
-		return fmt.Errorf("payment repo error when confirming: %v", err)
+		return false, fmt.Errorf("payment repo error when adding refund: %v", err)
 	}
 
-	log.Printf("payment for order %v was confirmed successfully", orderID)
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("payment repo error when adding refund: %v", err)
+	}
+
+	return affected == 1, nil
+}
+
+// ReleaseRefund takes back an amount AddRefund added, when the provider did not refund it.
+func (r *PaymentRepository) ReleaseRefund(orderID uuid.UUID, amount int64) error {
+	_, err := r.db.Exec(PAYMENT_REFUND_RELEASE_STATEMENT, amount, orderID, amount)
+	if err != nil {
+		return fmt.Errorf("payment repo error when releasing refund: %v", err)
+	}
+
+	return nil
+}
+
+// SetProviderPaymentID stores the id the provider gave to the payment, unless it is already known.
+func (r *PaymentRepository) SetProviderPaymentID(orderID uuid.UUID, providerPaymentID string) error {
+	_, err := r.db.Exec(PAYMENT_PROVIDER_ID_STATEMENT, providerPaymentID, orderID)
+	if err != nil {
+		return fmt.Errorf("payment repo error when setting provider payment id: %v", err)
+	}
+
+	return nil
+}
+
+// Transition moves the payment of the order to the next status if it is currently in one of the
+// statuses that can lead to it, and reports whether it did. The check and the update are a single
+// statement so concurrent callbacks cannot both apply.
+func (r *PaymentRepository) Transition(orderID uuid.UUID, next entity.PaymentStatus) (bool, error) {
+	previous := entity.PreviousPaymentStatuses(next)
+	if len(previous) == 0 {
+		return false, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(previous)), ", ")
+	args := make([]any, 0, len(previous)+2)
+	args = append(args, next, orderID)
+	for _, status := range previous {
+		args = append(args, status)
+	}
+
+	result, err := r.db.Exec(fmt.Sprintf(PAYMENT_TRANSITION_STATEMENT, placeholders), args...)
+	if err != nil {
+		return false, fmt.Errorf("payment repo error when changing status: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("payment repo error when changing status: %v", err)
+	}
+
+	return affected == 1, nil
+}
+
+type PaymentEventCreateBody struct {
+	OrderID     uuid.UUID
+	FromStatus  *entity.PaymentStatus
+	ToStatus    *entity.PaymentStatus
+	Applied     bool
+	Payload     string
+	PayloadHash string
+}
+
+func (r *PaymentRepository) CreateEvent(event *PaymentEventCreateBody) error {
+	newID := uuid.New()
+
+	_, err := r.db.Exec(PAYMENT_EVENT_INSERT_STATEMENT, newID, event.OrderID, event.FromStatus, event.ToStatus, event.Applied, event.Payload, event.PayloadHash)
+	if err != nil {
+		return fmt.Errorf("payment repo error when adding event: %v", err)
+	}
 
 	return nil
 }
 
+// EventApplied reports whether a callback with the payload hash has already changed a payment status.
+func (r *PaymentRepository) EventApplied(payloadHash string) (bool, error) {
+	var count int64
+
+	err := r.db.QueryRow(PAYMENT_EVENT_APPLIED_STATEMENT, payloadHash).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("payment repo error on reading events: %v", err)
+	}
+
+	return count > 0, nil
+}
+
+// PaidAt returns when the payment of the order succeeded, the zero time if no succeeded event was
+// recorded for it.
+func (r *PaymentRepository) PaidAt(orderID uuid.UUID) (time.Time, error) {
+	var paidAt time.Time
+
+	err := r.db.QueryRow(PAYMENT_PAID_AT_STATEMENT, orderID, entity.PaymentSucceeded).Scan(&paidAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("payment repo error on reading paid at: %v", err)
+	}
+
+	return paidAt, nil
+}
+
 type Payment struct {
-	ID       uuid.UUID `db:"id"`
-	UserID   uuid.UUID `db:"user_id"`
-	CourseID uuid.UUID `db:"course_id"`
+	ID                 uuid.UUID            `db:"id"`
+	UserID             uuid.UUID            `db:"user_id"`
+	CourseID           uuid.NullUUID        `db:"course_id"`
+	BundleID           uuid.NullUUID        `db:"bundle_id"`
+	SubscriptionID     uuid.NullUUID        `db:"subscription_id"`
+	OrderID            uuid.NullUUID        `db:"order_id"`
+	Status             entity.PaymentStatus `db:"status"`
+	Amount             int64                `db:"amount"`
+	Discount           int64                `db:"discount_amount"`
+	RefundedAmount     int64                `db:"refunded_amount"`
+	Currency           string               `db:"currency"`
+	Provider           sql.NullString       `db:"provider"`
+	ProviderPaymentID  sql.NullString       `db:"provider_payment_id"`
+	CheckoutURL        sql.NullString       `db:"checkout_url"`
+	Gift               bool                 `db:"gift"`
+	GiftRecipientEmail sql.NullString       `db:"gift_recipient_email"`
+	CreatedAt          time.Time            `db:"created_at"`
+	UpdatedAt          time.Time            `db:"updated_at"`
 }
 
 type PaymentFilters struct {
 	UserID   *uuid.UUID
 	CourseID *uuid.UUID
+	OrderID  *uuid.UUID
+	Status   *entity.PaymentStatus
+	// Statuses matches any of the statuses.
+	Statuses []entity.PaymentStatus
 }
 
+// Read returns the first payment matching the filters, or nil if there is none. The course filter
+// matches orders of a single course as well as bundle and cart orders that include the course.
 func (r *PaymentRepository) Read(filters *PaymentFilters) (*Payment, error) {
-	if filters == nil || (filters.UserID == nil || filters.CourseID == nil) {
-		return nil, fmt.Errorf("payment repo error on read: user_id and course_id filter should be passed")
+	if filters == nil || (filters.OrderID == nil && (filters.UserID == nil || filters.CourseID == nil)) {
+		return nil, fmt.Errorf("payment repo error on read: order_id or user_id and course_id filter should be passed")
 	}
 
 	payment := Payment{}
 
+	statement, args := paymentWhere(filters)
+	statement += " order by created_at desc limit 1"
+
+	err := scanPayment(r.db.QueryRow(statement, args...), &payment)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("payment repo error on read: %v", err)
+	}
+
+	return &payment, nil
+}
+
+// List returns the payments matching the filters, newest first.
+func (r *PaymentRepository) List(pagination entity.Pagination, filters *PaymentFilters) ([]Payment, error) {
+	statement, args := paymentWhere(filters)
+
+	if pagination.Limit == 0 {
+		pagination.Limit = 1
+	}
+	statement += " order by created_at desc limit ? offset ?"
+	args = append(args, pagination.Limit, pagination.Offset)
+
+	rows, err := r.db.Query(statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("payment repo error on list: %v", err)
+	}
+	defer rows.Close()
+
+	payments := make([]Payment, 0)
+	for rows.Next() {
+		payment := Payment{}
+
+		err = scanPayment(rows, &payment)
+		if err != nil {
+			return nil, fmt.Errorf("payment repo error on scanning a payment: %v", err)
+		}
+
+		payments = append(payments, payment)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("payment repo error on rows when listing: %v", err)
+	}
+
+	return payments, nil
+}
+
+// Stale returns the oldest orders of the provider that have been created or pending since before
+// the given time and have not been flagged for review.
+func (r *PaymentRepository) Stale(before time.Time, provider string, limit int) ([]Payment, error) {
+	rows, err := r.db.Query(PAYMENT_STALE_STATEMENT, entity.PaymentCreated, entity.PaymentPending, before, provider, limit)
+	if err != nil {
+		return nil, fmt.Errorf("payment repo error on reading stale payments: %v", err)
+	}
+	defer rows.Close()
+
+	payments := make([]Payment, 0)
+	for rows.Next() {
+		payment := Payment{}
+
+		err = scanPayment(rows, &payment)
+		if err != nil {
+			return nil, fmt.Errorf("payment repo error on scanning a payment: %v", err)
+		}
+
+		payments = append(payments, payment)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("payment repo error on rows when reading stale payments: %v", err)
+	}
+
+	return payments, nil
+}
+
+func paymentWhere(filters *PaymentFilters) (string, []any) {
 	statement := PAYMENT_SELECT_STATEMENT
-	statement += " where "
-	args := make([]any, 0, 2)
+	args := make([]any, 0, 5)
+	if filters == nil {
+		return statement, args
+	}
 
+	statement += " where "
 	if filters.UserID != nil {
 		statement += "user_id = uuid_to_bin(?) and "
 		args = append(args, *filters.UserID)
 	}
 	if filters.CourseID != nil {
-		statement += "course_id = uuid_to_bin(?) and "
-		args = append(args, *filters.CourseID)
+		statement += "(course_id = uuid_to_bin(?) or order_id in (select order_id from payment_items where course_id = uuid_to_bin(?))) and "
+		args = append(args, *filters.CourseID, *filters.CourseID)
 	}
-	statement += "confirmed = 1"
-
-	row := r.db.QueryRow(statement, args...)
-	err := row.Scan(&payment.ID, &payment.UserID, &payment.CourseID)
-	if errors.Is(err, sql.ErrNoRows) {
-		return nil, nil
+	if filters.OrderID != nil {
+		statement += "order_id = uuid_to_bin(?) and "
+		args = append(args, *filters.OrderID)
 	}
-	if err != nil {
-		return nil, fmt.Errorf("payment repo error on read: %v", err)
+	if filters.Status != nil {
+		statement += "status = ? and "
+		args = append(args, *filters.Status)
 	}
+	if len(filters.Statuses) != 0 {
+		statement += "status in (" + strings.TrimSuffix(strings.Repeat("?, ", len(filters.Statuses)), ", ") + ") and "
+		for _, status := range filters.Statuses {
+			args = append(args, status)
+		}
+	}
+	statement = strings.TrimSuffix(statement, " and ")
+	statement = strings.TrimSuffix(statement, " where ")
 
-	return &payment, nil
+	return statement, args
+}
+
+func scanPayment(row interface{ Scan(...any) error }, payment *Payment) error {
+	return row.Scan(&payment.ID, &payment.UserID, &payment.CourseID, &payment.BundleID, &payment.SubscriptionID, &payment.OrderID, &payment.Status, &payment.Amount, &payment.Discount, &payment.RefundedAmount, &payment.Currency, &payment.Provider, &payment.ProviderPaymentID, &payment.CheckoutURL, &payment.Gift, &payment.GiftRecipientEmail, &payment.CreatedAt, &payment.UpdatedAt)
 }
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/google/uuid"
+)
+
+const (
+	// PAYMENT_REVIEW_FLAG_STATEMENT keeps a single review per order, flagging the order again updates
+	// the reason but does not reopen a resolved review.
+	PAYMENT_REVIEW_FLAG_STATEMENT = "insert into payment_reviews(id, order_id, reason, local_status, provider_status) values(uuid_to_bin(?), uuid_to_bin(?), ?, ?, nullif(?, '')) " +
+		"on duplicate key update reason = values(reason), local_status = values(local_status), provider_status = values(provider_status)"
+	PAYMENT_REVIEW_SELECT_STATEMENT  = "select id, created_at, updated_at, order_id, reason, local_status, coalesce(provider_status, ''), resolved_at, resolved_by, coalesce(note, '') from payment_reviews"
+	PAYMENT_REVIEW_RESOLVE_STATEMENT = "update payment_reviews set resolved_at = current_timestamp, resolved_by = uuid_to_bin(?), note = ? where order_id = uuid_to_bin(?) and resolved_at is null"
+)
+
+type PaymentReviewRepository struct {
+	db *sql.DB
+}
+
+func NewPaymentReviewRepository(db *sql.DB) *PaymentReviewRepository {
+	return &PaymentReviewRepository{db: db}
+}
+
+func (r *PaymentReviewRepository) Flag(orderID uuid.UUID, reason string, localStatus entity.PaymentStatus, providerStatus string) error {
+	_, err := r.db.Exec(PAYMENT_REVIEW_FLAG_STATEMENT, uuid.New(), orderID, reason, localStatus, providerStatus)
+	if err != nil {
+		return fmt.Errorf("payment review repo error when flagging order: %v", err)
+	}
+
+	return nil
+}
+
+func (r *PaymentReviewRepository) Read(pagination entity.Pagination, openOnly bool) ([]entity.PaymentReview, error) {
+	statement := PAYMENT_REVIEW_SELECT_STATEMENT
+	if openOnly {
+		statement += " where resolved_at is null"
+	}
+
+	if pagination.Limit == 0 {
+		pagination.Limit = 1
+	}
+	statement += " order by updated_at desc limit ? offset ?"
+
+	rows, err := r.db.Query(statement, pagination.Limit, pagination.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("payment review repo error on reading reviews: %v", err)
+	}
+	defer rows.Close()
+
+	reviews := make([]entity.PaymentReview, 0)
+	for rows.Next() {
+		review := entity.PaymentReview{}
+		var resolvedAt sql.NullTime
+		var resolvedBy uuid.NullUUID
+
+		err = rows.Scan(&review.ID, &review.CreatedAt, &review.UpdatedAt, &review.OrderID, &review.Reason, &review.LocalStatus, &review.ProviderStatus, &resolvedAt, &resolvedBy, &review.Note)
+		if err != nil {
+			return nil, fmt.Errorf("payment review repo error on scanning a review: %v", err)
+		}
+
+		review.ResolvedAt = nullTime(resolvedAt)
+		if resolvedBy.Valid {
+			review.ResolvedBy = &resolvedBy.UUID
+		}
+
+		reviews = append(reviews, review)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("payment review repo error on rows when reading: %v", err)
+	}
+
+	return reviews, nil
+}
+
+// Resolve closes the open review of the order, it reports false if there is none.
+func (r *PaymentReviewRepository) Resolve(orderID uuid.UUID, resolvedBy uuid.UUID, note string) (bool, error) {
+	result, err := r.db.Exec(PAYMENT_REVIEW_RESOLVE_STATEMENT, resolvedBy, note, orderID)
+	if err != nil {
+		return false, fmt.Errorf("payment review repo error when resolving review: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("payment review repo error when resolving review: %v", err)
+	}
+
+	return affected == 1, nil
+}
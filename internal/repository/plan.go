@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/google/uuid"
+	"strings"
+)
+
+const (
+	PLAN_INSERT_STATEMENT     = "insert into plans(id, name, description, billing_interval, price, currency, grace_days) values(uuid_to_bin(?), ?, ?, ?, ?, ?, ?)"
+	PLAN_SELECT_STATEMENT     = "select id, created_at, name, description, billing_interval, price, currency, grace_days, active from plans"
+	PLAN_DEACTIVATE_STATEMENT = "update plans set active = 0 where id = uuid_to_bin(?)"
+)
+
+type PlanRepository struct {
+	db *sql.DB
+}
+
+func NewPlanRepository(db *sql.DB) *PlanRepository {
+	return &PlanRepository{db: db}
+}
+
+func (r *PlanRepository) Create(plan entity.NewPlan) (uuid.UUID, error) {
+	newID := uuid.New()
+
+	_, err := r.db.Exec(PLAN_INSERT_STATEMENT, newID, plan.Name, plan.Description, plan.Interval, plan.Price, plan.Currency, plan.GraceDays)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("plan repo error when adding new plan: %v", err)
+	}
+
+	return newID, nil
+}
+
+type PlanFilters struct {
+	ID         *uuid.UUID
+	ActiveOnly bool
+}
+
+func (r *PlanRepository) Read(pagination entity.Pagination, filters PlanFilters) ([]entity.Plan, error) {
+	statement := PLAN_SELECT_STATEMENT
+	args := make([]any, 0, 3)
+
+	if filters.ID != nil || filters.ActiveOnly {
+		statement += " where "
+	}
+	if filters.ID != nil {
+		statement += "id = uuid_to_bin(?) and "
+		args = append(args, *filters.ID)
+	}
+	if filters.ActiveOnly {
+		statement += "active = 1 and "
+	}
+	statement = strings.TrimSuffix(statement, " and ")
+
+	if pagination.Limit == 0 {
+		pagination.Limit = 1
+	}
+	statement += " order by price limit ? offset ?"
+	args = append(args, pagination.Limit, pagination.Offset)
+
+	rows, err := r.db.Query(statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("plan repo error on reading plans: %v", err)
+	}
+	defer rows.Close()
+
+	plans := make([]entity.Plan, 0)
+	for rows.Next() {
+		plan := entity.Plan{}
+
+		err = rows.Scan(&plan.ID, &plan.CreatedAt, &plan.Name, &plan.Description, &plan.Interval, &plan.Price, &plan.Currency, &plan.GraceDays, &plan.Active)
+		if err != nil {
+			return nil, fmt.Errorf("plan repo error on scanning a plan: %v", err)
+		}
+
+		plans = append(plans, plan)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("plan repo error on rows when reading: %v", err)
+	}
+
+	return plans, nil
+}
+
+func (r *PlanRepository) Deactivate(id uuid.UUID) error {
+	_, err := r.db.Exec(PLAN_DEACTIVATE_STATEMENT, id)
+	if err != nil {
+		return fmt.Errorf("plan repo error when deactivating plan: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+const (
+	RECEIPT_INSERT_STATEMENT = "insert into receipts(id, number, order_id, user_id, paid_at, file_url) values(uuid_to_bin(?), ?, uuid_to_bin(?), uuid_to_bin(?), ?, ?)"
+	RECEIPT_SELECT_STATEMENT = "select id, created_at, updated_at, number, order_id, user_id, paid_at, file_url from receipts"
+	RECEIPT_FILE_STATEMENT   = "update receipts set file_url = ?, updated_at = current_timestamp where order_id = uuid_to_bin(?)"
+)
+
+type ReceiptRepository struct {
+	db *sql.DB
+}
+
+func NewReceiptRepository(db *sql.DB) *ReceiptRepository {
+	return &ReceiptRepository{db: db}
+}
+
+type ReceiptCreateBody struct {
+	Number  string
+	OrderID uuid.UUID
+	UserID  uuid.UUID
+	PaidAt  time.Time
+	FileURL string
+}
+
+func (r *ReceiptRepository) Create(receipt *ReceiptCreateBody) error {
+	newID := uuid.New()
+
+	_, err := r.db.Exec(RECEIPT_INSERT_STATEMENT, newID, receipt.Number, receipt.OrderID, receipt.UserID, receipt.PaidAt, receipt.FileURL)
+	if err != nil {
+		return fmt.Errorf("receipt repo error when adding new receipt: %v", err)
+	}
+
+	return nil
+}
+
+type ReceiptFilters struct {
+	UserID   *uuid.UUID
+	OrderIDs []uuid.UUID
+}
+
+func (r *ReceiptRepository) Read(filters ReceiptFilters) ([]entity.Receipt, error) {
+	statement := RECEIPT_SELECT_STATEMENT
+	args := make([]any, 0, len(filters.OrderIDs)+1)
+
+	if filters.UserID != nil || len(filters.OrderIDs) != 0 {
+		statement += " where "
+	}
+	if filters.UserID != nil {
+		statement += "user_id = uuid_to_bin(?) and "
+		args = append(args, *filters.UserID)
+	}
+	if len(filters.OrderIDs) != 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("uuid_to_bin(?), ", len(filters.OrderIDs)), ", ")
+		statement += "order_id in (" + placeholders + ") and "
+		for _, orderID := range filters.OrderIDs {
+			args = append(args, orderID)
+		}
+	}
+	statement = strings.TrimSuffix(statement, " and ")
+	statement += " order by paid_at desc"
+
+	rows, err := r.db.Query(statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("receipt repo error on reading receipts: %v", err)
+	}
+	defer rows.Close()
+
+	receipts := make([]entity.Receipt, 0)
+	for rows.Next() {
+		receipt := entity.Receipt{}
+
+		err = rows.Scan(&receipt.ID, &receipt.CreatedAt, &receipt.UpdatedAt, &receipt.Number, &receipt.OrderID, &receipt.UserID, &receipt.PaidAt, &receipt.FileURL)
+		if err != nil {
+			return nil, fmt.Errorf("receipt repo error on scanning a receipt: %v", err)
+		}
+
+		receipts = append(receipts, receipt)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("receipt repo error on rows when reading: %v", err)
+	}
+
+	return receipts, nil
+}
+
+func (r *ReceiptRepository) SetFile(orderID uuid.UUID, fileURL string) error {
+	_, err := r.db.Exec(RECEIPT_FILE_STATEMENT, fileURL, orderID)
+	if err != nil {
+		return fmt.Errorf("receipt repo error when updating file: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+// REVENUE_PERIOD_STATEMENTS maps the report periods to the start date of the period an order falls
+// in, weeks start on Monday.
+var REVENUE_PERIOD_STATEMENTS = map[string]string{
+	"day":   "date(p.created_at)",
+	"week":  "date(p.created_at) - interval weekday(p.created_at) day",
+	"month": "date_format(p.created_at, '%Y-%m-01')",
+}
+
+const (
+	// REVENUE_STATEMENT is completed with the period, the gross, discount and refunded amounts, the
+	// joins and the filters of the report.
+	REVENUE_STATEMENT = "select date_format(%v, '%%Y-%%m-%%d') as period, p.currency, count(*), " +
+		"cast(coalesce(sum(%v), 0) as signed), " +
+		"cast(coalesce(sum(%v), 0) as signed), " +
+		"cast(coalesce(sum(%v), 0) as signed) " +
+		"from course_payments p%v" +
+		" where p.created_at >= ? and p.created_at < ?%v" +
+		" group by period, p.currency order by period, p.currency"
+	// REVENUE_COURSE_SHARE_STATEMENT is the part of an order paid for the course of the report, the
+	// order amount for orders of the course alone.
+	REVENUE_COURSE_SHARE_STATEMENT  = "coalesce(i.amount, p.amount)"
+	REVENUE_COURSE_JOIN_STATEMENT   = " left join payment_items i on i.order_id = p.order_id and i.course_id = uuid_to_bin(?)"
+	REVENUE_COURSE_FILTER_STATEMENT = " and (i.course_id is not null or p.course_id = uuid_to_bin(?))"
+)
+
+type ReportRepository struct {
+	db *sql.DB
+}
+
+func NewReportRepository(db *sql.DB) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+type RevenueFilters struct {
+	// From is inclusive and To exclusive.
+	From     time.Time
+	To       time.Time
+	CourseID *uuid.UUID
+	Statuses []entity.PaymentStatus
+	// Period is day, week or month.
+	Period string
+}
+
+type RevenueRow struct {
+	Period   string
+	Currency string
+	Orders   int64
+	Gross    int64
+	Discount int64
+	Refunded int64
+}
+
+// Revenue sums the orders created in the date range by period and currency. With a course filter
+// only the part of cart and bundle orders paid for the course is counted, and their discount and
+// refunds are split in the same proportion.
+func (r *ReportRepository) Revenue(filters RevenueFilters) ([]RevenueRow, error) {
+	period, ok := REVENUE_PERIOD_STATEMENTS[filters.Period]
+	if !ok {
+		return nil, fmt.Errorf("report repo error on revenue: unknown period %q", filters.Period)
+	}
+
+	gross, discount, refunded := "p.amount", "p.discount_amount", "p.refunded_amount"
+	join, where := "", ""
+	args := make([]any, 0, len(filters.Statuses)+5)
+	if filters.CourseID != nil {
+		gross = REVENUE_COURSE_SHARE_STATEMENT
+		discount = "round(p.discount_amount * " + REVENUE_COURSE_SHARE_STATEMENT + " / nullif(p.amount, 0))"
+		refunded = "round(p.refunded_amount * " + REVENUE_COURSE_SHARE_STATEMENT + " / nullif(p.amount, 0))"
+		join = REVENUE_COURSE_JOIN_STATEMENT
+		where = REVENUE_COURSE_FILTER_STATEMENT
+		args = append(args, *filters.CourseID)
+	}
+	args = append(args, filters.From, filters.To)
+	if filters.CourseID != nil {
+		args = append(args, *filters.CourseID)
+	}
+
+	statuses := ""
+	if len(filters.Statuses) != 0 {
+		statuses = " and p.status in (" + strings.TrimSuffix(strings.Repeat("?, ", len(filters.Statuses)), ", ") + ")"
+		for _, status := range filters.Statuses {
+			args = append(args, status)
+		}
+	}
+
+	statement := fmt.Sprintf(REVENUE_STATEMENT, period, gross, discount, refunded, join, where+statuses)
+
+	rows, err := r.db.Query(statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("report repo error on revenue: %v", err)
+	}
+	defer rows.Close()
+
+	revenue := make([]RevenueRow, 0)
+	for rows.Next() {
+		row := RevenueRow{}
+
+		err = rows.Scan(&row.Period, &row.Currency, &row.Orders, &row.Gross, &row.Discount, &row.Refunded)
+		if err != nil {
+			return nil, fmt.Errorf("report repo error on scanning revenue: %v", err)
+		}
+
+		revenue = append(revenue, row)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("report repo error on rows when reading revenue: %v", err)
+	}
+
+	return revenue, nil
+}
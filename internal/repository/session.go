@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	sessionInsertStatement       = "insert into sessions(id, family_id, user_id, token_hash, expires_at) values(uuid_to_bin(?), uuid_to_bin(?), uuid_to_bin(?), ?, ?)"
+	sessionSelectStatement       = "select id, family_id, user_id, expires_at, rotated_at, revoked_at from sessions where token_hash = ?"
+	sessionRotateStatement       = "update sessions set rotated_at = current_timestamp where id = uuid_to_bin(?) and rotated_at is null and revoked_at is null"
+	sessionRevokeFamilyStatement = "update sessions set revoked_at = current_timestamp where family_id = uuid_to_bin(?) and revoked_at is null"
+	sessionRevokeUserStatement   = "update sessions set revoked_at = current_timestamp where user_id = uuid_to_bin(?) and revoked_at is null"
+	sessionActiveStatement       = "select count(*) from sessions where family_id = uuid_to_bin(?) and revoked_at is null and expires_at > current_timestamp"
+)
+
+type SessionRepository struct {
+	db *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+type SessionCreateBody struct {
+	FamilyID  uuid.UUID
+	UserID    uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+func (r *SessionRepository) Create(session *SessionCreateBody) error {
+	newID := uuid.New()
+
+	_, err := r.db.Exec(sessionInsertStatement, newID, session.FamilyID, session.UserID, session.TokenHash, session.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("session repo error when adding new session: %v", err)
+	}
+
+	return nil
+}
+
+type Session struct {
+	ID        uuid.UUID    `db:"id"`
+	FamilyID  uuid.UUID    `db:"family_id"`
+	UserID    uuid.UUID    `db:"user_id"`
+	ExpiresAt time.Time    `db:"expires_at"`
+	RotatedAt sql.NullTime `db:"rotated_at"`
+	RevokedAt sql.NullTime `db:"revoked_at"`
+}
+
+func (r *SessionRepository) ReadByTokenHash(tokenHash string) (*Session, error) {
+	session := Session{}
+
+	row := r.db.QueryRow(sessionSelectStatement, tokenHash)
+	err := row.Scan(&session.ID, &session.FamilyID, &session.UserID, &session.ExpiresAt, &session.RotatedAt, &session.RevokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("session repo error on read: %v", err)
+	}
+
+	return &session, nil
+}
+
+// Rotate marks the session as used and reports whether this call was the one that did it,
+// so two concurrent refreshes with the same token cannot both succeed.
+func (r *SessionRepository) Rotate(id uuid.UUID) (bool, error) {
+	result, err := r.db.Exec(sessionRotateStatement, id)
+	if err != nil {
+		return false, fmt.Errorf("session repo error when rotating: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("session repo error when rotating: %v", err)
+	}
+
+	return affected == 1, nil
+}
+
+func (r *SessionRepository) RevokeFamily(familyID uuid.UUID) error {
+	_, err := r.db.Exec(sessionRevokeFamilyStatement, familyID)
+	if err != nil {
+		return fmt.Errorf("session repo error when revoking family: %v", err)
+	}
+
+	return nil
+}
+
+func (r *SessionRepository) RevokeUser(userID uuid.UUID) error {
+	_, err := r.db.Exec(sessionRevokeUserStatement, userID)
+	if err != nil {
+		return fmt.Errorf("session repo error when revoking user sessions: %v", err)
+	}
+
+	return nil
+}
+
+func (r *SessionRepository) IsActive(familyID uuid.UUID) (bool, error) {
+	count := 0
+
+	err := r.db.QueryRow(sessionActiveStatement, familyID).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("session repo error when checking family: %v", err)
+	}
+
+	return count > 0, nil
+}
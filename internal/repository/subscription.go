@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+const (
+	SUBSCRIPTION_INSERT_STATEMENT = "insert into subscriptions(id, user_id, plan_id, status) values(uuid_to_bin(?), uuid_to_bin(?), uuid_to_bin(?), ?)"
+	SUBSCRIPTION_SELECT_STATEMENT = "select s.id, s.created_at, s.user_id, s.status, s.current_period_start, s.current_period_end, s.cancel_at_period_end, s.cancelled_at, " +
+		"p.id, p.created_at, p.name, p.description, p.billing_interval, p.price, p.currency, p.grace_days, p.active " +
+		"from subscriptions s join plans p on p.id = s.plan_id"
+	SUBSCRIPTION_PERIOD_STATEMENT = "update subscriptions set status = ?, current_period_start = ?, current_period_end = ?, cancel_at_period_end = 0, cancelled_at = null where id = uuid_to_bin(?)"
+	SUBSCRIPTION_CANCEL_STATEMENT = "update subscriptions set cancel_at_period_end = 1, cancelled_at = ? where id = uuid_to_bin(?)"
+	SUBSCRIPTION_END_STATEMENT    = "update subscriptions set status = ?, current_period_end = ? where id = uuid_to_bin(?)"
+)
+
+type SubscriptionRepository struct {
+	db *sql.DB
+}
+
+func NewSubscriptionRepository(db *sql.DB) *SubscriptionRepository {
+	return &SubscriptionRepository{db: db}
+}
+
+// Create adds a pending subscription of the user to the plan.
+func (r *SubscriptionRepository) Create(userID uuid.UUID, planID uuid.UUID) (uuid.UUID, error) {
+	newID := uuid.New()
+
+	_, err := r.db.Exec(SUBSCRIPTION_INSERT_STATEMENT, newID, userID, planID, entity.SubscriptionPending)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("subscription repo error when adding new subscription: %v", err)
+	}
+
+	return newID, nil
+}
+
+type SubscriptionFilters struct {
+	ID     *uuid.UUID
+	UserID *uuid.UUID
+	// Paid leaves out subscriptions that are still waiting for their first payment.
+	Paid bool
+}
+
+// Read returns the subscriptions matching the filters, the most recent first.
+func (r *SubscriptionRepository) Read(pagination entity.Pagination, filters SubscriptionFilters) ([]entity.Subscription, error) {
+	statement := SUBSCRIPTION_SELECT_STATEMENT
+	args := make([]any, 0, 4)
+
+	if filters.ID != nil || filters.UserID != nil || filters.Paid {
+		statement += " where "
+	}
+	if filters.ID != nil {
+		statement += "s.id = uuid_to_bin(?) and "
+		args = append(args, *filters.ID)
+	}
+	if filters.UserID != nil {
+		statement += "s.user_id = uuid_to_bin(?) and "
+		args = append(args, *filters.UserID)
+	}
+	if filters.Paid {
+		statement += "s.status <> ? and "
+		args = append(args, entity.SubscriptionPending)
+	}
+	statement = strings.TrimSuffix(statement, " and ")
+
+	if pagination.Limit == 0 {
+		pagination.Limit = 1
+	}
+	statement += " order by s.current_period_end is null, s.current_period_end desc, s.created_at desc limit ? offset ?"
+	args = append(args, pagination.Limit, pagination.Offset)
+
+	rows, err := r.db.Query(statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("subscription repo error on reading subscriptions: %v", err)
+	}
+	defer rows.Close()
+
+	subscriptions := make([]entity.Subscription, 0)
+	for rows.Next() {
+		subscription := entity.Subscription{}
+		var periodStart, periodEnd, cancelledAt sql.NullTime
+
+		err = rows.Scan(&subscription.ID, &subscription.CreatedAt, &subscription.UserID, &subscription.Status, &periodStart, &periodEnd, &subscription.CancelAtPeriodEnd, &cancelledAt,
+			&subscription.Plan.ID, &subscription.Plan.CreatedAt, &subscription.Plan.Name, &subscription.Plan.Description, &subscription.Plan.Interval, &subscription.Plan.Price, &subscription.Plan.Currency, &subscription.Plan.GraceDays, &subscription.Plan.Active)
+		if err != nil {
+			return nil, fmt.Errorf("subscription repo error on scanning a subscription: %v", err)
+		}
+
+		if periodStart.Valid {
+			subscription.CurrentPeriodStart = &periodStart.Time
+		}
+		if periodEnd.Valid {
+			subscription.CurrentPeriodEnd = &periodEnd.Time
+		}
+		if cancelledAt.Valid {
+			subscription.CancelledAt = &cancelledAt.Time
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("subscription repo error on rows when reading: %v", err)
+	}
+
+	return subscriptions, nil
+}
+
+// SetPeriod activates the subscription for the period and clears a pending cancellation.
+func (r *SubscriptionRepository) SetPeriod(id uuid.UUID, start time.Time, end time.Time) error {
+	_, err := r.db.Exec(SUBSCRIPTION_PERIOD_STATEMENT, entity.SubscriptionActive, start, end, id)
+	if err != nil {
+		return fmt.Errorf("subscription repo error when setting period: %v", err)
+	}
+
+	return nil
+}
+
+func (r *SubscriptionRepository) Cancel(id uuid.UUID, at time.Time) error {
+	_, err := r.db.Exec(SUBSCRIPTION_CANCEL_STATEMENT, at, id)
+	if err != nil {
+		return fmt.Errorf("subscription repo error when cancelling: %v", err)
+	}
+
+	return nil
+}
+
+// End ends the subscription at the given time.
+func (r *SubscriptionRepository) End(id uuid.UUID, at time.Time) error {
+	_, err := r.db.Exec(SUBSCRIPTION_END_STATEMENT, entity.SubscriptionEnded, at, id)
+	if err != nil {
+		return fmt.Errorf("subscription repo error when ending: %v", err)
+	}
+
+	return nil
+}
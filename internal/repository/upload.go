@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+const (
+	UPLOAD_INSERT_STATEMENT = "insert into uploads(id, created_by, course_id, storage_key, filename, content_type, size, checksum, multipart_upload_id, part_size, part_checksums, status, expires_at) " +
+		"values(uuid_to_bin(?), uuid_to_bin(?), uuid_to_bin(?), ?, ?, ?, ?, ?, nullif(?, ''), ?, nullif(?, ''), ?, ?)"
+	UPLOAD_SELECT_STATEMENT  = "select id, created_at, updated_at, created_by, course_id, storage_key, filename, content_type, size, checksum, coalesce(multipart_upload_id, ''), part_size, coalesce(part_checksums, ''), status, expires_at, completed_at from uploads"
+	UPLOAD_EXPIRED_STATEMENT = UPLOAD_SELECT_STATEMENT + " where (status = ? and expires_at < ?) or (status = ? and updated_at < ?) order by updated_at limit ?"
+	// UPLOAD_STATUS_STATEMENT only moves an upload on from the expected status, so two requests can
+	// not both act on it.
+	UPLOAD_STATUS_STATEMENT = "update uploads set status = ?, completed_at = if(? = 'completed', current_timestamp, completed_at) where id = uuid_to_bin(?) and status = ?"
+)
+
+type UploadCreateBody struct {
+	CreatedBy     uuid.UUID
+	CourseID      uuid.UUID
+	Key           string
+	Filename      string
+	ContentType   string
+	Size          int64
+	Checksum      string
+	MultipartID   string
+	PartSize      int64
+	PartChecksums []string
+	ExpiresAt     time.Time
+}
+
+type UploadRepository struct {
+	db *sql.DB
+}
+
+func NewUploadRepository(db *sql.DB) *UploadRepository {
+	return &UploadRepository{db: db}
+}
+
+func (r *UploadRepository) Create(upload *UploadCreateBody) (*entity.Upload, error) {
+	newID := uuid.New()
+
+	_, err := r.db.Exec(UPLOAD_INSERT_STATEMENT, newID, upload.CreatedBy, upload.CourseID, upload.Key, upload.Filename, upload.ContentType, upload.Size, upload.Checksum, upload.MultipartID, upload.PartSize, strings.Join(upload.PartChecksums, ","), entity.UploadPending, upload.ExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("upload repo error when adding new upload: %v", err)
+	}
+
+	return r.Read(newID)
+}
+
+// Read returns nil if there is no upload with the id.
+func (r *UploadRepository) Read(id uuid.UUID) (*entity.Upload, error) {
+	uploads, err := r.query(UPLOAD_SELECT_STATEMENT+" where id = uuid_to_bin(?)", id)
+	if err != nil || len(uploads) == 0 {
+		return nil, err
+	}
+
+	return &uploads[0], nil
+}
+
+// Expired returns pending uploads whose URLs stopped working before the time, and uploads that have
+// been verifying since before verifyingBefore because their completion never finished.
+func (r *UploadRepository) Expired(before time.Time, verifyingBefore time.Time, limit int) ([]entity.Upload, error) {
+	return r.query(UPLOAD_EXPIRED_STATEMENT, entity.UploadPending, before, entity.UploadVerifying, verifyingBefore, limit)
+}
+
+// SetStatus moves the upload from one status to another, it reports false if the upload was not in
+// the expected status.
+func (r *UploadRepository) SetStatus(id uuid.UUID, from entity.UploadStatus, to entity.UploadStatus) (bool, error) {
+	result, err := r.db.Exec(UPLOAD_STATUS_STATEMENT, to, to, id, from)
+	if err != nil {
+		return false, fmt.Errorf("upload repo error when updating status: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("upload repo error when updating status: %v", err)
+	}
+
+	return affected == 1, nil
+}
+
+func (r *UploadRepository) query(statement string, args ...any) ([]entity.Upload, error) {
+	rows, err := r.db.Query(statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("upload repo error on reading uploads: %v", err)
+	}
+	defer rows.Close()
+
+	uploads := make([]entity.Upload, 0)
+	for rows.Next() {
+		upload := entity.Upload{}
+		var partChecksums string
+		var completedAt sql.NullTime
+
+		err = rows.Scan(&upload.ID, &upload.CreatedAt, &upload.UpdatedAt, &upload.CreatedBy, &upload.CourseID, &upload.Key, &upload.Filename, &upload.ContentType, &upload.Size, &upload.Checksum, &upload.MultipartID, &upload.PartSize, &partChecksums, &upload.Status, &upload.ExpiresAt, &completedAt)
+		if err != nil {
+			return nil, fmt.Errorf("upload repo error on scanning an upload: %v", err)
+		}
+
+		if partChecksums != "" {
+			upload.PartChecksums = strings.Split(partChecksums, ",")
+		}
+		upload.CompletedAt = nullTime(completedAt)
+		uploads = append(uploads, upload)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("upload repo error on rows when reading: %v", err)
+	}
+
+	return uploads, nil
+}
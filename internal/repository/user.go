@@ -9,10 +9,11 @@ import (
 )
 
 const (
-	userInsertStatement = "insert into users(id, email, name, phone, password, role) values(uuid_to_bin(?), ?, ?, ?, ?, ?)"
-	userSelectStatement = "select id, email, name, phone, password, role from users"
+	userInsertStatement = "insert into users(id, email, name, phone, password, role, locale) values(uuid_to_bin(?), ?, ?, ?, ?, ?, ?)"
+	userSelectStatement = "select id, email, name, phone, password, role, locale, email_verified_at from users"
 	userUpdateStatement = "update users set "
 	userDeleteStatement = "delete from users where id = uuid_to_bin(?);"
+	userVerifyStatement = "update users set email_verified_at = current_timestamp where id = uuid_to_bin(?) and email_verified_at is null"
 )
 
 type UserRepository struct {
@@ -28,7 +29,7 @@ func NewUserRepo(db *sql.DB) *UserRepository {
 func (r *UserRepository) Create(user entity.NewUser) (*uuid.UUID, error) {
 	newID := uuid.New()
 
-	_, err := r.db.Exec(userInsertStatement, newID, user.Email, user.Name, user.Phone, user.Password, user.Role)
+	_, err := r.db.Exec(userInsertStatement, newID, user.Email, user.Name, user.Phone, user.Password, user.Role, user.Locale)
 	if err != nil {
 		return nil, fmt.Errorf("user repo error when adding new user: %v", err)
 	}
@@ -71,7 +72,7 @@ func (r *UserRepository) Read(pagination entity.Pagination, filters entity.UserF
 	for rows.Next() {
 		user := entity.User{}
 
-		err = rows.Scan(&user.ID, &user.Email, &user.Name, &user.Phone, &user.Password, &user.Role)
+		err = rows.Scan(&user.ID, &user.Email, &user.Name, &user.Phone, &user.Password, &user.Role, &user.Locale, &user.EmailVerifiedAt)
 		if err != nil {
 			return nil, fmt.Errorf("user repo error on scanning a user: %v", err)
 		}
@@ -89,7 +90,7 @@ func (r *UserRepository) Read(pagination entity.Pagination, filters entity.UserF
 
 func (r *UserRepository) Update(body entity.UserUpdateBody) (bool, error) {
 	statement := userUpdateStatement
-	args := make([]any, 0, 6)
+	args := make([]any, 0, 8)
 
 	if body.ID == uuid.Nil {
 		return false, fmt.Errorf("ID is empty, repo layer error")
@@ -101,8 +102,8 @@ func (r *UserRepository) Update(body entity.UserUpdateBody) (bool, error) {
 	}
 
 	if body.Email != nil {
-		statement += "email = ?, "
-		args = append(args, body.Email)
+		statement += "email = ?, email_verified_at = if(email = ?, email_verified_at, null), "
+		args = append(args, body.Email, body.Email)
 	}
 
 	if body.Phone != nil {
@@ -120,6 +121,11 @@ func (r *UserRepository) Update(body entity.UserUpdateBody) (bool, error) {
 		args = append(args, body.Role)
 	}
 
+	if body.Locale != nil {
+		statement += "locale = ?, "
+		args = append(args, body.Locale)
+	}
+
 	if len(args) == 0 {
 		return false, fmt.Errorf("user repo error: update body is empty")
 	}
@@ -149,3 +155,16 @@ func (r *UserRepository) Delete(id uuid.UUID) (bool, error) {
 
 	return true, nil
 }
+
+func (r *UserRepository) VerifyEmail(id uuid.UUID) error {
+	if id == uuid.Nil {
+		return fmt.Errorf("user repo error when verifying email: id is empty")
+	}
+
+	_, err := r.db.Exec(userVerifyStatement, id)
+	if err != nil {
+		return fmt.Errorf("user repo error when verifying email: %v", err)
+	}
+
+	return nil
+}
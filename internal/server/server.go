@@ -3,6 +3,7 @@ package server
 import (
 	"fmt"
 	"github.com/AlnurZhanibek/kazusa-server/internal/handler"
+	"github.com/AlnurZhanibek/kazusa-server/internal/storage"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"log"
 	"net/http"
@@ -10,12 +11,119 @@ import (
 )
 
 type Handlers struct {
-	CourseHandler   *handler.CourseHandler
-	ModuleHandler   *handler.ModuleHandler
-	UserHandler     *handler.UserHandler
-	AuthHandler     *handler.AuthHandler
-	ActivityHandler *handler.ActivityHandler
-	PaymentHandler  *handler.PaymentHandler
+	CourseHandler         *handler.CourseHandler
+	ModuleHandler         *handler.ModuleHandler
+	UserHandler           *handler.UserHandler
+	AuthHandler           *handler.AuthHandler
+	ActivityHandler       *handler.ActivityHandler
+	PaymentHandler        *handler.PaymentHandler
+	PasswordHandler       *handler.PasswordHandler
+	EmailHandler          *handler.EmailVerificationHandler
+	CertificateHandler    *handler.CertificateHandler
+	DashboardHandler      *handler.DashboardHandler
+	CouponHandler         *handler.CouponHandler
+	BundleHandler         *handler.BundleHandler
+	SubscriptionHandler   *handler.SubscriptionHandler
+	EnrollmentHandler     *handler.EnrollmentHandler
+	GiftHandler           *handler.GiftHandler
+	ReceiptHandler        *handler.ReceiptHandler
+	ReportHandler         *handler.ReportHandler
+	ReconciliationHandler *handler.ReconciliationHandler
+	UploadHandler         *handler.UploadHandler
+	AuthMiddleware        *handler.AuthMiddleware
+	// FileServer serves stored files when the storage has no server of its own, it is nil otherwise.
+	FileServer http.Handler
+}
+
+var Policies = handler.Policies{
+	"GET /course":    handler.Public,
+	"POST /course":   handler.AdminOnly,
+	"PUT /course":    handler.AdminOnly,
+	"DELETE /course": handler.AdminOnly,
+	"GET /course/":   handler.Public,
+
+	"GET /module":    handler.Public,
+	"POST /module":   handler.AdminOnly,
+	"PUT /module":    handler.AdminOnly,
+	"DELETE /module": handler.AdminOnly,
+
+	"GET /user":    handler.SelfOrAdmin,
+	"POST /user":   handler.AdminOnly,
+	"PUT /user":    handler.AdminOnly,
+	"DELETE /user": handler.AdminOnly,
+
+	"POST /activity": handler.Authenticated,
+
+	"POST /payment/confirm":            handler.Public,
+	"POST /payment/refund":             handler.AdminOnly,
+	"POST /payment/receipt":            handler.AdminOnly,
+	"GET /payment/review":              handler.AdminOnly,
+	"POST /payment/review/resolve":     handler.AdminOnly,
+	"GET /payment/simulator/checkout":  handler.Public,
+	"POST /payment/simulator/checkout": handler.Public,
+	"POST /order":                      handler.Authenticated,
+	"POST /order/gift":                 handler.Authenticated,
+	"POST /checkout":                   handler.Authenticated,
+	"POST /checkout/preview":           handler.Authenticated,
+
+	"POST /login":    handler.Public,
+	"POST /register": handler.Public,
+
+	"POST /token/refresh": handler.Public,
+	"POST /logout":        handler.Public,
+	"POST /logout/all":    handler.Authenticated,
+
+	"POST /password/forgot": handler.Public,
+	"POST /password/reset":  handler.Public,
+
+	"POST /verify-email":        handler.Public,
+	"POST /verify-email/resend": handler.Authenticated,
+
+	"GET /certificate":         handler.Authenticated,
+	"GET /certificate/verify/": handler.Public,
+
+	"GET /me/courses":          handler.Authenticated,
+	"GET /me/payments":         handler.Authenticated,
+	"GET /me/payments/receipt": handler.Authenticated,
+
+	"GET /coupon":          handler.AdminOnly,
+	"POST /coupon":         handler.AdminOnly,
+	"DELETE /coupon":       handler.AdminOnly,
+	"POST /coupon/preview": handler.Authenticated,
+
+	"GET /bundle":    handler.Public,
+	"POST /bundle":   handler.AdminOnly,
+	"DELETE /bundle": handler.AdminOnly,
+
+	"GET /plan":    handler.Public,
+	"POST /plan":   handler.AdminOnly,
+	"DELETE /plan": handler.AdminOnly,
+
+	"POST /subscription":   handler.Authenticated,
+	"DELETE /subscription": handler.Authenticated,
+	"GET /me/subscription": handler.Authenticated,
+
+	"GET /enrollment":         handler.AdminOnly,
+	"POST /enrollment":        handler.Authenticated,
+	"POST /enrollment/grant":  handler.AdminOnly,
+	"POST /enrollment/revoke": handler.AdminOnly,
+
+	"POST /redeem":          handler.Authenticated,
+	"GET /me/gifts":         handler.Authenticated,
+	"GET /code-batch":       handler.AdminOnly,
+	"POST /code-batch":      handler.AdminOnly,
+	"GET /code-batch/codes": handler.AdminOnly,
+
+	"GET /reports/revenue": handler.AdminOnly,
+
+	"POST /upload":          handler.AdminOnly,
+	"DELETE /upload":        handler.AdminOnly,
+	"POST /upload/complete": handler.AdminOnly,
+
+	"GET /files/": handler.Public,
+	"PUT /files/": handler.Public,
+
+	"GET /swagger": handler.Public,
 }
 
 func Start(handlers *Handlers) {
@@ -36,6 +144,12 @@ func Start(handlers *Handlers) {
 		}
 	})
 
+	mux.HandleFunc("/course/{id}/attachments/{n}", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			handlers.CourseHandler.Attachment(w, r)
+		}
+	})
+
 	mux.HandleFunc("/module", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
@@ -74,9 +188,40 @@ func Start(handlers *Handlers) {
 		}
 	})
 
-	mux.HandleFunc("/payment", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/payment/refund", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			handlers.PaymentHandler.Refund(w, r)
+		}
+	})
+
+	mux.HandleFunc("/payment/review", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			handlers.ReconciliationHandler.Reviews(w, r)
+		}
+	})
+
+	mux.HandleFunc("/payment/review/resolve", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			handlers.ReconciliationHandler.Resolve(w, r)
+		}
+	})
+
+	mux.HandleFunc("/payment/simulator/checkout", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodPost:
+			handlers.PaymentHandler.SimulatorCheckout(w, r)
+		}
+	})
+
+	mux.HandleFunc("/order", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodPost {
-			handlers.PaymentHandler.Create(w, r)
+			handlers.PaymentHandler.CreateOrder(w, r)
+		}
+	})
+
+	mux.HandleFunc("/order/gift", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			handlers.PaymentHandler.CreateGiftOrder(w, r)
 		}
 	})
 
@@ -92,11 +237,233 @@ func Start(handlers *Handlers) {
 		}
 	})
 
+	mux.HandleFunc("/token/refresh", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			handlers.AuthHandler.Refresh(w, r)
+		}
+	})
+
+	mux.HandleFunc("/logout", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			handlers.AuthHandler.Logout(w, r)
+		}
+	})
+
+	mux.HandleFunc("/logout/all", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			handlers.AuthHandler.LogoutAll(w, r)
+		}
+	})
+
+	mux.HandleFunc("/password/forgot", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			handlers.PasswordHandler.Forgot(w, r)
+		}
+	})
+
+	mux.HandleFunc("/password/reset", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			handlers.PasswordHandler.Reset(w, r)
+		}
+	})
+
+	mux.HandleFunc("/verify-email", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			handlers.EmailHandler.Verify(w, r)
+		}
+	})
+
+	mux.HandleFunc("/verify-email/resend", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			handlers.EmailHandler.Resend(w, r)
+		}
+	})
+
+	mux.HandleFunc("/certificate", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			handlers.CertificateHandler.Read(w, r)
+		}
+	})
+
+	mux.HandleFunc("/certificate/verify/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			handlers.CertificateHandler.Verify(w, r)
+		}
+	})
+
+	mux.HandleFunc("/me/courses", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			handlers.DashboardHandler.Courses(w, r)
+		}
+	})
+
+	mux.HandleFunc("/coupon", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handlers.CouponHandler.Read(w, r)
+		case http.MethodPost:
+			handlers.CouponHandler.Create(w, r)
+		case http.MethodDelete:
+			handlers.CouponHandler.Delete(w, r)
+		}
+	})
+
+	mux.HandleFunc("/coupon/preview", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			handlers.CouponHandler.Preview(w, r)
+		}
+	})
+
+	mux.HandleFunc("/bundle", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handlers.BundleHandler.Read(w, r)
+		case http.MethodPost:
+			handlers.BundleHandler.Create(w, r)
+		case http.MethodDelete:
+			handlers.BundleHandler.Delete(w, r)
+		}
+	})
+
+	mux.HandleFunc("/checkout", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			handlers.PaymentHandler.Checkout(w, r)
+		}
+	})
+
+	mux.HandleFunc("/checkout/preview", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			handlers.PaymentHandler.PreviewCheckout(w, r)
+		}
+	})
+
+	mux.HandleFunc("/plan", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handlers.SubscriptionHandler.ReadPlans(w, r)
+		case http.MethodPost:
+			handlers.SubscriptionHandler.CreatePlan(w, r)
+		case http.MethodDelete:
+			handlers.SubscriptionHandler.DeletePlan(w, r)
+		}
+	})
+
+	mux.HandleFunc("/subscription", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			handlers.SubscriptionHandler.Subscribe(w, r)
+		case http.MethodDelete:
+			handlers.SubscriptionHandler.Cancel(w, r)
+		}
+	})
+
+	mux.HandleFunc("/me/subscription", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			handlers.SubscriptionHandler.Current(w, r)
+		}
+	})
+
+	mux.HandleFunc("/enrollment", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handlers.EnrollmentHandler.Read(w, r)
+		case http.MethodPost:
+			handlers.EnrollmentHandler.Enroll(w, r)
+		}
+	})
+
+	mux.HandleFunc("/enrollment/grant", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			handlers.EnrollmentHandler.Grant(w, r)
+		}
+	})
+
+	mux.HandleFunc("/enrollment/revoke", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			handlers.EnrollmentHandler.Revoke(w, r)
+		}
+	})
+
+	mux.HandleFunc("/redeem", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			handlers.GiftHandler.Redeem(w, r)
+		}
+	})
+
+	mux.HandleFunc("/me/gifts", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			handlers.GiftHandler.Gifts(w, r)
+		}
+	})
+
+	mux.HandleFunc("/code-batch", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handlers.GiftHandler.ReadBatches(w, r)
+		case http.MethodPost:
+			handlers.GiftHandler.CreateBatch(w, r)
+		}
+	})
+
+	mux.HandleFunc("/code-batch/codes", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			handlers.GiftHandler.ReadCodes(w, r)
+		}
+	})
+
+	mux.HandleFunc("/me/payments", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			handlers.ReceiptHandler.Payments(w, r)
+		}
+	})
+
+	mux.HandleFunc("/me/payments/receipt", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			handlers.ReceiptHandler.Download(w, r)
+		}
+	})
+
+	mux.HandleFunc("/payment/receipt", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			handlers.ReceiptHandler.Regenerate(w, r)
+		}
+	})
+
+	mux.HandleFunc("/reports/revenue", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			handlers.ReportHandler.Revenue(w, r)
+		}
+	})
+
+	mux.HandleFunc("/upload", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			handlers.UploadHandler.Create(w, r)
+		case http.MethodDelete:
+			handlers.UploadHandler.Abort(w, r)
+		}
+	})
+
+	mux.HandleFunc("/upload/complete", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			handlers.UploadHandler.Complete(w, r)
+		}
+	})
+
+	if handlers.FileServer != nil {
+		mux.HandleFunc(storage.LocalRoute, func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodPut:
+				handlers.FileServer.ServeHTTP(w, r)
+			}
+		})
+	}
+
 	mux.HandleFunc("/swagger", httpSwagger.Handler(
 		httpSwagger.URL("http://localhost:"+port+"/swagger/doc.json"),
 	))
 
-	err := http.ListenAndServe(fmt.Sprintf(":%v", port), mux)
+	err := http.ListenAndServe(fmt.Sprintf(":%v", port), handlers.AuthMiddleware.Handler(mux))
 	if err != nil {
 		log.Fatalf("server error: %v", err)
 	} else {
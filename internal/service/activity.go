@@ -1,18 +1,27 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
 
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
 	"github.com/AlnurZhanibek/kazusa-server/internal/repository"
 	"github.com/google/uuid"
 )
 
+var ErrModuleNotInCourse = errors.New("module does not belong to the course")
+
 type ActivityService struct {
-	repo *repository.ActivityRepository
+	repo               *repository.ActivityRepository
+	moduleRepo         repository.ModuleRepositoryImplementation
+	certificateService *CertificateService
+	enrollmentService  *EnrollmentService
 }
 
-func NewActivityService(repo *repository.ActivityRepository) *ActivityService {
-	return &ActivityService{repo: repo}
+func NewActivityService(repo *repository.ActivityRepository, moduleRepo repository.ModuleRepositoryImplementation, certificateService *CertificateService, enrollmentService *EnrollmentService) *ActivityService {
+	return &ActivityService{repo: repo, moduleRepo: moduleRepo, certificateService: certificateService, enrollmentService: enrollmentService}
 }
 
 type ActivityCreateBody struct {
@@ -25,12 +34,43 @@ type ActivityCreateBody struct {
 	IsLast       *bool
 }
 
-func (s *ActivityService) Create(activity *ActivityCreateBody) error {
-	return s.repo.Create(&repository.ActivityCreateBody{
+// Create records a completed module and issues the certificate once the course is completed.
+// Completion is computed on the server for every module instead of trusting IsLast from the client,
+// so learners who finish modules out of order still get their certificate.
+// The caller has to have access to the course and the module has to belong to it,
+// otherwise the error wraps ErrPaymentRequired, ErrEnrollmentRequired or ErrModuleNotInCourse.
+// A course taken through the subscription is recorded as started for the dashboard.
+func (s *ActivityService) Create(ctx context.Context, activity *ActivityCreateBody) error {
+	err := s.enrollmentService.CheckAccess(ctx, activity.CourseID)
+	if err != nil {
+		return fmt.Errorf("activity service create error: %w", err)
+	}
+
+	modules, err := s.moduleRepo.Read(entity.ModuleFilters{ID: activity.ModuleID}, entity.Pagination{Limit: 1})
+	if err != nil {
+		return fmt.Errorf("activity service create error: %v", err)
+	}
+	if len(modules) == 0 || modules[0].CourseID != activity.CourseID {
+		return fmt.Errorf("activity service create error: %w", ErrModuleNotInCourse)
+	}
+
+	err = s.repo.Create(&repository.ActivityCreateBody{
 		UserID:   activity.UserID,
 		CourseID: activity.CourseID,
 		ModuleID: activity.ModuleID,
 	})
+	if err != nil {
+		return err
+	}
+
+	s.enrollmentService.recordStart(activity.UserID, activity.CourseID)
+
+	_, err = s.certificateService.IssueIfCompleted(ctx, activity.UserID, activity.CourseID)
+	if err != nil {
+		log.Printf("activity service: issuing certificate for user %v course %v failed: %v", activity.UserID, activity.CourseID, err)
+	}
+
+	return nil
 }
 
 type Activity struct {
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"github.com/AlnurZhanibek/kazusa-server/internal/repository"
+	"github.com/google/uuid"
+)
+
+const AttachmentMigrationLockName = "kazusa.attachment_migration"
+
+// AttachmentMigration moves the attachments uploaded before attachments were private, which are
+// stored as public URLs, to private keys of their course. The public copies are released to the
+// file collection once no course refers to them.
+type AttachmentMigration struct {
+	courseRepo  *repository.CourseRepository
+	fileService *FileService
+	lock        *repository.LeaderLock
+}
+
+func NewAttachmentMigration(courseRepo *repository.CourseRepository, fileService *FileService, lock *repository.LeaderLock) *AttachmentMigration {
+	return &AttachmentMigration{courseRepo: courseRepo, fileService: fileService, lock: lock}
+}
+
+// Run migrates the legacy attachments of every course once. Only the replica holding the leader
+// lock runs it, an attachment that fails is tried again on the next start.
+func (m *AttachmentMigration) Run(ctx context.Context) {
+	leader, err := m.lock.Acquire(ctx)
+	if err != nil {
+		log.Printf("attachment migration: %v", err)
+	}
+	if !leader {
+		return
+	}
+	defer m.lock.Release()
+
+	courses, err := m.courseRepo.LegacyAttachments()
+	if err != nil {
+		log.Printf("attachment migration: %v", err)
+		return
+	}
+
+	for _, course := range courses {
+		attachments, err := course.Attachments()
+		if err != nil {
+			log.Printf("attachment migration: course %v: %v", course.ID, err)
+			continue
+		}
+
+		for _, attachment := range attachments {
+			if ctx.Err() != nil {
+				return
+			}
+
+			legacyKey, ok := m.fileService.Key(attachment)
+			if !ok {
+				continue
+			}
+
+			err = m.migrate(ctx, course.ID, attachment, legacyKey)
+			if err != nil {
+				log.Printf("attachment migration: course %v, %v: %v", course.ID, attachment, err)
+			}
+		}
+	}
+}
+
+func (m *AttachmentMigration) migrate(ctx context.Context, courseID uuid.UUID, attachment string, legacyKey string) error {
+	key, err := m.fileService.CopyCourseAttachment(ctx, courseID, legacyKey)
+	if err != nil {
+		return err
+	}
+
+	replaced, err := m.courseRepo.ReplaceAttachment(courseID, attachment, key)
+	if err != nil || !replaced {
+		if releaseErr := m.fileService.Release(key); releaseErr != nil {
+			log.Printf("attachment migration: %v", releaseErr)
+		}
+		return err
+	}
+
+	used, err := m.courseRepo.AttachmentUsed(attachment)
+	if err != nil || used {
+		return err
+	}
+
+	// a public copy shared by several courses was not tracked, tracking it twice is harmless
+	err = m.fileService.Track(legacyKey, courseID, AttachmentFilePurpose)
+	if err != nil {
+		return err
+	}
+
+	return m.fileService.Release(legacyKey)
+}
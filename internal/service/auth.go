@@ -1,34 +1,56 @@
 package service
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
 	"github.com/AlnurZhanibek/kazusa-server/internal/repository"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
+	"log"
+	"net/mail"
 	"os"
 	"time"
 )
 
 var jwtKey = []byte(os.Getenv("JWT_KEY"))
 
+const (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
 type Claims struct {
 	jwt.StandardClaims
-	UserID uuid.UUID   `json:"userId"`
-	Name   string      `json:"name"`
-	Role   entity.Role `json:"role"`
+	UserID    uuid.UUID   `json:"userId"`
+	SessionID uuid.UUID   `json:"sid"`
+	Name      string      `json:"name"`
+	Role      entity.Role `json:"role"`
+}
+
+type Tokens struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
 }
 
 type AuthService struct {
-	userRepo *repository.UserRepository
+	userRepo            *repository.UserRepository
+	sessionRepo         *repository.SessionRepository
+	verificationService *EmailVerificationService
 }
 
-func NewAuthService(userRepo *repository.UserRepository) *AuthService {
-	return &AuthService{userRepo: userRepo}
+func NewAuthService(userRepo *repository.UserRepository, sessionRepo *repository.SessionRepository, verificationService *EmailVerificationService) *AuthService {
+	return &AuthService{userRepo: userRepo, sessionRepo: sessionRepo, verificationService: verificationService}
 }
 
-func (s *AuthService) Login(email string, password string) (string, error) {
+func (s *AuthService) Login(email string, password string) (*Tokens, error) {
 	users, err := s.userRepo.Read(
 		entity.Pagination{
 			Offset: 0,
@@ -39,43 +61,38 @@ func (s *AuthService) Login(email string, password string) (string, error) {
 		},
 	)
 	if err != nil {
-		return "", fmt.Errorf("auth service login error: %v", err)
+		return nil, fmt.Errorf("auth service login error: %v", err)
 	}
 	if len(users) == 0 {
-		return "", fmt.Errorf("auth service login error: user not found")
+		return nil, fmt.Errorf("auth service login error: user not found")
 	}
 	user := users[0]
 
 	err = bcrypt.CompareHashAndPassword([]byte(*user.Password), []byte(password))
 	if err != nil {
-		return "", fmt.Errorf("auth service login error comparing pass: %v", err)
+		return nil, fmt.Errorf("auth service login error comparing pass: %v", err)
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Hour * 24).Unix(),
-			Subject:   email,
-		},
-		UserID: user.ID,
-		Name:   user.Name,
-		Role:   user.Role,
-	})
-
-	tokenString, err := token.SignedString(jwtKey)
+	tokens, err := s.issueTokens(user, uuid.New())
 	if err != nil {
-		return "", fmt.Errorf("auth service login error generating token: %v", err)
+		return nil, fmt.Errorf("auth service login error: %v", err)
 	}
 
-	return tokenString, nil
+	return tokens, nil
 }
 
-func (s *AuthService) Register(name string, email string, phone string, password string, passwordConfirmation string) (string, error) {
+func (s *AuthService) Register(ctx context.Context, name string, email string, phone string, password string, passwordConfirmation string, locale string) (*Tokens, error) {
 	if password != passwordConfirmation {
-		return "", fmt.Errorf("auth service register error: passwords do not match")
+		return nil, fmt.Errorf("auth service register error: passwords do not match")
 	}
 
 	if name == "" || email == "" {
-		return "", fmt.Errorf("auth service register error: name or email is empty")
+		return nil, fmt.Errorf("auth service register error: name or email is empty")
+	}
+
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return nil, fmt.Errorf("auth service register error: email is invalid")
 	}
 
 	users, err := s.userRepo.Read(
@@ -88,45 +105,229 @@ func (s *AuthService) Register(name string, email string, phone string, password
 		},
 	)
 	if err != nil {
-		return "", fmt.Errorf("auth service register error: %v", err)
+		return nil, fmt.Errorf("auth service register error: %v", err)
 	}
 	if len(users) != 0 {
-		return "", fmt.Errorf("auth service register error: user already exists")
+		return nil, fmt.Errorf("auth service register error: user already exists")
 	}
 
-	hashedPasswordBytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+	hashedPassword, err := hashPassword(password)
 	if err != nil {
-		return "", fmt.Errorf("auth service register password hash error: %v", err)
+		return nil, fmt.Errorf("auth service register error: %v", err)
 	}
 
-	hashedPassword := string(hashedPasswordBytes)
-
 	newUser := entity.NewUser{
 		Name:     name,
 		Email:    email,
 		Phone:    phone,
 		Password: hashedPassword,
+		Role:     entity.UserRole,
+		Locale:   NormalizeLocale(locale),
 	}
 
 	newID, err := s.userRepo.Create(newUser)
 	if err != nil {
-		return "", fmt.Errorf("auth service register error: %v", err)
+		return nil, fmt.Errorf("auth service register error: %v", err)
+	}
+
+	user := entity.User{
+		ID:     *newID,
+		Name:   name,
+		Email:  email,
+		Role:   entity.UserRole,
+		Locale: newUser.Locale,
+	}
+
+	err = s.verificationService.Send(ctx, user)
+	if err != nil {
+		log.Printf("auth service register: sending verification email to %v failed: %v", email, err)
+	}
+
+	tokens, err := s.issueTokens(user, uuid.New())
+	if err != nil {
+		return nil, fmt.Errorf("auth service register error: %v", err)
+	}
+
+	return tokens, nil
+}
+
+// Refresh exchanges a refresh token for a new token pair. Every refresh token can be used once:
+// presenting an already rotated or revoked token is treated as theft and revokes its whole family.
+func (s *AuthService) Refresh(refreshToken string) (*Tokens, error) {
+	session, err := s.sessionRepo.ReadByTokenHash(hashToken(refreshToken))
+	if err != nil {
+		return nil, fmt.Errorf("auth service refresh error: %v", err)
+	}
+	if session == nil {
+		return nil, fmt.Errorf("auth service refresh error: session not found")
+	}
+
+	if session.RotatedAt.Valid || session.RevokedAt.Valid {
+		err = s.sessionRepo.RevokeFamily(session.FamilyID)
+		if err != nil {
+			return nil, fmt.Errorf("auth service refresh error: %v", err)
+		}
+		return nil, fmt.Errorf("auth service refresh error: refresh token reuse detected")
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		return nil, fmt.Errorf("auth service refresh error: refresh token expired")
+	}
+
+	rotated, err := s.sessionRepo.Rotate(session.ID)
+	if err != nil {
+		return nil, fmt.Errorf("auth service refresh error: %v", err)
+	}
+	if !rotated {
+		err = s.sessionRepo.RevokeFamily(session.FamilyID)
+		if err != nil {
+			return nil, fmt.Errorf("auth service refresh error: %v", err)
+		}
+		return nil, fmt.Errorf("auth service refresh error: refresh token reuse detected")
+	}
+
+	users, err := s.userRepo.Read(
+		entity.Pagination{
+			Offset: 0,
+			Limit:  1,
+		},
+		entity.UserFilters{
+			ID: &session.UserID,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("auth service refresh error: %v", err)
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("auth service refresh error: user not found")
+	}
+
+	tokens, err := s.issueTokens(users[0], session.FamilyID)
+	if err != nil {
+		return nil, fmt.Errorf("auth service refresh error: %v", err)
 	}
 
+	return tokens, nil
+}
+
+func (s *AuthService) Logout(refreshToken string) error {
+	session, err := s.sessionRepo.ReadByTokenHash(hashToken(refreshToken))
+	if err != nil {
+		return fmt.Errorf("auth service logout error: %v", err)
+	}
+	if session == nil {
+		return nil
+	}
+
+	err = s.sessionRepo.RevokeFamily(session.FamilyID)
+	if err != nil {
+		return fmt.Errorf("auth service logout error: %v", err)
+	}
+
+	return nil
+}
+
+func (s *AuthService) LogoutAll(userID uuid.UUID) error {
+	err := s.sessionRepo.RevokeUser(userID)
+	if err != nil {
+		return fmt.Errorf("auth service logout all error: %v", err)
+	}
+
+	return nil
+}
+
+func (s *AuthService) ParseToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return jwtKey, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("auth service parse token error: %v", err)
+	}
+	if !token.Valid || claims.UserID == uuid.Nil {
+		return nil, fmt.Errorf("auth service parse token error: token is invalid")
+	}
+
+	active, err := s.sessionRepo.IsActive(claims.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("auth service parse token error: %v", err)
+	}
+	if !active {
+		return nil, fmt.Errorf("auth service parse token error: session is revoked")
+	}
+
+	return claims, nil
+}
+
+func (s *AuthService) issueTokens(user entity.User, familyID uuid.UUID) (*Tokens, error) {
+	now := time.Now()
+	accessExpiresAt := now.Add(AccessTokenTTL)
+	refreshExpiresAt := now.Add(RefreshTokenTTL)
+
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Hour * 24).Unix(),
-			Subject:   email,
+			ExpiresAt: accessExpiresAt.Unix(),
+			IssuedAt:  now.Unix(),
+			Subject:   user.Email,
 		},
-		UserID: *newID,
-		Name:   name,
-		Role:   "user",
+		UserID:    user.ID,
+		SessionID: familyID,
+		Name:      user.Name,
+		Role:      user.Role,
 	})
 
-	tokenString, err := token.SignedString(jwtKey)
+	accessToken, err := token.SignedString(jwtKey)
 	if err != nil {
-		return "", fmt.Errorf("auth service register error generating token: %v", err)
+		return nil, fmt.Errorf("generating access token: %v", err)
 	}
 
-	return tokenString, nil
+	refreshToken, err := randomToken()
+	if err != nil {
+		return nil, fmt.Errorf("generating refresh token: %v", err)
+	}
+
+	err = s.sessionRepo.Create(&repository.SessionCreateBody{
+		FamilyID:  familyID,
+		UserID:    user.ID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: refreshExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tokens{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+func hashPassword(password string) (string, error) {
+	hashedPasswordBytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+	if err != nil {
+		return "", fmt.Errorf("password hash error: %v", err)
+	}
+
+	return string(hashedPasswordBytes), nil
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
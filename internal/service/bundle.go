@@ -0,0 +1,110 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/AlnurZhanibek/kazusa-server/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrBundleNotFound = errors.New("bundle not found")
+	ErrInvalidBundle  = errors.New("invalid bundle")
+)
+
+type BundleService struct {
+	repo       *repository.BundleRepository
+	courseRepo repository.CourseRepositoryImplementation
+}
+
+func NewBundleService(repo *repository.BundleRepository, courseRepo repository.CourseRepositoryImplementation) *BundleService {
+	return &BundleService{repo: repo, courseRepo: courseRepo}
+}
+
+// Create adds a bundle of at least two existing courses, all priced in the currency of the bundle.
+func (s *BundleService) Create(bundle entity.NewBundle) (uuid.UUID, error) {
+	bundle.Currency = strings.ToUpper(strings.TrimSpace(bundle.Currency))
+	if bundle.Currency == "" {
+		bundle.Currency = entity.DefaultCurrency
+	}
+	bundle.CourseIDs = uniqueIDs(bundle.CourseIDs)
+
+	switch {
+	case strings.TrimSpace(bundle.Title) == "":
+		return uuid.Nil, fmt.Errorf("bundle service create error: %w, title is empty", ErrInvalidBundle)
+	case bundle.Price < 0:
+		return uuid.Nil, fmt.Errorf("bundle service create error: %w, price is negative", ErrInvalidBundle)
+	case len(bundle.CourseIDs) < 2:
+		return uuid.Nil, fmt.Errorf("bundle service create error: %w, a bundle needs at least two courses", ErrInvalidBundle)
+	}
+
+	for _, courseID := range bundle.CourseIDs {
+		courses, err := s.courseRepo.Read(entity.Pagination{Limit: 1}, entity.CourseFilters{ID: courseID})
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("bundle service create error: %v", err)
+		}
+		if len(courses) == 0 {
+			return uuid.Nil, fmt.Errorf("bundle service create error: %w, course %v not found", ErrInvalidBundle, courseID)
+		}
+		if courses[0].Currency != bundle.Currency {
+			return uuid.Nil, fmt.Errorf("bundle service create error: %w, course %v is priced in %v", ErrInvalidBundle, courseID, courses[0].Currency)
+		}
+	}
+
+	id, err := s.repo.Create(bundle)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("bundle service create error: %v", err)
+	}
+
+	return id, nil
+}
+
+// Read returns the bundles on sale, or every bundle including the deactivated ones for admins.
+func (s *BundleService) Read(pagination entity.Pagination, all bool) ([]entity.Bundle, error) {
+	bundles, err := s.repo.Read(pagination, repository.BundleFilters{ActiveOnly: !all})
+	if err != nil {
+		return nil, fmt.Errorf("bundle service read error: %v", err)
+	}
+
+	return bundles, nil
+}
+
+// Get returns the bundle if it is on sale.
+func (s *BundleService) Get(id uuid.UUID) (*entity.Bundle, error) {
+	bundles, err := s.repo.Read(entity.Pagination{Limit: 1}, repository.BundleFilters{ID: &id, ActiveOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("bundle service get error: %v", err)
+	}
+	if len(bundles) == 0 {
+		return nil, fmt.Errorf("bundle service get error: %w", ErrBundleNotFound)
+	}
+
+	return &bundles[0], nil
+}
+
+// Delete takes the bundle off sale, it stays referenced by the orders it was bought with.
+func (s *BundleService) Delete(id uuid.UUID) error {
+	err := s.repo.Deactivate(id)
+	if err != nil {
+		return fmt.Errorf("bundle service delete error: %v", err)
+	}
+
+	return nil
+}
+
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if id == uuid.Nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+
+	return unique
+}
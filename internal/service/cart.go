@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidCart          = errors.New("invalid cart")
+	ErrCartCurrencyMismatch = errors.New("cart has prices in different currencies")
+)
+
+// Cart is what the user buys with one order, either a bundle or several courses.
+type Cart struct {
+	CourseIDs []uuid.UUID
+	BundleID  *uuid.UUID
+}
+
+type CartItem struct {
+	CourseID uuid.UUID `json:"courseId"`
+	Title    string    `json:"title"`
+	// Price is the list price of the course and Amount the part of the order total paid for it.
+	Price  int64 `json:"price"`
+	Amount int64 `json:"amount"`
+} // @name CartItem
+
+type CartQuote struct {
+	BundleID   *uuid.UUID `json:"bundleId,omitempty"`
+	Items      []CartItem `json:"items"`
+	Price      int64      `json:"price"`
+	Discount   int64      `json:"discount"`
+	Total      int64      `json:"total"`
+	Currency   string     `json:"currency"`
+	CouponCode string     `json:"couponCode,omitempty"`
+
+	couponID    *uuid.UUID
+	description string
+} // @name CartQuote
+
+// QuoteCart prices the cart for the user. A bundle costs its own price and can be bought unless the
+// user already has every course in it; courses cost the sum of their prices, have to share a
+// currency and must not be paid already. The total is split over the items in proportion to their
+// prices, except that a course coupon only lowers the amount of its course.
+func (s *PaymentService) QuoteCart(userID uuid.UUID, cart Cart, couponCode string) (*CartQuote, error) {
+	courseIDs := uniqueIDs(cart.CourseIDs)
+
+	var bundle *entity.Bundle
+	switch {
+	case cart.BundleID != nil && len(courseIDs) > 0:
+		return nil, fmt.Errorf("payment service quote cart error: %w, buy either a bundle or courses", ErrInvalidCart)
+	case cart.BundleID != nil:
+		found, err := s.bundleService.Get(*cart.BundleID)
+		if err != nil {
+			return nil, fmt.Errorf("payment service quote cart error: %w", err)
+		}
+		bundle = found
+		courseIDs = bundle.CourseIDs
+	case len(courseIDs) == 0:
+		return nil, fmt.Errorf("payment service quote cart error: %w, cart is empty", ErrInvalidCart)
+	}
+
+	quote := &CartQuote{Items: make([]CartItem, 0, len(courseIDs))}
+	titles := make([]string, 0, len(courseIDs))
+	owned := 0
+
+	for _, courseID := range courseIDs {
+		courses, err := s.courseRepo.Read(entity.Pagination{Limit: 1}, entity.CourseFilters{ID: courseID})
+		if err != nil {
+			return nil, fmt.Errorf("payment service quote cart error: %v", err)
+		}
+		if len(courses) == 0 {
+			return nil, fmt.Errorf("payment service quote cart error: %w: %v", ErrCourseNotFound, courseID)
+		}
+		course := courses[0]
+
+		if quote.Currency == "" {
+			quote.Currency = course.Currency
+		}
+		if bundle == nil && course.Currency != quote.Currency {
+			return nil, fmt.Errorf("payment service quote cart error: %w, %v and %v", ErrCartCurrencyMismatch, quote.Currency, course.Currency)
+		}
+
+		paid, err := s.enrollmentService.Owns(userID, course.ID)
+		if err != nil {
+			return nil, fmt.Errorf("payment service quote cart error: %v", err)
+		}
+		if paid {
+			if bundle == nil {
+				return nil, fmt.Errorf("payment service quote cart error: %w: %v", ErrCourseAlreadyPaid, course.Title)
+			}
+			owned++
+		}
+
+		quote.Items = append(quote.Items, CartItem{CourseID: course.ID, Title: course.Title, Price: course.Price})
+		quote.Price += course.Price
+		titles = append(titles, course.Title)
+	}
+	quote.description = strings.Join(titles, ", ")
+
+	coursePrices := make(map[uuid.UUID]int64, len(quote.Items))
+	for _, item := range quote.Items {
+		coursePrices[item.CourseID] = item.Price
+	}
+
+	if bundle != nil {
+		if owned == len(courseIDs) {
+			return nil, fmt.Errorf("payment service quote cart error: %w, every course of the bundle is paid", ErrCourseAlreadyPaid)
+		}
+		quote.BundleID = &bundle.ID
+		quote.Price = bundle.Price
+		quote.Currency = bundle.Currency
+		quote.description = bundle.Title
+		coursePrices = nil
+	}
+
+	discount, coupon, err := s.couponService.Discount(userID, couponCode, quote.Price, quote.Currency, coursePrices)
+	if err != nil {
+		return nil, fmt.Errorf("payment service quote cart error: %w", err)
+	}
+	quote.Discount = discount
+	quote.Total = quote.Price - discount
+
+	if coupon != nil {
+		quote.CouponCode = coupon.Code
+		quote.couponID = &coupon.ID
+	}
+
+	if coupon != nil && coupon.CourseID != nil {
+		for i, item := range quote.Items {
+			quote.Items[i].Amount = item.Price
+			if item.CourseID == *coupon.CourseID {
+				quote.Items[i].Amount -= discount
+			}
+		}
+	} else {
+		weights := make([]int64, 0, len(quote.Items))
+		for _, item := range quote.Items {
+			weights = append(weights, item.Price)
+		}
+		for i, amount := range allocate(quote.Total, weights) {
+			quote.Items[i].Amount = amount
+		}
+	}
+
+	return quote, nil
+}
+
+// Checkout creates one order for everything in the cart, see QuoteCart for how it is priced.
+func (s *PaymentService) Checkout(ctx context.Context, userID uuid.UUID, cart Cart, couponCode string) (*Order, error) {
+	user, err := s.buyer(userID)
+	if err != nil {
+		return nil, fmt.Errorf("payment service checkout error: %w", err)
+	}
+
+	quote, err := s.QuoteCart(userID, cart, couponCode)
+	if err != nil {
+		return nil, fmt.Errorf("payment service checkout error: %w", err)
+	}
+
+	order, err := s.placeOrder(ctx, user, &Order{}, quote)
+	if err != nil {
+		return nil, fmt.Errorf("payment service checkout error: %v", err)
+	}
+
+	return order, nil
+}
+
+// allocate splits the total in proportion to the weights, the rounding remainder goes to the last
+// part. Equal weights are used when they are all zero.
+func allocate(total int64, weights []int64) []int64 {
+	parts := make([]int64, len(weights))
+	if len(weights) == 0 {
+		return parts
+	}
+
+	var sum int64
+	for _, weight := range weights {
+		sum += weight
+	}
+
+	var allocated int64
+	for i, weight := range weights {
+		if sum == 0 {
+			parts[i] = total / int64(len(weights))
+		} else {
+			parts[i] = total * weight / sum
+		}
+		allocated += parts[i]
+	}
+	parts[len(parts)-1] += total - allocated
+
+	return parts
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/AlnurZhanibek/kazusa-server/internal/document"
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/AlnurZhanibek/kazusa-server/internal/repository"
+	"github.com/google/uuid"
+)
+
+const CourseCompletedTemplate = "course_completed"
+
+// serialAlphabet leaves out 0, 1, I and O so serials can be typed in from a printout.
+const serialAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+type CertificateService struct {
+	repo         *repository.CertificateRepository
+	activityRepo *repository.ActivityRepository
+	userRepo     *repository.UserRepository
+	courseRepo   repository.CourseRepositoryImplementation
+	fileService  *FileService
+	notifier     Notifier
+}
+
+func NewCertificateService(repo *repository.CertificateRepository, activityRepo *repository.ActivityRepository, userRepo *repository.UserRepository, courseRepo repository.CourseRepositoryImplementation, fileService *FileService, notifier Notifier) *CertificateService {
+	return &CertificateService{repo: repo, activityRepo: activityRepo, userRepo: userRepo, courseRepo: courseRepo, fileService: fileService, notifier: notifier}
+}
+
+type Certificate struct {
+	Serial      string    `json:"serial"`
+	UserID      uuid.UUID `json:"userId"`
+	CourseID    uuid.UUID `json:"courseId"`
+	LearnerName string    `json:"learnerName"`
+	CourseTitle string    `json:"courseTitle"`
+	IssuedAt    time.Time `json:"issuedAt"`
+	FileURL     string    `json:"fileUrl"`
+} // @name Certificate
+
+// IssueIfCompleted issues the certificate once the user has completed every module of the course.
+// It returns nil when the course is not completed yet.
+func (s *CertificateService) IssueIfCompleted(ctx context.Context, userID uuid.UUID, courseID uuid.UUID) (*Certificate, error) {
+	completed, total, err := s.activityRepo.Progress(userID, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("certificate service issue error: %v", err)
+	}
+	if total == 0 || completed < total {
+		return nil, nil
+	}
+
+	return s.Issue(ctx, userID, courseID)
+}
+
+// Issue renders and stores the certificate of the user for the course. Issuing is idempotent,
+// an already issued certificate is returned as is.
+func (s *CertificateService) Issue(ctx context.Context, userID uuid.UUID, courseID uuid.UUID) (*Certificate, error) {
+	existing, err := s.repo.Read(&repository.CertificateFilters{
+		UserID:   &userID,
+		CourseID: &courseID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("certificate service issue error: %v", err)
+	}
+	if len(existing) != 0 {
+		return toCertificate(existing[0]), nil
+	}
+
+	users, err := s.userRepo.Read(entity.Pagination{Limit: 1}, entity.UserFilters{ID: &userID})
+	if err != nil {
+		return nil, fmt.Errorf("certificate service issue error: %v", err)
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("certificate service issue error: user not found")
+	}
+	user := users[0]
+
+	courses, err := s.courseRepo.Read(entity.Pagination{Limit: 1}, entity.CourseFilters{ID: courseID})
+	if err != nil {
+		return nil, fmt.Errorf("certificate service issue error: %v", err)
+	}
+	if len(courses) == 0 {
+		return nil, fmt.Errorf("certificate service issue error: course not found")
+	}
+	course := courses[0]
+
+	serial, err := newSerial("KZU", time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("certificate service issue error generating serial: %v", err)
+	}
+
+	issuedAt := time.Now()
+	content, err := document.Certificate(document.CertificateData{
+		Serial:      serial,
+		LearnerName: user.Name,
+		CourseTitle: course.Title,
+		IssuedAt:    issuedAt,
+		VerifyURL:   verifyURL(serial),
+		Locale:      user.Locale,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("certificate service issue error: %v", err)
+	}
+
+	fileURL, err := s.fileService.Put(ctx, "certificates/"+serial+".pdf", bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("certificate service issue error uploading pdf: %v", err)
+	}
+
+	err = s.repo.Create(&repository.CertificateCreateBody{
+		Serial:      serial,
+		UserID:      userID,
+		CourseID:    courseID,
+		LearnerName: user.Name,
+		CourseTitle: course.Title,
+		FileURL:     *fileURL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("certificate service issue error: %v", err)
+	}
+
+	certificate := &Certificate{
+		Serial:      serial,
+		UserID:      userID,
+		CourseID:    courseID,
+		LearnerName: user.Name,
+		CourseTitle: course.Title,
+		IssuedAt:    issuedAt,
+		FileURL:     *fileURL,
+	}
+
+	err = s.notifier.Notify(ctx, Notification{
+		To:       user.Email,
+		Locale:   user.Locale,
+		Template: CourseCompletedTemplate,
+		Data: map[string]any{
+			"Name":       user.Name,
+			"CourseName": course.Title,
+			"Link":       certificate.FileURL,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("certificate service issue error sending notification: %v", err)
+	}
+
+	return certificate, nil
+}
+
+// Verify returns the certificate with the given serial, or nil if there is no such certificate.
+func (s *CertificateService) Verify(serial string) (*Certificate, error) {
+	certificates, err := s.repo.Read(&repository.CertificateFilters{
+		Serial: &serial,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("certificate service verify error: %v", err)
+	}
+	if len(certificates) == 0 {
+		return nil, nil
+	}
+
+	return toCertificate(certificates[0]), nil
+}
+
+func (s *CertificateService) Read(userID uuid.UUID) ([]Certificate, error) {
+	repoCertificates, err := s.repo.Read(&repository.CertificateFilters{
+		UserID: &userID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("certificate service read error: %v", err)
+	}
+
+	certificates := make([]Certificate, 0, len(repoCertificates))
+	for _, repoCertificate := range repoCertificates {
+		certificates = append(certificates, *toCertificate(repoCertificate))
+	}
+
+	return certificates, nil
+}
+
+func toCertificate(certificate repository.Certificate) *Certificate {
+	return &Certificate{
+		Serial:      certificate.Serial,
+		UserID:      certificate.UserID,
+		CourseID:    certificate.CourseID,
+		LearnerName: certificate.LearnerName,
+		CourseTitle: certificate.CourseTitle,
+		IssuedAt:    certificate.CreatedAt,
+		FileURL:     certificate.FileURL,
+	}
+}
+
+// newSerial returns a serial such as KZU-2026-7KQ2M9XD, the prefix tells what document it is for.
+func newSerial(prefix string, now time.Time) (string, error) {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	for i := range b {
+		b[i] = serialAlphabet[int(b[i])%len(serialAlphabet)]
+	}
+
+	return fmt.Sprintf("%v-%v-%s", prefix, now.Year(), b), nil
+}
+
+func verifyURL(serial string) string {
+	return fmt.Sprintf("%v/certificate/verify/%v", os.Getenv("APP_URL"), serial)
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/google/uuid"
+)
+
+type ContextKey string
+
+const (
+	userIDCtxKey ContextKey = "userId"
+	roleCtxKey   ContextKey = "role"
+)
+
+func ContextWithClaims(ctx context.Context, claims *Claims) context.Context {
+	ctx = context.WithValue(ctx, userIDCtxKey, claims.UserID)
+	ctx = context.WithValue(ctx, roleCtxKey, claims.Role)
+	return ctx
+}
+
+func UserIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	userID, ok := ctx.Value(userIDCtxKey).(uuid.UUID)
+	return userID, ok && userID != uuid.Nil
+}
+
+func RoleFromContext(ctx context.Context) (entity.Role, bool) {
+	role, ok := ctx.Value(roleCtxKey).(entity.Role)
+	return role, ok
+}
+
+func IsAdmin(ctx context.Context) bool {
+	role, ok := RoleFromContext(ctx)
+	return ok && role == entity.AdminRole
+}
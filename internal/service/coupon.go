@@ -0,0 +1,177 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/AlnurZhanibek/kazusa-server/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCouponNotFound      = errors.New("coupon not found")
+	ErrCouponNotApplicable = errors.New("coupon can not be applied")
+	ErrCouponExhausted     = errors.New("coupon has been used up")
+	ErrInvalidCoupon       = errors.New("invalid coupon")
+)
+
+type CouponService struct {
+	repo       *repository.CouponRepository
+	courseRepo repository.CourseRepositoryImplementation
+}
+
+func NewCouponService(repo *repository.CouponRepository, courseRepo repository.CourseRepositoryImplementation) *CouponService {
+	return &CouponService{repo: repo, courseRepo: courseRepo}
+}
+
+func (s *CouponService) Create(coupon entity.NewCoupon) error {
+	coupon.Code = normalizeCouponCode(coupon.Code)
+
+	switch {
+	case coupon.Code == "":
+		return fmt.Errorf("coupon service create error: %w, code is empty", ErrInvalidCoupon)
+	case coupon.DiscountType != entity.PercentDiscount && coupon.DiscountType != entity.FixedDiscount:
+		return fmt.Errorf("coupon service create error: %w, unknown discount type %q", ErrInvalidCoupon, coupon.DiscountType)
+	case coupon.DiscountValue <= 0 || (coupon.DiscountType == entity.PercentDiscount && coupon.DiscountValue > 100):
+		return fmt.Errorf("coupon service create error: %w, discount value is out of range", ErrInvalidCoupon)
+	case coupon.StartsAt != nil && coupon.EndsAt != nil && !coupon.EndsAt.After(*coupon.StartsAt):
+		return fmt.Errorf("coupon service create error: %w, coupon ends before it starts", ErrInvalidCoupon)
+	}
+
+	err := s.repo.Create(coupon)
+	if err != nil {
+		return fmt.Errorf("coupon service create error: %v", err)
+	}
+
+	return nil
+}
+
+func (s *CouponService) Read(pagination entity.Pagination) ([]entity.Coupon, error) {
+	coupons, err := s.repo.Read(pagination, repository.CouponFilters{})
+	if err != nil {
+		return nil, fmt.Errorf("coupon service read error: %v", err)
+	}
+
+	return coupons, nil
+}
+
+// Delete deactivates the coupon, it stays referenced by the payments it was used for.
+func (s *CouponService) Delete(id uuid.UUID) error {
+	err := s.repo.Deactivate(id)
+	if err != nil {
+		return fmt.Errorf("coupon service delete error: %v", err)
+	}
+
+	return nil
+}
+
+type Quote struct {
+	CourseID   uuid.UUID `json:"courseId"`
+	Price      int64     `json:"price"`
+	Discount   int64     `json:"discount"`
+	Total      int64     `json:"total"`
+	Currency   string    `json:"currency"`
+	CouponCode string    `json:"couponCode,omitempty"`
+
+	couponID *uuid.UUID
+} // @name Quote
+
+// Preview returns the price the user would pay for the course with the coupon.
+func (s *CouponService) Preview(userID uuid.UUID, courseID uuid.UUID, code string) (*Quote, error) {
+	courses, err := s.courseRepo.Read(entity.Pagination{Limit: 1}, entity.CourseFilters{ID: courseID})
+	if err != nil {
+		return nil, fmt.Errorf("coupon service preview error: %v", err)
+	}
+	if len(courses) == 0 {
+		return nil, fmt.Errorf("coupon service preview error: %w", ErrCourseNotFound)
+	}
+
+	return s.Quote(userID, courses[0], code)
+}
+
+// Quote prices the course for the user, applying the coupon if the code is not empty.
+func (s *CouponService) Quote(userID uuid.UUID, course repository.Course, code string) (*Quote, error) {
+	quote := &Quote{
+		CourseID: course.ID,
+		Price:    course.Price,
+		Total:    course.Price,
+		Currency: course.Currency,
+	}
+
+	discount, coupon, err := s.Discount(userID, code, course.Price, course.Currency, map[uuid.UUID]int64{course.ID: course.Price})
+	if err != nil {
+		return nil, fmt.Errorf("coupon service quote error: %w", err)
+	}
+	if coupon == nil {
+		return quote, nil
+	}
+
+	quote.Discount = discount
+	quote.Total = course.Price - discount
+	quote.CouponCode = coupon.Code
+	quote.couponID = &coupon.ID
+
+	return quote, nil
+}
+
+// Discount returns how much the coupon takes off an order of the given total and the coupon itself,
+// or no coupon if the code is empty. coursePrices lists the courses of the order with their prices,
+// a course coupon only discounts the price of its course; it is nil for bundles, which only take
+// sitewide coupons. The coupon has to be active, within its validity window, and below its global
+// and per user limits; placed orders count as redemptions until they fail or are cancelled. The
+// limits are checked again when the order is added, so concurrent orders cannot exceed them.
+func (s *CouponService) Discount(userID uuid.UUID, code string, total int64, currency string, coursePrices map[uuid.UUID]int64) (int64, *entity.Coupon, error) {
+	code = normalizeCouponCode(code)
+	if code == "" {
+		return 0, nil, nil
+	}
+
+	coupons, err := s.repo.Read(entity.Pagination{Limit: 1}, repository.CouponFilters{Code: &code})
+	if err != nil {
+		return 0, nil, fmt.Errorf("coupon service discount error: %v", err)
+	}
+	if len(coupons) == 0 || !coupons[0].Active {
+		return 0, nil, fmt.Errorf("coupon service discount error: %w", ErrCouponNotFound)
+	}
+	coupon := coupons[0]
+
+	price := total
+	if coupon.CourseID != nil {
+		coursePrice, ok := coursePrices[*coupon.CourseID]
+		if !ok {
+			return 0, nil, fmt.Errorf("coupon service discount error: %w, coupon is for another course", ErrCouponNotApplicable)
+		}
+		price = coursePrice
+	}
+
+	now := time.Now()
+	switch {
+	case coupon.StartsAt != nil && now.Before(*coupon.StartsAt):
+		return 0, nil, fmt.Errorf("coupon service discount error: %w, coupon is not valid yet", ErrCouponNotApplicable)
+	case coupon.EndsAt != nil && now.After(*coupon.EndsAt):
+		return 0, nil, fmt.Errorf("coupon service discount error: %w, coupon has expired", ErrCouponNotApplicable)
+	case coupon.DiscountType == entity.FixedDiscount && coupon.CourseID == nil && currency != entity.DefaultCurrency:
+		return 0, nil, fmt.Errorf("coupon service discount error: %w, fixed sitewide coupons are only for %v prices", ErrCouponNotApplicable, entity.DefaultCurrency)
+	case coupon.MaxRedemptions != nil && coupon.Redemptions >= *coupon.MaxRedemptions:
+		return 0, nil, fmt.Errorf("coupon service discount error: %w", ErrCouponExhausted)
+	}
+
+	if coupon.MaxPerUser != nil {
+		used, err := s.repo.UserRedemptions(coupon.ID, userID)
+		if err != nil {
+			return 0, nil, fmt.Errorf("coupon service discount error: %v", err)
+		}
+		if used >= *coupon.MaxPerUser {
+			return 0, nil, fmt.Errorf("coupon service discount error: %w", ErrCouponExhausted)
+		}
+	}
+
+	return coupon.Discount(price), &coupon, nil
+}
+
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
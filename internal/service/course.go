@@ -2,10 +2,12 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"mime/multipart"
-	"path/filepath"
-	"strings"
+	"os"
+	"time"
 
 	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
 	"github.com/AlnurZhanibek/kazusa-server/internal/repository"
@@ -17,17 +19,31 @@ type CourseServiceImplementation interface {
 	Read(ctx context.Context, pagination entity.Pagination, filters entity.CourseFilters) ([]entity.Course, error)
 	Update(body entity.CourseUpdateBody) (bool, error)
 	Delete(id uuid.UUID) (bool, error)
+	Attachment(ctx context.Context, courseID uuid.UUID, n int) (string, error)
 }
 
+var ErrAttachmentNotFound = errors.New("attachment not found")
+
+// defaultAttachmentTTL is how long a presigned attachment URL works, long enough to start the
+// download and short enough that a shared link is useless.
+const defaultAttachmentTTL = 5 * time.Minute
+
 type CourseService struct {
-	repo           repository.CourseRepositoryImplementation
-	moduleService  ModuleServiceImplementation
-	fileService    *FileService
-	paymentService *PaymentService
+	repo              repository.CourseRepositoryImplementation
+	moduleService     ModuleServiceImplementation
+	fileService       *FileService
+	enrollmentService *EnrollmentService
+	attachmentTTL     time.Duration
 }
 
-func NewCourseService(repo repository.CourseRepositoryImplementation, moduleService ModuleServiceImplementation, fileService *FileService, paymentService *PaymentService) *CourseService {
-	return &CourseService{repo: repo, moduleService: moduleService, fileService: fileService, paymentService: paymentService}
+func NewCourseService(repo repository.CourseRepositoryImplementation, moduleService ModuleServiceImplementation, fileService *FileService, enrollmentService *EnrollmentService) *CourseService {
+	return &CourseService{
+		repo:              repo,
+		moduleService:     moduleService,
+		fileService:       fileService,
+		enrollmentService: enrollmentService,
+		attachmentTTL:     durationFromEnv("ATTACHMENT_URL_TTL", defaultAttachmentTTL),
+	}
 }
 
 type FileWithHeader struct {
@@ -39,6 +55,7 @@ type CourseCreateBody struct {
 	Title       string
 	Description string
 	Price       int64
+	Currency    string
 	Cover       FileWithHeader
 	Attachments []FileWithHeader
 }
@@ -46,30 +63,29 @@ type CourseCreateBody struct {
 func (s *CourseService) Create(course CourseCreateBody) (bool, error) {
 
 	ctx := context.Background()
-	coverFilename := strings.ToLower(strings.ReplaceAll(course.Title, " ", "-")) + "-cover" + filepath.Ext(course.Cover.Header.Filename)
+	courseID := uuid.New()
 
-	coverUrl, err := s.fileService.Put(ctx, coverFilename, course.Cover.File)
+	coverURL, err := s.fileService.PutCourseCover(ctx, courseID, course.Cover.Header.Filename, course.Cover.File)
 	if err != nil {
 		return false, fmt.Errorf("course service create error: uploading cover image")
 	}
 
-	attachmentURLs := make([]string, len(course.Attachments))
+	attachmentKeys := make([]string, len(course.Attachments))
 	for i, attachment := range course.Attachments {
-		filename := strings.ToLower(strings.ReplaceAll(attachment.Header.Filename, " ", "-"))
-		var url *string
-		url, err = s.fileService.Put(ctx, filename, attachment.File)
+		attachmentKeys[i], err = s.fileService.PutCourseAttachment(ctx, courseID, attachment.Header.Filename, attachment.File)
 		if err != nil {
 			return false, fmt.Errorf("course service create error: uploading attachment")
 		}
-		attachmentURLs[i] = *url
 	}
 
 	ok, err := s.repo.Create(repository.CourseCreateBody{
-		Title:          course.Title,
-		Description:    course.Description,
-		Price:          course.Price,
-		CoverURL:       *coverUrl,
-		AttachmentURLs: attachmentURLs,
+		ID:          courseID,
+		Title:       course.Title,
+		Description: course.Description,
+		Price:       course.Price,
+		Currency:    course.Currency,
+		CoverURL:    coverURL,
+		Attachments: attachmentKeys,
 	})
 
 	if err != nil {
@@ -87,6 +103,11 @@ func (s *CourseService) Read(ctx context.Context, pagination entity.Pagination,
 
 	courses := make([]entity.Course, len(repoCourses))
 	for i, repoCourse := range repoCourses {
+		attachmentURLs, err := attachmentLinks(repoCourse)
+		if err != nil {
+			return nil, fmt.Errorf("course service read error: %v", err)
+		}
+
 		courses[i] = entity.Course{
 			ID:             repoCourse.ID,
 			CreatedAt:      repoCourse.CreatedAt,
@@ -94,8 +115,9 @@ func (s *CourseService) Read(ctx context.Context, pagination entity.Pagination,
 			Title:          repoCourse.Title,
 			Description:    repoCourse.Description,
 			Price:          repoCourse.Price,
+			Currency:       repoCourse.Currency,
 			CoverURL:       repoCourse.CoverURL,
-			AttachmentURLs: repoCourse.AttachmentURLs.String,
+			AttachmentURLs: attachmentURLs,
 			Modules:        nil,
 		}
 	}
@@ -111,26 +133,74 @@ func (s *CourseService) Read(ctx context.Context, pagination entity.Pagination,
 
 		courses[0].Modules = &modules
 
-		userIDCtx := ctx.Value("user_id")
-		if userIDCtx != nil {
-			userID := userIDCtx.(uuid.UUID)
-			payment := &Payment{}
-			payment, _ = s.paymentService.Read(PaymentFilters{
-				UserID:   &userID,
-				CourseID: &filters.ID,
-			})
-
-			if payment != nil {
-				courses[0].IsPaid = true
-			} else {
-				courses[0].IsPaid = false
-			}
+		userID, hasUser := UserIDFromContext(ctx)
+		if hasUser {
+			enrolled, _ := s.enrollmentService.HasAccess(userID, courses[0].ID)
+			courses[0].IsPaid = enrolled
 		}
 	}
 
 	return courses, nil
 }
 
+// attachmentLinks lists the attachments of the course as links to the attachment endpoint, in the
+// same JSON shape the attachment_urls column has, so the stored keys never reach the client.
+func attachmentLinks(course repository.Course) (string, error) {
+	attachments, err := course.Attachments()
+	if err != nil || attachments == nil {
+		return course.AttachmentURLs.String, err
+	}
+
+	links := make([]string, len(attachments))
+	for n := range attachments {
+		links[n] = fmt.Sprintf("%v/course/%v/attachments/%v", os.Getenv("APP_URL"), course.ID, n)
+	}
+
+	content, err := json.Marshal(map[string][]string{"attachment_urls": links})
+	if err != nil {
+		return "", err
+	}
+
+	return string(content), nil
+}
+
+// Attachment returns a short lived URL of the nth attachment of the course, counting from zero, to
+// admins and users with access to the course.
+func (s *CourseService) Attachment(ctx context.Context, courseID uuid.UUID, n int) (string, error) {
+	courses, err := s.repo.Read(entity.Pagination{Limit: 1}, entity.CourseFilters{ID: courseID})
+	if err != nil {
+		return "", fmt.Errorf("course service attachment error: %v", err)
+	}
+	if len(courses) == 0 {
+		return "", fmt.Errorf("course service attachment error: %w", ErrCourseNotFound)
+	}
+
+	attachments, err := courses[0].Attachments()
+	if err != nil {
+		return "", fmt.Errorf("course service attachment error: %v", err)
+	}
+	if n < 0 || n >= len(attachments) {
+		return "", fmt.Errorf("course service attachment error: %w", ErrAttachmentNotFound)
+	}
+
+	err = s.enrollmentService.CheckAccess(ctx, courseID)
+	if err != nil {
+		return "", fmt.Errorf("course service attachment error: %w", err)
+	}
+
+	key := attachments[n]
+	if publicKey, ok := s.fileService.Key(key); ok {
+		key = publicKey
+	}
+
+	url, err := s.fileService.PresignGet(ctx, key, s.attachmentTTL)
+	if err != nil {
+		return "", fmt.Errorf("course service attachment error: %v", err)
+	}
+
+	return url, nil
+}
+
 func (s *CourseService) Update(course entity.CourseUpdateBody) (bool, error) {
 	ok, err := s.repo.Update(course)
 	if err != nil {
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AlnurZhanibek/kazusa-server/internal/repository"
+	"github.com/google/uuid"
+)
+
+type DashboardService struct {
+	repo                *repository.DashboardRepository
+	subscriptionService *SubscriptionService
+}
+
+func NewDashboardService(repo *repository.DashboardRepository, subscriptionService *SubscriptionService) *DashboardService {
+	return &DashboardService{repo: repo, subscriptionService: subscriptionService}
+}
+
+type LearnerCourse struct {
+	CourseID         uuid.UUID   `json:"courseId"`
+	Title            string      `json:"title"`
+	CoverURL         string      `json:"coverUrl"`
+	CompletedModules int64       `json:"completedModules"`
+	TotalModules     int64       `json:"totalModules"`
+	CompletedMinutes int64       `json:"completedMinutes"`
+	TotalMinutes     int64       `json:"totalMinutes"`
+	Percent          int64       `json:"percent"`
+	LastActivityAt   *time.Time  `json:"lastActivityAt"`
+	NextModule       *NextModule `json:"nextModule"`
+} // @name LearnerCourse
+
+type NextModule struct {
+	ID    uuid.UUID `json:"id"`
+	Name  string    `json:"name"`
+	Order int64     `json:"order"`
+} // @name NextModule
+
+// Courses returns the courses the user has paid for together with their progress. Percent is
+// weighted by module duration so long modules count for more than short ones; courses without
+// durations fall back to the share of completed modules. NextModule is nil once a course is completed.
+// Courses opened with a subscription are left out once the subscription no longer grants access.
+func (s *DashboardService) Courses(userID uuid.UUID) ([]LearnerCourse, error) {
+	subscribed, err := s.subscriptionService.HasAccess(userID)
+	if err != nil {
+		return nil, fmt.Errorf("dashboard service courses error: %v", err)
+	}
+
+	repoCourses, err := s.repo.Courses(userID, subscribed)
+	if err != nil {
+		return nil, fmt.Errorf("dashboard service courses error: %v", err)
+	}
+
+	nextModules, err := s.repo.NextModules(userID, subscribed)
+	if err != nil {
+		return nil, fmt.Errorf("dashboard service courses error: %v", err)
+	}
+
+	courses := make([]LearnerCourse, 0, len(repoCourses))
+	for _, repoCourse := range repoCourses {
+		course := LearnerCourse{
+			CourseID:         repoCourse.CourseID,
+			Title:            repoCourse.Title,
+			CoverURL:         repoCourse.CoverURL,
+			CompletedModules: repoCourse.CompletedModules,
+			TotalModules:     repoCourse.TotalModules,
+			CompletedMinutes: repoCourse.CompletedMinutes,
+			TotalMinutes:     repoCourse.TotalMinutes,
+			Percent:          percent(repoCourse),
+		}
+		if repoCourse.LastActivityAt.Valid {
+			course.LastActivityAt = &repoCourse.LastActivityAt.Time
+		}
+		if module, ok := nextModules[repoCourse.CourseID]; ok {
+			course.NextModule = &NextModule{
+				ID:    module.ID,
+				Name:  module.Name,
+				Order: module.Order.Int64,
+			}
+		}
+		courses = append(courses, course)
+	}
+
+	return courses, nil
+}
+
+func percent(course repository.DashboardCourse) int64 {
+	if course.TotalMinutes > 0 {
+		return course.CompletedMinutes * 100 / course.TotalMinutes
+	}
+	if course.TotalModules > 0 {
+		return course.CompletedModules * 100 / course.TotalModules
+	}
+
+	return 0
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/AlnurZhanibek/kazusa-server/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	EmailVerificationTTL = 48 * time.Hour
+	// ResendInterval is the minimum time between two verification emails to the same user.
+	ResendInterval = time.Minute
+	// ResendDailyLimit caps how many verification emails a user can request per day.
+	ResendDailyLimit = 5
+)
+
+var (
+	ErrEmailNotVerified     = errors.New("email is not verified")
+	ErrEmailAlreadyVerified = errors.New("email is already verified")
+	ErrResendThrottled      = errors.New("verification email was sent recently, try again later")
+)
+
+type EmailVerificationService struct {
+	userRepo         *repository.UserRepository
+	verificationRepo *repository.EmailVerificationRepository
+	notifier         Notifier
+}
+
+func NewEmailVerificationService(userRepo *repository.UserRepository, verificationRepo *repository.EmailVerificationRepository, notifier Notifier) *EmailVerificationService {
+	return &EmailVerificationService{userRepo: userRepo, verificationRepo: verificationRepo, notifier: notifier}
+}
+
+func (s *EmailVerificationService) Send(ctx context.Context, user entity.User) error {
+	token, err := randomToken()
+	if err != nil {
+		return fmt.Errorf("email verification service send error generating token: %v", err)
+	}
+
+	err = s.verificationRepo.Create(&repository.EmailVerificationCreateBody{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(EmailVerificationTTL),
+	})
+	if err != nil {
+		return fmt.Errorf("email verification service send error: %v", err)
+	}
+
+	err = s.notifier.Notify(ctx, Notification{
+		To:       user.Email,
+		Locale:   user.Locale,
+		Template: VerifyEmailTemplate,
+		Data: map[string]any{
+			"Name":  user.Name,
+			"Link":  fmt.Sprintf("%v/verify-email?token=%v", os.Getenv("APP_URL"), token),
+			"Hours": int(EmailVerificationTTL.Hours()),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("email verification service send error sending notification: %v", err)
+	}
+
+	return nil
+}
+
+func (s *EmailVerificationService) Resend(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.readUser(userID)
+	if err != nil {
+		return fmt.Errorf("email verification service resend error: %w", err)
+	}
+	if user.EmailVerifiedAt != nil {
+		return fmt.Errorf("email verification service resend error: %w", ErrEmailAlreadyVerified)
+	}
+
+	stats, err := s.verificationRepo.Stats(userID, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		return fmt.Errorf("email verification service resend error: %v", err)
+	}
+	if stats.Count >= ResendDailyLimit || (stats.LastSentAt.Valid && time.Since(stats.LastSentAt.Time) < ResendInterval) {
+		return fmt.Errorf("email verification service resend error: %w", ErrResendThrottled)
+	}
+
+	return s.Send(ctx, *user)
+}
+
+func (s *EmailVerificationService) Verify(token string) error {
+	verification, err := s.verificationRepo.ReadByTokenHash(hashToken(token))
+	if err != nil {
+		return fmt.Errorf("email verification service verify error: %v", err)
+	}
+	if verification == nil || verification.UsedAt.Valid || time.Now().After(verification.ExpiresAt) {
+		return fmt.Errorf("email verification service verify error: token is invalid or expired")
+	}
+
+	used, err := s.verificationRepo.Use(verification.ID)
+	if err != nil {
+		return fmt.Errorf("email verification service verify error: %v", err)
+	}
+	if !used {
+		return fmt.Errorf("email verification service verify error: token is invalid or expired")
+	}
+
+	err = s.userRepo.VerifyEmail(verification.UserID)
+	if err != nil {
+		return fmt.Errorf("email verification service verify error: %v", err)
+	}
+
+	return nil
+}
+
+func (s *EmailVerificationService) IsVerified(userID uuid.UUID) (bool, error) {
+	user, err := s.readUser(userID)
+	if err != nil {
+		return false, fmt.Errorf("email verification service error: %v", err)
+	}
+
+	return user.EmailVerifiedAt != nil, nil
+}
+
+func (s *EmailVerificationService) readUser(userID uuid.UUID) (*entity.User, error) {
+	users, err := s.userRepo.Read(
+		entity.Pagination{
+			Offset: 0,
+			Limit:  1,
+		},
+		entity.UserFilters{
+			ID: &userID,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	return &users[0], nil
+}
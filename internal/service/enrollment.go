@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/AlnurZhanibek/kazusa-server/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCourseNotFree     = errors.New("course is not free")
+	ErrInvalidEnrollment = errors.New("invalid enrollment")
+	// ErrPaymentRequired and ErrEnrollmentRequired tell why the content of a course is withheld, the
+	// course has to be bought or, being free, only enrolled in.
+	ErrPaymentRequired    = errors.New("course has to be bought to access its content")
+	ErrEnrollmentRequired = errors.New("enroll in the course to access its content")
+)
+
+const (
+	EnrollAuditAction   = "enrollment.grant"
+	UnenrollAuditAction = "enrollment.revoke"
+)
+
+// maxBulkEnrollments limits how many user and course pairs one bulk request may change.
+const maxBulkEnrollments = 1000
+
+type EnrollmentService struct {
+	repo                *repository.EnrollmentRepository
+	courseRepo          repository.CourseRepositoryImplementation
+	auditRepo           *repository.AuditRepository
+	subscriptionService *SubscriptionService
+}
+
+func NewEnrollmentService(repo *repository.EnrollmentRepository, courseRepo repository.CourseRepositoryImplementation, auditRepo *repository.AuditRepository, subscriptionService *SubscriptionService) *EnrollmentService {
+	return &EnrollmentService{repo: repo, courseRepo: courseRepo, auditRepo: auditRepo, subscriptionService: subscriptionService}
+}
+
+// HasAccess reports whether the user can take the course, either through an enrollment or an
+// active subscription.
+func (s *EnrollmentService) HasAccess(userID uuid.UUID, courseID uuid.UUID) (bool, error) {
+	owned, err := s.Owns(userID, courseID)
+	if err != nil || owned {
+		return owned, err
+	}
+
+	subscribed, err := s.subscriptionService.HasAccess(userID)
+	if err != nil {
+		return false, fmt.Errorf("enrollment service has access error: %v", err)
+	}
+
+	return subscribed, nil
+}
+
+// recordStart records a course the user started through their subscription as a subscription
+// enrollment, so it shows up on their dashboard while the subscription lasts. Courses the user
+// owns are left as they are.
+func (s *EnrollmentService) recordStart(userID uuid.UUID, courseID uuid.UUID) {
+	owned, err := s.Owns(userID, courseID)
+	if err != nil || owned {
+		return
+	}
+
+	subscribed, err := s.subscriptionService.HasAccess(userID)
+	if err != nil || !subscribed {
+		return
+	}
+
+	_, err = s.repo.Create(&repository.EnrollmentCreateBody{UserID: userID, CourseID: courseID, Source: entity.SubscriptionEnrollment})
+	if err != nil {
+		log.Printf("enrollment service failed to record subscription enrollment of user %v in course %v: %v", userID, courseID, err)
+	}
+}
+
+// CheckAccess returns nil when the user in the context may see the content of the course, that is
+// an admin or a user with access to it. Otherwise it returns ErrPaymentRequired or
+// ErrEnrollmentRequired depending on the price of the course.
+func (s *EnrollmentService) CheckAccess(ctx context.Context, courseID uuid.UUID) error {
+	if IsAdmin(ctx) {
+		return nil
+	}
+
+	if userID, ok := UserIDFromContext(ctx); ok {
+		access, err := s.HasAccess(userID, courseID)
+		if err != nil {
+			return fmt.Errorf("enrollment service check access error: %v", err)
+		}
+		if access {
+			return nil
+		}
+	}
+
+	courses, err := s.courseRepo.Read(entity.Pagination{Limit: 1}, entity.CourseFilters{ID: courseID})
+	if err != nil {
+		return fmt.Errorf("enrollment service check access error: %v", err)
+	}
+	if len(courses) == 0 {
+		return fmt.Errorf("enrollment service check access error: %w", ErrCourseNotFound)
+	}
+	if courses[0].Price == 0 {
+		return fmt.Errorf("enrollment service check access error: %w", ErrEnrollmentRequired)
+	}
+
+	return fmt.Errorf("enrollment service check access error: %w", ErrPaymentRequired)
+}
+
+// Owns reports whether the user is enrolled in the course on its own, regardless of subscriptions.
+func (s *EnrollmentService) Owns(userID uuid.UUID, courseID uuid.UUID) (bool, error) {
+	owned, err := s.repo.Active(userID, courseID, entity.SubscriptionEnrollment)
+	if err != nil {
+		return false, fmt.Errorf("enrollment service owns error: %v", err)
+	}
+
+	return owned, nil
+}
+
+// EnrollFree enrolls the user in a course that costs nothing.
+func (s *EnrollmentService) EnrollFree(userID uuid.UUID, courseID uuid.UUID) error {
+	courses, err := s.courseRepo.Read(entity.Pagination{Limit: 1}, entity.CourseFilters{ID: courseID})
+	if err != nil {
+		return fmt.Errorf("enrollment service enroll free error: %v", err)
+	}
+	if len(courses) == 0 {
+		return fmt.Errorf("enrollment service enroll free error: %w", ErrCourseNotFound)
+	}
+	if courses[0].Price != 0 {
+		return fmt.Errorf("enrollment service enroll free error: %w", ErrCourseNotFree)
+	}
+
+	_, err = s.repo.Create(&repository.EnrollmentCreateBody{UserID: userID, CourseID: courseID, Source: entity.FreeEnrollment})
+	if err != nil {
+		return fmt.Errorf("enrollment service enroll free error: %v", err)
+	}
+
+	return nil
+}
+
+// enrollOrder enrolls the buyer of a paid order in its courses.
+func (s *EnrollmentService) enrollOrder(orderID uuid.UUID, userID uuid.UUID, courseIDs []uuid.UUID, source entity.EnrollmentSource) error {
+	for _, courseID := range courseIDs {
+		_, err := s.repo.Create(&repository.EnrollmentCreateBody{UserID: userID, CourseID: courseID, Source: source, OrderID: &orderID})
+		if err != nil {
+			return fmt.Errorf("enrollment service enroll order error: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// revokeOrder revokes the enrollments a refunded order gave.
+func (s *EnrollmentService) revokeOrder(orderID uuid.UUID) error {
+	err := s.repo.RevokeOrder(orderID)
+	if err != nil {
+		return fmt.Errorf("enrollment service revoke order error: %v", err)
+	}
+
+	return nil
+}
+
+// enroll records a single enrollment, such as one from a redeemed access code.
+func (s *EnrollmentService) enroll(enrollment *repository.EnrollmentCreateBody) error {
+	_, err := s.repo.Create(enrollment)
+	if err != nil {
+		return fmt.Errorf("enrollment service enroll error: %v", err)
+	}
+
+	return nil
+}
+
+type BulkEnrollment struct {
+	UserIDs   []uuid.UUID `json:"userIds"`
+	CourseIDs []uuid.UUID `json:"courseIds"`
+	Reason    string      `json:"reason"`
+} // @name BulkEnrollment
+
+type BulkEnrollmentResult struct {
+	// Changed is the number of user and course pairs that were enrolled or unenrolled, pairs
+	// already in the requested state are not counted.
+	Changed int `json:"changed"`
+} // @name BulkEnrollmentResult
+
+// Grant enrolls every user in every course as an admin grant. The admin is taken from the context
+// and the grant is written to the audit log.
+func (s *EnrollmentService) Grant(ctx context.Context, bulk BulkEnrollment) (*BulkEnrollmentResult, error) {
+	operatorID, userIDs, courseIDs, err := s.bulk(ctx, bulk)
+	if err != nil {
+		return nil, fmt.Errorf("enrollment service grant error: %w", err)
+	}
+
+	for _, courseID := range courseIDs {
+		courses, err := s.courseRepo.Read(entity.Pagination{Limit: 1}, entity.CourseFilters{ID: courseID})
+		if err != nil {
+			return nil, fmt.Errorf("enrollment service grant error: %v", err)
+		}
+		if len(courses) == 0 {
+			return nil, fmt.Errorf("enrollment service grant error: %w: %v", ErrCourseNotFound, courseID)
+		}
+	}
+
+	result := &BulkEnrollmentResult{}
+	for _, userID := range userIDs {
+		for _, courseID := range courseIDs {
+			changed, err := s.repo.Create(&repository.EnrollmentCreateBody{UserID: userID, CourseID: courseID, Source: entity.AdminEnrollment, GrantedBy: &operatorID})
+			if err != nil {
+				return nil, fmt.Errorf("enrollment service grant error after %v enrollments: %v", result.Changed, err)
+			}
+			if changed {
+				result.Changed++
+			}
+		}
+	}
+
+	s.audit(operatorID, EnrollAuditAction, userIDs, courseIDs, bulk.Reason)
+
+	return result, nil
+}
+
+// Revoke unenrolls every user from every course whatever the enrollment came from. Payments are not
+// refunded, see PaymentService.Refund for that.
+func (s *EnrollmentService) Revoke(ctx context.Context, bulk BulkEnrollment) (*BulkEnrollmentResult, error) {
+	operatorID, userIDs, courseIDs, err := s.bulk(ctx, bulk)
+	if err != nil {
+		return nil, fmt.Errorf("enrollment service revoke error: %w", err)
+	}
+
+	result := &BulkEnrollmentResult{}
+	for _, userID := range userIDs {
+		for _, courseID := range courseIDs {
+			changed, err := s.repo.Revoke(userID, courseID)
+			if err != nil {
+				return nil, fmt.Errorf("enrollment service revoke error after %v unenrollments: %v", result.Changed, err)
+			}
+			if changed {
+				result.Changed++
+			}
+		}
+	}
+
+	s.audit(operatorID, UnenrollAuditAction, userIDs, courseIDs, bulk.Reason)
+
+	return result, nil
+}
+
+func (s *EnrollmentService) bulk(ctx context.Context, bulk BulkEnrollment) (uuid.UUID, []uuid.UUID, []uuid.UUID, error) {
+	operatorID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return uuid.Nil, nil, nil, fmt.Errorf("operator is unknown")
+	}
+
+	userIDs := uniqueIDs(bulk.UserIDs)
+	courseIDs := uniqueIDs(bulk.CourseIDs)
+	switch {
+	case len(userIDs) == 0 || len(courseIDs) == 0:
+		return uuid.Nil, nil, nil, fmt.Errorf("%w, users and courses are required", ErrInvalidEnrollment)
+	case len(userIDs)*len(courseIDs) > maxBulkEnrollments:
+		return uuid.Nil, nil, nil, fmt.Errorf("%w, at most %v enrollments can be changed at once", ErrInvalidEnrollment, maxBulkEnrollments)
+	}
+
+	return operatorID, userIDs, courseIDs, nil
+}
+
+// audit writes an entry per course, so the audit log of a course shows who was enrolled in it.
+func (s *EnrollmentService) audit(operatorID uuid.UUID, action string, userIDs []uuid.UUID, courseIDs []uuid.UUID, reason string) {
+	for _, courseID := range courseIDs {
+		err := s.auditRepo.Create(&repository.AuditEntry{
+			ActorID:    operatorID,
+			Action:     action,
+			EntityType: "course",
+			EntityID:   courseID.String(),
+			Details: map[string]any{
+				"userIds": userIDs,
+				"reason":  reason,
+			},
+		})
+		if err != nil {
+			log.Printf("enrollment service failed to audit %v of course %v by %v: %v", action, courseID, operatorID, err)
+		}
+	}
+}
+
+func (s *EnrollmentService) Read(pagination entity.Pagination, filters repository.EnrollmentFilters) ([]entity.Enrollment, error) {
+	enrollments, err := s.repo.Read(pagination, filters)
+	if err != nil {
+		return nil, fmt.Errorf("enrollment service read error: %v", err)
+	}
+
+	return enrollments, nil
+}
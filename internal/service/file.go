@@ -3,48 +3,221 @@ package service
 import (
 	"context"
 	"fmt"
-	"mime/multipart"
-	"os"
+	"io"
+	"log"
+	"mime"
+	"path/filepath"
+	"strings"
+	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/AlnurZhanibek/kazusa-server/internal/repository"
+	"github.com/AlnurZhanibek/kazusa-server/internal/storage"
+	"github.com/google/uuid"
 )
 
+const (
+	FileGCLockName = "kazusa.file_gc"
+
+	CourseFileOwner       = "course"
+	CoverFilePurpose      = "cover"
+	AttachmentFilePurpose = "attachment"
+
+	fileGCBatch            = 100
+	defaultFileGCInterval  = time.Hour
+	defaultFileGCGraceTime = 24 * time.Hour
+)
+
+// FileService stores files and keeps track of the course files in the files table. Course files are
+// recorded before they are uploaded, so none goes unnoticed if the request fails halfway. Run deletes
+// the files that were released and the files of courses that no longer exist, once they have been
+// around for FILE_GC_GRACE. Only the replica holding the leader lock runs the collection.
 type FileService struct {
-	client *s3.Client
-	bucket string
+	storage  storage.Storage
+	repo     *repository.FileRepository
+	lock     *repository.LeaderLock
+	interval time.Duration
+	grace    time.Duration
+}
+
+func NewFileService(storage storage.Storage, repo *repository.FileRepository, lock *repository.LeaderLock) *FileService {
+	return &FileService{
+		storage:  storage,
+		repo:     repo,
+		lock:     lock,
+		interval: durationFromEnv("FILE_GC_INTERVAL", defaultFileGCInterval),
+		grace:    durationFromEnv("FILE_GC_GRACE", defaultFileGCGraceTime),
+	}
+}
+
+// Put uploads a public file, such as a certificate, and returns its URL. The file is not tracked, the
+// key has to be unique on its own.
+func (fs *FileService) Put(ctx context.Context, key string, r io.Reader) (*string, error) {
+	err := fs.storage.Put(ctx, key, r, contentType(key))
+
+	fileURL := fs.storage.PublicURL(key)
+
+	return &fileURL, err
 }
 
-func NewFileService(ctx context.Context) (*FileService, error) {
-	region := os.Getenv("AWS_REGION")
-	bucket := os.Getenv("S3_BUCKET")
+// PutPrivate uploads a private file, such as a receipt, under PrivatePrefix and returns its key, see
+// PresignGet to serve it. The file is not tracked, the key has to be unique on its own.
+func (fs *FileService) PutPrivate(ctx context.Context, key string, r io.Reader) (string, error) {
+	key = storage.PrivatePrefix + key
 
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	err := fs.storage.Put(ctx, key, r, contentType(key))
 	if err != nil {
-		return nil, fmt.Errorf("load AWS config: %w", err)
+		return "", fmt.Errorf("file service put private error: %v", err)
 	}
 
-	client := s3.NewFromConfig(cfg)
+	return key, nil
+}
 
-	return &FileService{
-		client: client,
-		bucket: bucket,
-	}, nil
+// PutCourseCover uploads the cover of the course and returns its public URL.
+func (fs *FileService) PutCourseCover(ctx context.Context, courseID uuid.UUID, filename string, r io.Reader) (string, error) {
+	key := fmt.Sprintf("courses/%v/cover/%v%v", courseID, uuid.NewString(), strings.ToLower(filepath.Ext(filename)))
+
+	err := fs.putCourseFile(ctx, courseID, CoverFilePurpose, key, r)
+	if err != nil {
+		return "", fmt.Errorf("file service put course cover error: %v", err)
+	}
+
+	return fs.storage.PublicURL(key), nil
 }
 
-func (fs *FileService) Put(ctx context.Context, key string, r multipart.File) (*string, error) {
-	_, err := fs.client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket: aws.String(fs.bucket),
-		Key:    aws.String(key),
-		Body:   r,
-	})
+// PutCourseAttachment uploads a private attachment of the course and returns its key, see
+// PresignGet to serve it.
+func (fs *FileService) PutCourseAttachment(ctx context.Context, courseID uuid.UUID, filename string, r io.Reader) (string, error) {
+	key := courseAttachmentKey(courseID, filename)
 
-	fileURL := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s",
-		os.Getenv("S3_BUCKET"),
-		os.Getenv("AWS_REGION"),
-		key,
-	)
+	err := fs.putCourseFile(ctx, courseID, AttachmentFilePurpose, key, r)
+	if err != nil {
+		return "", fmt.Errorf("file service put course attachment error: %v", err)
+	}
 
-	return &fileURL, err
+	return key, nil
+}
+
+// CopyCourseAttachment copies an object into a new private attachment of the course, keeping its
+// file name, and returns the key of the copy.
+func (fs *FileService) CopyCourseAttachment(ctx context.Context, courseID uuid.UUID, from string) (string, error) {
+	key := courseAttachmentKey(courseID, from)
+
+	err := fs.Track(key, courseID, AttachmentFilePurpose)
+	if err != nil {
+		return "", fmt.Errorf("file service copy course attachment error: %v", err)
+	}
+
+	err = fs.storage.Copy(ctx, from, key)
+	if err != nil {
+		if releaseErr := fs.repo.Release(key); releaseErr != nil {
+			log.Printf("file service failed to release %v: %v", key, releaseErr)
+		}
+		return "", fmt.Errorf("file service copy course attachment error: %v", err)
+	}
+
+	return key, nil
+}
+
+func (fs *FileService) putCourseFile(ctx context.Context, courseID uuid.UUID, purpose string, key string, r io.Reader) error {
+	err := fs.Track(key, courseID, purpose)
+	if err != nil {
+		return err
+	}
+
+	return fs.storage.Put(ctx, key, r, contentType(key))
+}
+
+// Track records a file of the course that is uploaded by other means, before it is uploaded.
+func (fs *FileService) Track(key string, courseID uuid.UUID, purpose string) error {
+	err := fs.repo.Create(&repository.FileCreateBody{Key: key, OwnerType: CourseFileOwner, OwnerID: courseID, Purpose: purpose})
+	if err != nil {
+		return fmt.Errorf("file service track error: %v", err)
+	}
+
+	return nil
+}
+
+// Release marks a tracked file as no longer used, it is deleted by the collection.
+func (fs *FileService) Release(key string) error {
+	err := fs.repo.Release(key)
+	if err != nil {
+		return fmt.Errorf("file service release error: %v", err)
+	}
+
+	return nil
+}
+
+// PresignGet returns a URL that downloads the object until ttl passes.
+func (fs *FileService) PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	url, err := fs.storage.PresignGet(ctx, key, ttl)
+	if err != nil {
+		return "", fmt.Errorf("file service presign get error: %v", err)
+	}
+
+	return url, nil
+}
+
+// Key returns the key of an object from the URL Put returned for it.
+func (fs *FileService) Key(fileURL string) (string, bool) {
+	return strings.CutPrefix(fileURL, fs.storage.PublicURL(""))
+}
+
+// Run collects garbage until the context is cancelled, an interval of zero disables it.
+func (fs *FileService) Run(ctx context.Context) {
+	if fs.interval <= 0 {
+		return
+	}
+	defer fs.lock.Release()
+
+	ticker := time.NewTicker(fs.interval)
+	defer ticker.Stop()
+
+	for {
+		leader, err := fs.lock.Acquire(ctx)
+		if err != nil {
+			log.Printf("file gc: %v", err)
+		}
+		if leader {
+			fs.collect(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (fs *FileService) collect(ctx context.Context) {
+	files, err := fs.repo.Garbage(time.Now().Add(-fs.grace), fileGCBatch)
+	if err != nil {
+		log.Printf("file gc: %v", err)
+		return
+	}
+
+	for _, file := range files {
+		if ctx.Err() != nil {
+			return
+		}
+
+		err = fs.storage.Delete(ctx, file.Key)
+		if err == nil {
+			err = fs.repo.Delete(file.ID)
+		}
+		if err != nil {
+			log.Printf("file gc: deleting %v: %v", file.Key, err)
+		}
+	}
+}
+
+// courseAttachmentKey returns a key of its own for every attachment, under a random directory so
+// the file name is kept for the download.
+func courseAttachmentKey(courseID uuid.UUID, filename string) string {
+	filename = strings.ToLower(strings.ReplaceAll(filepath.Base(filename), " ", "-"))
+	return fmt.Sprintf("%vcourses/%v/attachments/%v/%v", storage.PrivatePrefix, courseID, uuid.NewString(), filename)
+}
+
+func contentType(key string) string {
+	return mime.TypeByExtension(filepath.Ext(key))
 }
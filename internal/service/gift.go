@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/AlnurZhanibek/kazusa-server/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCodeNotFound     = errors.New("access code not found")
+	ErrCodeRedeemed     = errors.New("access code has already been redeemed")
+	ErrCodeExpired      = errors.New("access code has expired")
+	ErrInvalidCodeBatch = errors.New("invalid code batch")
+	ErrAlreadyEnrolled  = errors.New("already enrolled in the course")
+)
+
+const GiftReceivedTemplate = "gift_received"
+
+const (
+	maxCodeBatch = 1000
+	// accessCodeAlphabet leaves out 0, O, 1 and I, which are easy to mix up when a code is typed in.
+	accessCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	accessCodeLength   = 12
+	accessCodeGroup    = 4
+)
+
+type GiftService struct {
+	repo              *repository.AccessCodeRepository
+	courseRepo        repository.CourseRepositoryImplementation
+	userRepo          *repository.UserRepository
+	enrollmentService *EnrollmentService
+	notifier          Notifier
+}
+
+func NewGiftService(repo *repository.AccessCodeRepository, courseRepo repository.CourseRepositoryImplementation, userRepo *repository.UserRepository, enrollmentService *EnrollmentService, notifier Notifier) *GiftService {
+	return &GiftService{repo: repo, courseRepo: courseRepo, userRepo: userRepo, enrollmentService: enrollmentService, notifier: notifier}
+}
+
+// CreateBatch generates a batch of codes for the course, the admin is taken from the context.
+func (s *GiftService) CreateBatch(ctx context.Context, batch entity.NewCodeBatch) (*entity.CodeBatch, error) {
+	operatorID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("gift service create batch error: operator is unknown")
+	}
+
+	switch {
+	case batch.Quantity < 1 || batch.Quantity > maxCodeBatch:
+		return nil, fmt.Errorf("gift service create batch error: %w, quantity should be from 1 to %v", ErrInvalidCodeBatch, maxCodeBatch)
+	case batch.ExpiresAt != nil && !batch.ExpiresAt.After(time.Now()):
+		return nil, fmt.Errorf("gift service create batch error: %w, expiry is in the past", ErrInvalidCodeBatch)
+	}
+
+	courses, err := s.courseRepo.Read(entity.Pagination{Limit: 1}, entity.CourseFilters{ID: batch.CourseID})
+	if err != nil {
+		return nil, fmt.Errorf("gift service create batch error: %v", err)
+	}
+	if len(courses) == 0 {
+		return nil, fmt.Errorf("gift service create batch error: %w", ErrCourseNotFound)
+	}
+
+	codes := make([]string, 0, batch.Quantity)
+	for range batch.Quantity {
+		code, err := newAccessCode()
+		if err != nil {
+			return nil, fmt.Errorf("gift service create batch error: %v", err)
+		}
+		codes = append(codes, code)
+	}
+
+	id, err := s.repo.CreateBatch(batch, operatorID, codes)
+	if err != nil {
+		return nil, fmt.Errorf("gift service create batch error: %v", err)
+	}
+
+	codeBatch := &entity.CodeBatch{
+		ID:        id,
+		CreatedAt: time.Now(),
+		CourseID:  batch.CourseID,
+		Label:     batch.Label,
+		Quantity:  len(codes),
+		ExpiresAt: batch.ExpiresAt,
+		CreatedBy: &operatorID,
+	}
+
+	codeBatch.Codes, err = s.repo.Read(entity.Pagination{Limit: int64(len(codes))}, repository.AccessCodeFilters{BatchID: &id})
+	if err != nil {
+		return nil, fmt.Errorf("gift service create batch error: %v", err)
+	}
+
+	return codeBatch, nil
+}
+
+func (s *GiftService) ReadBatches(pagination entity.Pagination, courseID *uuid.UUID) ([]entity.CodeBatch, error) {
+	batches, err := s.repo.ReadBatches(pagination, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("gift service read batches error: %v", err)
+	}
+
+	return batches, nil
+}
+
+func (s *GiftService) ReadCodes(pagination entity.Pagination, batchID uuid.UUID) ([]entity.AccessCode, error) {
+	codes, err := s.repo.Read(pagination, repository.AccessCodeFilters{BatchID: &batchID})
+	if err != nil {
+		return nil, fmt.Errorf("gift service read codes error: %v", err)
+	}
+
+	return codes, nil
+}
+
+// Gifts returns the codes of the gifts the user bought.
+func (s *GiftService) Gifts(pagination entity.Pagination, userID uuid.UUID) ([]entity.AccessCode, error) {
+	codes, err := s.repo.Read(pagination, repository.AccessCodeFilters{BuyerID: &userID})
+	if err != nil {
+		return nil, fmt.Errorf("gift service gifts error: %v", err)
+	}
+
+	return codes, nil
+}
+
+type Redemption struct {
+	CourseID uuid.UUID `json:"courseId"`
+} // @name Redemption
+
+// Redeem enrolls the user in the course of the code. A code can be redeemed once, and not by a user
+// who already has the course, so it is not wasted.
+func (s *GiftService) Redeem(userID uuid.UUID, code string) (*Redemption, error) {
+	accessCode, err := s.repo.Find(normalizeAccessCode(code))
+	if err != nil {
+		return nil, fmt.Errorf("gift service redeem error: %v", err)
+	}
+
+	switch {
+	case accessCode == nil || accessCode.VoidedAt != nil:
+		return nil, fmt.Errorf("gift service redeem error: %w", ErrCodeNotFound)
+	case accessCode.RedeemedAt != nil:
+		return nil, fmt.Errorf("gift service redeem error: %w", ErrCodeRedeemed)
+	case accessCode.ExpiresAt != nil && !accessCode.ExpiresAt.After(time.Now()):
+		return nil, fmt.Errorf("gift service redeem error: %w", ErrCodeExpired)
+	}
+
+	owned, err := s.enrollmentService.Owns(userID, accessCode.CourseID)
+	if err != nil {
+		return nil, fmt.Errorf("gift service redeem error: %v", err)
+	}
+	if owned {
+		return nil, fmt.Errorf("gift service redeem error: %w", ErrAlreadyEnrolled)
+	}
+
+	claimed, err := s.repo.Claim(accessCode.ID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("gift service redeem error: %v", err)
+	}
+	if !claimed {
+		return nil, fmt.Errorf("gift service redeem error: %w", ErrCodeRedeemed)
+	}
+
+	// the order of a bought code is kept on the enrollment, so refunding the gift revokes it
+	err = s.enrollmentService.enroll(&repository.EnrollmentCreateBody{
+		UserID:   userID,
+		CourseID: accessCode.CourseID,
+		Source:   entity.GiftEnrollment,
+		OrderID:  accessCode.OrderID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gift service redeem error: code %v was redeemed but enrollment failed: %v", accessCode.ID, err)
+	}
+
+	return &Redemption{CourseID: accessCode.CourseID}, nil
+}
+
+// issue creates the code of a paid gift order and sends it to the recipient, if the buyer gave
+// one; otherwise the buyer finds the code among their gifts.
+func (s *GiftService) issue(ctx context.Context, order *repository.Payment, courseID uuid.UUID) error {
+	code, err := newAccessCode()
+	if err != nil {
+		return fmt.Errorf("gift service issue error: %v", err)
+	}
+
+	err = s.repo.CreateGift(code, courseID, order.OrderID.UUID)
+	if err != nil {
+		return fmt.Errorf("gift service issue error: %v", err)
+	}
+
+	if !order.GiftRecipientEmail.Valid {
+		return nil
+	}
+
+	users, err := s.userRepo.Read(entity.Pagination{Limit: 1}, entity.UserFilters{ID: &order.UserID})
+	if err != nil || len(users) == 0 {
+		return fmt.Errorf("gift service issue error reading buyer: %v", err)
+	}
+	buyer := users[0]
+
+	courses, err := s.courseRepo.Read(entity.Pagination{Limit: 1}, entity.CourseFilters{ID: courseID})
+	if err != nil || len(courses) == 0 {
+		return fmt.Errorf("gift service issue error reading course: %v", err)
+	}
+
+	err = s.notifier.Notify(ctx, Notification{
+		To:       order.GiftRecipientEmail.String,
+		Locale:   buyer.Locale,
+		Template: GiftReceivedTemplate,
+		Data: map[string]any{
+			"SenderName": buyer.Name,
+			"CourseName": courses[0].Title,
+			"Code":       code,
+			"Link":       fmt.Sprintf("%v/redeem?code=%v", os.Getenv("APP_URL"), code),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("gift service issue error sending notification: %v", err)
+	}
+
+	return nil
+}
+
+// void voids the code of a refunded gift order.
+func (s *GiftService) void(orderID uuid.UUID) error {
+	err := s.repo.VoidOrder(orderID)
+	if err != nil {
+		return fmt.Errorf("gift service void error: %v", err)
+	}
+
+	return nil
+}
+
+// newAccessCode returns a random code such as ABCD-EFGH-JKLM. The alphabet has 32 letters, so
+// taking a random byte modulo its size is not biased.
+func newAccessCode() (string, error) {
+	b := make([]byte, accessCodeLength)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	code := make([]byte, accessCodeLength)
+	for i := range b {
+		code[i] = accessCodeAlphabet[int(b[i])%len(accessCodeAlphabet)]
+	}
+
+	return formatAccessCode(string(code)), nil
+}
+
+// normalizeAccessCode accepts a code typed in lowercase, with spaces or without dashes.
+func normalizeAccessCode(code string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(code) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+
+	return formatAccessCode(b.String())
+}
+
+func formatAccessCode(code string) string {
+	groups := make([]string, 0, accessCodeLength/accessCodeGroup)
+	for len(code) > accessCodeGroup {
+		groups = append(groups, code[:accessCodeGroup])
+		code = code[accessCodeGroup:]
+	}
+
+	return strings.Join(append(groups, code), "-")
+}
@@ -2,16 +2,13 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
 	"github.com/AlnurZhanibek/kazusa-server/internal/repository"
 	"github.com/google/uuid"
 )
 
-type ContextKey string
-
-const userIdCtxKey ContextKey = "userId"
-
 type ModuleServiceImplementation interface {
 	Create(Module entity.NewModule) (bool, error)
 	Read(ctx context.Context, pagination entity.Pagination, filters entity.ModuleFilters) ([]entity.Module, error)
@@ -20,12 +17,13 @@ type ModuleServiceImplementation interface {
 }
 
 type ModuleService struct {
-	repo            repository.ModuleRepositoryImplementation
-	activityService *ActivityService
+	repo              repository.ModuleRepositoryImplementation
+	activityService   *ActivityService
+	enrollmentService *EnrollmentService
 }
 
-func NewModuleService(repo repository.ModuleRepositoryImplementation, activityService *ActivityService) *ModuleService {
-	return &ModuleService{repo: repo, activityService: activityService}
+func NewModuleService(repo repository.ModuleRepositoryImplementation, activityService *ActivityService, enrollmentService *EnrollmentService) *ModuleService {
+	return &ModuleService{repo: repo, activityService: activityService, enrollmentService: enrollmentService}
 }
 
 func (s *ModuleService) Create(Module entity.NewModule) (bool, error) {
@@ -37,18 +35,41 @@ func (s *ModuleService) Create(Module entity.NewModule) (bool, error) {
 	return ok, nil
 }
 
+// Read returns the content of the modules only to admins and users with access to the course, or
+// when the module is a free preview. Other modules are returned locked with their metadata only, and
+// reading a single locked module by id fails with ErrPaymentRequired or ErrEnrollmentRequired.
 func (s *ModuleService) Read(ctx context.Context, pagination entity.Pagination, filters entity.ModuleFilters) ([]entity.Module, error) {
 	modules, err := s.repo.Read(filters, pagination)
 	if err != nil {
 		return nil, fmt.Errorf("module service read error: %v", err)
 	}
 
-	userIDCtx := ctx.Value("user_id")
+	denied := make(map[uuid.UUID]error)
+	for i := range modules {
+		courseID := modules[i].CourseID
+		accessErr, checked := denied[courseID]
+		if !checked {
+			accessErr = s.enrollmentService.CheckAccess(ctx, courseID)
+			denied[courseID] = accessErr
+		}
+
+		if accessErr == nil || modules[i].FreePreview {
+			continue
+		}
+		if !errors.Is(accessErr, ErrPaymentRequired) && !errors.Is(accessErr, ErrEnrollmentRequired) {
+			return nil, fmt.Errorf("module service read error: %v", accessErr)
+		}
+		if filters.ID != uuid.Nil {
+			return nil, fmt.Errorf("module service read error: %w", accessErr)
+		}
 
-	if filters.CourseID != uuid.Nil && userIDCtx != nil {
+		modules[i].Content = ""
+		modules[i].Locked = true
+	}
 
-		userID := userIDCtx.(uuid.UUID)
+	userID, hasUser := UserIDFromContext(ctx)
 
+	if filters.CourseID != uuid.Nil && hasUser {
 		activities, actErr := s.activityService.Read(ActivityFilters{
 			UserID:   &userID,
 			CourseID: &filters.CourseID,
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+
+	"github.com/AlnurZhanibek/kazusa-server/internal/mailer"
+)
+
+const (
+	VerifyEmailTemplate   = "verify_email"
+	PasswordResetTemplate = "password_reset"
+)
+
+type Notification struct {
+	To       string
+	Locale   string
+	Template string
+	Data     map[string]any
+}
+
+// Notifier delivers templated notifications to users. MailNotifier sends them by email,
+// LogNotifier and MemoryNotifier are meant for local development and tests.
+type Notifier interface {
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// NormalizeLocale returns the locale if emails are translated to it, or the default locale otherwise.
+func NormalizeLocale(locale string) string {
+	if slices.Contains(mailer.Locales, locale) {
+		return locale
+	}
+
+	return mailer.DefaultLocale
+}
+
+type MailNotifier struct {
+	templates *mailer.Templates
+	mailer    mailer.Mailer
+}
+
+func NewMailNotifier(templates *mailer.Templates, mailer mailer.Mailer) *MailNotifier {
+	return &MailNotifier{templates: templates, mailer: mailer}
+}
+
+func (n *MailNotifier) Notify(ctx context.Context, notification Notification) error {
+	message, err := n.templates.Render(notification.Template, notification.Locale, notification.To, notification.Data)
+	if err != nil {
+		return fmt.Errorf("mail notifier error: %v", err)
+	}
+
+	err = n.mailer.Send(ctx, *message)
+	if err != nil {
+		return fmt.Errorf("mail notifier error: %v", err)
+	}
+
+	return nil
+}
+
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, notification Notification) error {
+	log.Printf("notification %v to %v (%v): %v", notification.Template, notification.To, notification.Locale, notification.Data)
+	return nil
+}
+
+type MemoryNotifier struct {
+	mu            sync.Mutex
+	notifications []Notification
+}
+
+func NewMemoryNotifier() *MemoryNotifier {
+	return &MemoryNotifier{}
+}
+
+func (n *MemoryNotifier) Notify(ctx context.Context, notification Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+func (n *MemoryNotifier) Notifications() []Notification {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	notifications := make([]Notification, len(n.notifications))
+	copy(notifications, n.notifications)
+	return notifications
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/AlnurZhanibek/kazusa-server/internal/repository"
+)
+
+const PasswordResetTTL = time.Hour
+
+type PasswordService struct {
+	userRepo    *repository.UserRepository
+	resetRepo   *repository.PasswordResetRepository
+	authService *AuthService
+	notifier    Notifier
+}
+
+func NewPasswordService(userRepo *repository.UserRepository, resetRepo *repository.PasswordResetRepository, authService *AuthService, notifier Notifier) *PasswordService {
+	return &PasswordService{userRepo: userRepo, resetRepo: resetRepo, authService: authService, notifier: notifier}
+}
+
+// Forgot sends a reset link to the user with the given email. Unknown emails are
+// silently ignored so the endpoint cannot be used to find out who is registered.
+func (s *PasswordService) Forgot(ctx context.Context, email string) error {
+	users, err := s.userRepo.Read(
+		entity.Pagination{
+			Offset: 0,
+			Limit:  1,
+		},
+		entity.UserFilters{
+			Email: &email,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("password service forgot error: %v", err)
+	}
+	if len(users) == 0 {
+		return nil
+	}
+	user := users[0]
+
+	token, err := randomToken()
+	if err != nil {
+		return fmt.Errorf("password service forgot error generating token: %v", err)
+	}
+
+	err = s.resetRepo.Create(&repository.PasswordResetCreateBody{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(PasswordResetTTL),
+	})
+	if err != nil {
+		return fmt.Errorf("password service forgot error: %v", err)
+	}
+
+	err = s.notifier.Notify(ctx, Notification{
+		To:       user.Email,
+		Locale:   user.Locale,
+		Template: PasswordResetTemplate,
+		Data: map[string]any{
+			"Name":  user.Name,
+			"Link":  fmt.Sprintf("%v/password/reset?token=%v", os.Getenv("APP_URL"), token),
+			"Hours": int(PasswordResetTTL.Hours()),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("password service forgot error sending notification: %v", err)
+	}
+
+	return nil
+}
+
+// Reset sets a new password using a token issued by Forgot. The token can be used once,
+// and every session of the user is revoked afterwards.
+func (s *PasswordService) Reset(token string, password string, passwordConfirmation string) error {
+	if password == "" || password != passwordConfirmation {
+		return fmt.Errorf("password service reset error: passwords do not match")
+	}
+
+	reset, err := s.resetRepo.ReadByTokenHash(hashToken(token))
+	if err != nil {
+		return fmt.Errorf("password service reset error: %v", err)
+	}
+	if reset == nil || reset.UsedAt.Valid || time.Now().After(reset.ExpiresAt) {
+		return fmt.Errorf("password service reset error: token is invalid or expired")
+	}
+
+	used, err := s.resetRepo.Use(reset.ID)
+	if err != nil {
+		return fmt.Errorf("password service reset error: %v", err)
+	}
+	if !used {
+		return fmt.Errorf("password service reset error: token is invalid or expired")
+	}
+
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return fmt.Errorf("password service reset error: %v", err)
+	}
+
+	_, err = s.userRepo.Update(entity.UserUpdateBody{
+		ID:       reset.UserID,
+		Password: &hashedPassword,
+	})
+	if err != nil {
+		return fmt.Errorf("password service reset error: %v", err)
+	}
+
+	err = s.resetRepo.UseAll(reset.UserID)
+	if err != nil {
+		return fmt.Errorf("password service reset error: %v", err)
+	}
+
+	err = s.authService.LogoutAll(reset.UserID)
+	if err != nil {
+		return fmt.Errorf("password service reset error: %v", err)
+	}
+
+	return nil
+}
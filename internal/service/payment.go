@@ -1,68 +1,577 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/AlnurZhanibek/kazusa-server/internal/payment"
 	"github.com/AlnurZhanibek/kazusa-server/internal/repository"
 	"github.com/google/uuid"
+	"log"
+	"net/mail"
+	"os"
+	"strconv"
 )
 
+var (
+	ErrPaymentNotFound          = errors.New("payment not found")
+	ErrInvalidPaymentTransition = errors.New("invalid payment status transition")
+	ErrCourseNotFound           = errors.New("course not found")
+	ErrCourseAlreadyPaid        = errors.New("course is already paid")
+	ErrPaymentAmountMismatch    = errors.New("paid amount does not match the order")
+	ErrInvalidRefundAmount      = errors.New("invalid refund amount")
+	ErrInvalidGiftRecipient     = errors.New("invalid gift recipient email")
+)
+
+const RefundAuditAction = "payment.refund"
+
 type PaymentService struct {
-	repo *repository.PaymentRepository
+	repo                 *repository.PaymentRepository
+	courseRepo           repository.CourseRepositoryImplementation
+	userRepo             *repository.UserRepository
+	auditRepo            *repository.AuditRepository
+	reviewRepo           *repository.PaymentReviewRepository
+	couponService        *CouponService
+	bundleService        *BundleService
+	subscriptionService  *SubscriptionService
+	enrollmentService    *EnrollmentService
+	giftService          *GiftService
+	receiptService       *ReceiptService
+	provider             payment.Provider
+	requireVerifiedEmail bool
+}
+
+func NewPaymentService(repo *repository.PaymentRepository, courseRepo repository.CourseRepositoryImplementation, userRepo *repository.UserRepository, auditRepo *repository.AuditRepository, reviewRepo *repository.PaymentReviewRepository, couponService *CouponService, bundleService *BundleService, subscriptionService *SubscriptionService, enrollmentService *EnrollmentService, giftService *GiftService, receiptService *ReceiptService, provider payment.Provider) *PaymentService {
+	requireVerifiedEmail, _ := strconv.ParseBool(os.Getenv("PAYMENT_REQUIRES_VERIFIED_EMAIL"))
+
+	return &PaymentService{
+		repo:                 repo,
+		courseRepo:           courseRepo,
+		userRepo:             userRepo,
+		auditRepo:            auditRepo,
+		reviewRepo:           reviewRepo,
+		couponService:        couponService,
+		bundleService:        bundleService,
+		subscriptionService:  subscriptionService,
+		enrollmentService:    enrollmentService,
+		giftService:          giftService,
+		receiptService:       receiptService,
+		provider:             provider,
+		requireVerifiedEmail: requireVerifiedEmail,
+	}
 }
 
-func NewPaymentService(repo *repository.PaymentRepository) *PaymentService {
-	return &PaymentService{repo: repo}
+type Order struct {
+	OrderID uuid.UUID `json:"orderId"`
+	// CourseID is set for orders of a single course, BundleID for orders of a bundle and
+	// SubscriptionID for orders paying a subscription period. Items lists the courses the order
+	// gives access to, unless the order is a gift: then it pays for an access code instead.
+	CourseID       *uuid.UUID           `json:"courseId,omitempty"`
+	BundleID       *uuid.UUID           `json:"bundleId,omitempty"`
+	SubscriptionID *uuid.UUID           `json:"subscriptionId,omitempty"`
+	Items          []CartItem           `json:"items"`
+	Amount         int64                `json:"amount"`
+	Discount       int64                `json:"discount"`
+	Currency       string               `json:"currency"`
+	CouponCode     string               `json:"couponCode,omitempty"`
+	Status         entity.PaymentStatus `json:"status"`
+	CheckoutURL    string               `json:"checkoutUrl,omitempty"`
+	Gift           bool                 `json:"gift,omitempty"`
+	// GiftRecipientEmail is where the access code of a gift is sent, without it the buyer hands
+	// the code over themselves.
+	GiftRecipientEmail string `json:"giftRecipientEmail,omitempty"`
+} // @name Order
+
+// CreateOrder starts a purchase of the course by the user. The price and currency of the course,
+// less the coupon discount if a coupon code is given, are copied into the order so later price
+// changes do not affect it, and the buyer is sent to the checkout page returned by the payment
+// provider. An order that costs nothing after the discount is paid right away.
+func (s *PaymentService) CreateOrder(ctx context.Context, userID uuid.UUID, courseID uuid.UUID, couponCode string) (*Order, error) {
+	order, err := s.createCourseOrder(ctx, userID, courseID, couponCode, &Order{})
+	if err != nil {
+		return nil, fmt.Errorf("payment service create order error: %w", err)
+	}
+
+	return order, nil
 }
 
-type PaymentCreateBody struct {
-	UserID   uuid.UUID
-	CourseID uuid.UUID
-	OrderID  uuid.UUID
+// CreateGiftOrder starts a purchase of the course as a gift. The buyer does not get access, once the
+// order is paid an access code is issued for it and emailed to the recipient if an email is given,
+// see GiftService.Redeem. A buyer may gift a course they own.
+func (s *PaymentService) CreateGiftOrder(ctx context.Context, userID uuid.UUID, courseID uuid.UUID, couponCode string, recipientEmail string) (*Order, error) {
+	if recipientEmail != "" {
+		address, err := mail.ParseAddress(recipientEmail)
+		if err != nil || address.Address != recipientEmail {
+			return nil, fmt.Errorf("payment service create gift order error: %w", ErrInvalidGiftRecipient)
+		}
+	}
+
+	order, err := s.createCourseOrder(ctx, userID, courseID, couponCode, &Order{Gift: true, GiftRecipientEmail: recipientEmail})
+	if err != nil {
+		return nil, fmt.Errorf("payment service create gift order error: %w", err)
+	}
+
+	return order, nil
 }
 
-func (s *PaymentService) Create(payment *PaymentCreateBody) error {
-	return s.repo.Create(&repository.PaymentCreateBody{
-		UserID:   payment.UserID,
-		CourseID: payment.CourseID,
-		OrderID:  payment.OrderID,
+func (s *PaymentService) createCourseOrder(ctx context.Context, userID uuid.UUID, courseID uuid.UUID, couponCode string, order *Order) (*Order, error) {
+	user, err := s.buyer(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	courses, err := s.courseRepo.Read(entity.Pagination{Limit: 1}, entity.CourseFilters{ID: courseID})
+	if err != nil {
+		return nil, err
+	}
+	if len(courses) == 0 {
+		return nil, ErrCourseNotFound
+	}
+	course := courses[0]
+
+	if !order.Gift {
+		owned, err := s.enrollmentService.Owns(userID, courseID)
+		if err != nil {
+			return nil, err
+		}
+		if owned {
+			return nil, ErrCourseAlreadyPaid
+		}
+	}
+
+	quote, err := s.couponService.Quote(userID, course, couponCode)
+	if err != nil {
+		return nil, err
+	}
+
+	order.CourseID = &course.ID
+	return s.placeOrder(ctx, user, order, &CartQuote{
+		Items:       []CartItem{{CourseID: course.ID, Title: course.Title, Price: quote.Price, Amount: quote.Total}},
+		Price:       quote.Price,
+		Discount:    quote.Discount,
+		Total:       quote.Total,
+		Currency:    quote.Currency,
+		CouponCode:  quote.CouponCode,
+		couponID:    quote.couponID,
+		description: course.Title,
 	})
 }
 
-type Payment struct {
-	ID       uuid.UUID
-	UserID   uuid.UUID
-	CourseID uuid.UUID
-} // @name Payment
+// buyer returns the user placing an order, who has to have a verified email if purchases require it.
+func (s *PaymentService) buyer(userID uuid.UUID) (*entity.User, error) {
+	users, err := s.userRepo.Read(entity.Pagination{Limit: 1}, entity.UserFilters{ID: &userID})
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("user not found")
+	}
+	user := users[0]
 
-type PaymentFilters struct {
-	UserID   *uuid.UUID
-	CourseID *uuid.UUID
+	if s.requireVerifiedEmail && user.EmailVerifiedAt == nil {
+		return nil, ErrEmailNotVerified
+	}
+
+	return &user, nil
 }
 
-func (s *PaymentService) Read(filters PaymentFilters) (*Payment, error) {
-	repoPayment, err := s.repo.Read(&repository.PaymentFilters{
-		UserID:   filters.UserID,
-		CourseID: filters.CourseID,
+// placeOrder records the priced order and sends it to the payment provider, the order only needs
+// to say what it is for.
+func (s *PaymentService) placeOrder(ctx context.Context, user *entity.User, order *Order, quote *CartQuote) (*Order, error) {
+	order.OrderID = uuid.New()
+	order.BundleID = quote.BundleID
+	order.Items = quote.Items
+	order.Amount = quote.Total
+	order.Discount = quote.Discount
+	order.Currency = quote.Currency
+	order.CouponCode = quote.CouponCode
+	order.Status = entity.PaymentCreated
+
+	items := make([]repository.PaymentItem, 0, len(quote.Items))
+	for _, item := range quote.Items {
+		items = append(items, repository.PaymentItem{CourseID: item.CourseID, Price: item.Price, Amount: item.Amount})
+	}
+
+	created, err := s.repo.Create(&repository.PaymentCreateBody{
+		UserID:             user.ID,
+		CourseID:           order.CourseID,
+		BundleID:           order.BundleID,
+		SubscriptionID:     order.SubscriptionID,
+		OrderID:            order.OrderID,
+		Amount:             order.Amount,
+		Currency:           order.Currency,
+		Provider:           s.provider.Name(),
+		CouponID:           quote.couponID,
+		Discount:           order.Discount,
+		Gift:               order.Gift,
+		GiftRecipientEmail: order.GiftRecipientEmail,
+		Items:              items,
 	})
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, fmt.Errorf("payment service place order error: %w", ErrCouponExhausted)
+	}
+
+	if order.Amount == 0 {
+		payload, _ := json.Marshal(map[string]any{"coupon": order.CouponCode, "discount": order.Discount})
+		err = s.UpdateStatus(order.OrderID, entity.PaymentSucceeded, string(payload))
+		if err != nil {
+			return nil, err
+		}
+		order.Status = entity.PaymentSucceeded
+
+		return order, nil
+	}
+
+	checkout, err := s.provider.CreateCheckout(ctx, payment.Order{
+		ID:          order.OrderID,
+		Amount:      order.Amount,
+		Currency:    order.Currency,
+		Description: quote.description,
+		Email:       user.Email,
+	})
+	if err != nil {
+		payload, _ := json.Marshal(map[string]string{"error": err.Error()})
+		_ = s.UpdateStatus(order.OrderID, entity.PaymentFailed, string(payload))
+		return nil, fmt.Errorf("error from %v provider: %v", s.provider.Name(), err)
+	}
+	order.CheckoutURL = checkout.URL
 
+	err = s.repo.SetCheckout(order.OrderID, checkout.URL, checkout.ProviderPaymentID)
 	if err != nil {
-		return nil, fmt.Errorf("service error when reading activity: %v", err)
+		return nil, err
+	}
+	order.Status = entity.PaymentPending
+
+	return order, nil
+}
+
+// Provider returns the payment provider in use.
+func (s *PaymentService) Provider() payment.Provider {
+	return s.provider
+}
+
+type Refund struct {
+	OrderID        uuid.UUID            `json:"orderId"`
+	Amount         int64                `json:"amount"`
+	RefundedAmount int64                `json:"refundedAmount"`
+	PaidAmount     int64                `json:"paidAmount"`
+	Currency       string               `json:"currency"`
+	Status         entity.PaymentStatus `json:"status"`
+} // @name Refund
+
+// Refund returns the amount of the order to the buyer through the payment provider, an amount of
+// zero refunds everything that is left. Once the whole payment is refunded it moves to the refunded
+// status and no longer gives access to the course, should that fail after the provider refunded the
+// money the refund is still returned and the order is flagged for review. The operator is taken
+// from the context and the refund is written to the audit log together with the reason.
+func (s *PaymentService) Refund(ctx context.Context, orderID uuid.UUID, amount int64, reason string) (*Refund, error) {
+	operatorID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("payment service refund error: operator is unknown")
 	}
 
-	payment := &Payment{}
-	if repoPayment != nil {
-		payment = &Payment{
-			ID:       repoPayment.ID,
-			UserID:   repoPayment.UserID,
-			CourseID: repoPayment.CourseID,
+	order, err := s.repo.Read(&repository.PaymentFilters{OrderID: &orderID})
+	if err != nil {
+		return nil, fmt.Errorf("payment service refund error: %v", err)
+	}
+	if order == nil {
+		return nil, fmt.Errorf("payment service refund error: %w", ErrPaymentNotFound)
+	}
+	if order.Status != entity.PaymentSucceeded {
+		return nil, fmt.Errorf("payment service refund error: %w, payment is %v", ErrInvalidPaymentTransition, order.Status)
+	}
+	if order.Provider.Valid && order.Provider.String != s.provider.Name() {
+		return nil, fmt.Errorf("payment service refund error: payment was made with %v, current provider is %v", order.Provider.String, s.provider.Name())
+	}
+
+	remaining := order.Amount - order.RefundedAmount
+	if amount == 0 {
+		amount = remaining
+	}
+	if amount <= 0 || amount > remaining {
+		return nil, fmt.Errorf("payment service refund error: %w, %v is left to refund", ErrInvalidRefundAmount, remaining)
+	}
+
+	// the amount is reserved before the provider is called, so concurrent refunds can not exceed
+	// the paid amount together
+	added, err := s.repo.AddRefund(orderID, amount)
+	if err != nil {
+		return nil, fmt.Errorf("payment service refund error: %v", err)
+	}
+	if !added {
+		return nil, fmt.Errorf("payment service refund error: %w, payment changed concurrently", ErrInvalidRefundAmount)
+	}
+
+	err = s.provider.Refund(ctx, payment.Reference{OrderID: orderID, ProviderPaymentID: order.ProviderPaymentID.String}, amount)
+	if err != nil {
+		releaseErr := s.repo.ReleaseRefund(orderID, amount)
+		if releaseErr != nil {
+			log.Printf("payment service refund of %v for order %v failed at the provider and could not be released: %v", amount, orderID, releaseErr)
+		}
+		return nil, fmt.Errorf("payment service refund error from %v provider: %v", s.provider.Name(), err)
+	}
+
+	refund := &Refund{
+		OrderID:        orderID,
+		Amount:         amount,
+		RefundedAmount: order.RefundedAmount + amount,
+		PaidAmount:     order.Amount,
+		Currency:       order.Currency,
+		Status:         order.Status,
+	}
+
+	// the refunded total only grows, so it keeps the payload of every refund of the order unique
+	// and a partial refund is never mistaken for an already applied full one
+	payload, _ := json.Marshal(map[string]any{
+		"refund_amount":   amount,
+		"refunded_amount": refund.RefundedAmount,
+		"operator_id":     operatorID,
+		"reason":          reason,
+	})
+	if refund.RefundedAmount == order.Amount {
+		err = s.UpdateStatus(orderID, entity.PaymentRefunded, string(payload))
+		if err != nil {
+			// the money is already back with the buyer, so the refund stands and an admin finishes it
+			s.flag(orderID, fmt.Sprintf("refunded at the provider, but the order could not be marked refunded: %v", err), order.Status, string(entity.PaymentRefunded))
+		} else {
+			refund.Status = entity.PaymentRefunded
 		}
 	} else {
-		payment = nil
+		s.recordEvent(&repository.PaymentEventCreateBody{
+			OrderID:     orderID,
+			FromStatus:  &order.Status,
+			ToStatus:    &order.Status,
+			Applied:     true,
+			Payload:     string(payload),
+			PayloadHash: hashToken(orderID.String() + string(payload)),
+		})
 	}
 
-	return payment, nil
+	err = s.auditRepo.Create(&repository.AuditEntry{
+		ActorID:    operatorID,
+		Action:     RefundAuditAction,
+		EntityType: "payment",
+		EntityID:   orderID.String(),
+		Details: map[string]any{
+			"amount":         amount,
+			"refundedAmount": refund.RefundedAmount,
+			"currency":       order.Currency,
+			"reason":         reason,
+		},
+	})
+	if err != nil {
+		log.Printf("payment service failed to audit refund of order %v: %v", orderID, err)
+	}
+
+	return refund, nil
+}
+
+// flag hands the order over to an admin through the payment review queue.
+func (s *PaymentService) flag(orderID uuid.UUID, reason string, localStatus entity.PaymentStatus, providerStatus string) {
+	err := s.reviewRepo.Flag(orderID, reason, localStatus, providerStatus)
+	if err != nil {
+		log.Printf("payment service failed to flag order %v for review (%v): %v", orderID, reason, err)
+		return
+	}
+
+	log.Printf("payment service flagged order %v for review: %v", orderID, reason)
 }
 
-func (s *PaymentService) Confirm(orderID uuid.UUID) error {
-	return s.repo.Confirm(orderID)
+// ApplyCallback applies a status callback of the payment provider. A succeeded payment whose
+// amount differs from the order, or that does not report the amount at all, is not applied. Such a
+// payment, and one on an order that can no longer be paid, is flagged for review, the buyer may
+// have been charged without getting anything.
+func (s *PaymentService) ApplyCallback(callback *payment.Callback) error {
+	if callback.ProviderPaymentID != "" {
+		err := s.repo.SetProviderPaymentID(callback.OrderID, callback.ProviderPaymentID)
+		if err != nil {
+			return fmt.Errorf("payment service apply callback error: %v", err)
+		}
+	}
+
+	if callback.Status != entity.PaymentSucceeded {
+		return s.UpdateStatus(callback.OrderID, callback.Status, callback.Payload)
+	}
+
+	order, err := s.repo.Read(&repository.PaymentFilters{OrderID: &callback.OrderID})
+	if err != nil {
+		return fmt.Errorf("payment service apply callback error: %v", err)
+	}
+	if order == nil {
+		return fmt.Errorf("payment service apply callback error: %w", ErrPaymentNotFound)
+	}
+
+	if order.Amount != callback.Amount {
+		failed := entity.PaymentFailed
+		s.recordEvent(&repository.PaymentEventCreateBody{
+			OrderID:     callback.OrderID,
+			FromStatus:  &order.Status,
+			ToStatus:    &failed,
+			Payload:     callback.Payload,
+			PayloadHash: hashToken(callback.OrderID.String() + callback.Payload),
+		})
+
+		err = fmt.Errorf("%w, paid %v instead of %v", ErrPaymentAmountMismatch, callback.Amount, order.Amount)
+		if callback.Amount == 0 {
+			err = fmt.Errorf("%w, the paid amount is missing", ErrPaymentAmountMismatch)
+		}
+		s.flag(callback.OrderID, fmt.Sprintf("provider reports the payment succeeded: %v", err), order.Status, string(callback.Status))
+		return fmt.Errorf("payment service apply callback error: %w", err)
+	}
+
+	err = s.UpdateStatus(callback.OrderID, callback.Status, callback.Payload)
+	if errors.Is(err, ErrInvalidPaymentTransition) {
+		// a concurrent callback may have applied the same success in the meantime
+		current := order
+		if fresh, readErr := s.repo.Read(&repository.PaymentFilters{OrderID: &callback.OrderID}); readErr == nil && fresh != nil {
+			current = fresh
+		}
+		if current.Status != entity.PaymentSucceeded {
+			s.flag(callback.OrderID, fmt.Sprintf("provider reports the payment succeeded, the order is %v", current.Status), current.Status, string(callback.Status))
+		}
+	}
+
+	return err
+}
+
+// UpdateStatus applies a status reported by the payment provider to the payment of the order.
+// Every call is recorded in the payment history together with the raw payload, including the
+// ones that are rejected. Reporting the current status again is a no-op, and a payload that has
+// already been applied is never applied again, so replaying an old callback cannot move a payment
+// back to a previous status.
+func (s *PaymentService) UpdateStatus(orderID uuid.UUID, status entity.PaymentStatus, payload string) error {
+	event := &repository.PaymentEventCreateBody{
+		OrderID:     orderID,
+		Payload:     payload,
+		PayloadHash: hashToken(orderID.String() + payload),
+	}
+	if status.Valid() {
+		event.ToStatus = &status
+	}
+
+	applied, err := s.repo.EventApplied(event.PayloadHash)
+	if err != nil {
+		return fmt.Errorf("payment service update status error: %v", err)
+	}
+	if applied {
+		log.Printf("payment callback for order %v was already applied, ignoring", orderID)
+	} else {
+		err = s.updateStatus(orderID, status, event)
+	}
+
+	s.recordEvent(event)
+
+	return err
+}
+
+func (s *PaymentService) recordEvent(event *repository.PaymentEventCreateBody) {
+	err := s.repo.CreateEvent(event)
+	if err != nil {
+		log.Printf("payment service failed to record event for order %v: %v", event.OrderID, err)
+	}
+}
+
+func (s *PaymentService) updateStatus(orderID uuid.UUID, status entity.PaymentStatus, event *repository.PaymentEventCreateBody) error {
+	if !status.Valid() {
+		return fmt.Errorf("payment service update status error: unknown status %q", status)
+	}
+
+	payment, err := s.repo.Read(&repository.PaymentFilters{OrderID: &orderID})
+	if err != nil {
+		return fmt.Errorf("payment service update status error: %v", err)
+	}
+	if payment == nil {
+		return fmt.Errorf("payment service update status error: %w", ErrPaymentNotFound)
+	}
+	event.FromStatus = &payment.Status
+
+	if payment.Status == status {
+		return nil
+	}
+	if !payment.Status.CanTransitionTo(status) {
+		return fmt.Errorf("payment service update status error: %w from %v to %v", ErrInvalidPaymentTransition, payment.Status, status)
+	}
+
+	applied, err := s.repo.Transition(orderID, status)
+	if err != nil {
+		return fmt.Errorf("payment service update status error: %v", err)
+	}
+	if !applied {
+		return fmt.Errorf("payment service update status error: %w, payment status changed concurrently", ErrInvalidPaymentTransition)
+	}
+	event.Applied = true
+
+	log.Printf("payment for order %v moved from %v to %v", orderID, payment.Status, status)
+
+	s.applyAccess(payment, status)
+
+	if status == entity.PaymentSucceeded {
+		err = s.receiptService.issue(context.Background(), payment)
+		if err != nil {
+			log.Printf("payment service failed to issue receipt for order %v: %v", orderID, err)
+		}
+	}
+
+	return nil
+}
+
+// applyAccess gives what a succeeded order paid for, enrollments in its courses, a subscription
+// period or the access code of a gift, and takes it back when the order is refunded. The payment has already moved, so a failure
+// is only logged.
+func (s *PaymentService) applyAccess(order *repository.Payment, status entity.PaymentStatus) {
+	if status != entity.PaymentSucceeded && status != entity.PaymentRefunded {
+		return
+	}
+	orderID := order.OrderID.UUID
+
+	var err error
+	switch {
+	case order.SubscriptionID.Valid && status == entity.PaymentSucceeded:
+		err = s.subscriptionService.Extend(order.SubscriptionID.UUID)
+	case order.SubscriptionID.Valid:
+		err = s.subscriptionService.End(order.SubscriptionID.UUID)
+	case order.Gift && status == entity.PaymentSucceeded:
+		err = s.giftService.issue(context.Background(), order, order.CourseID.UUID)
+	case order.Gift:
+		// the enrollment of a redeemed code carries the order, so it is revoked as well
+		err = s.giftService.void(orderID)
+		if err == nil {
+			err = s.enrollmentService.revokeOrder(orderID)
+		}
+	case status == entity.PaymentSucceeded:
+		var courseIDs []uuid.UUID
+		courseIDs, err = s.orderCourses(order)
+		if err == nil {
+			err = s.enrollmentService.enrollOrder(orderID, order.UserID, courseIDs, entity.PurchaseEnrollment)
+		}
+	default:
+		err = s.enrollmentService.revokeOrder(orderID)
+	}
+	if err != nil {
+		log.Printf("payment service failed to apply %v order %v to the access of user %v: %v", status, orderID, order.UserID, err)
+	}
+}
+
+// orderCourses returns the courses bought with the order, orders made before orders had items
+// only have a course.
+func (s *PaymentService) orderCourses(order *repository.Payment) ([]uuid.UUID, error) {
+	items, err := s.repo.Items(order.OrderID.UUID)
+	if err != nil {
+		return nil, err
+	}
+
+	courseIDs := make([]uuid.UUID, 0, len(items)+1)
+	for _, item := range items {
+		courseIDs = append(courseIDs, item.CourseID)
+	}
+	if len(courseIDs) == 0 && order.CourseID.Valid {
+		courseIDs = append(courseIDs, order.CourseID.UUID)
+	}
+
+	return courseIDs, nil
 }
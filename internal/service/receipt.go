@@ -0,0 +1,400 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/AlnurZhanibek/kazusa-server/internal/document"
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/AlnurZhanibek/kazusa-server/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrReceiptNotFound = errors.New("receipt not found")
+	ErrOrderNotPaid    = errors.New("order is not paid")
+)
+
+const (
+	PaymentReceiptTemplate       = "payment_receipt"
+	RegenerateReceiptAuditAction = "receipt.regenerate"
+
+	// defaultReceiptTTL is how long a presigned receipt URL works.
+	defaultReceiptTTL = 5 * time.Minute
+)
+
+// Seller is printed on receipts, it is configured with the RECEIPT_SELLER_* environment variables.
+type Seller struct {
+	Name    string
+	BIN     string
+	Address string
+}
+
+type ReceiptService struct {
+	repo             *repository.ReceiptRepository
+	paymentRepo      *repository.PaymentRepository
+	userRepo         *repository.UserRepository
+	courseRepo       repository.CourseRepositoryImplementation
+	subscriptionRepo *repository.SubscriptionRepository
+	auditRepo        *repository.AuditRepository
+	fileService      *FileService
+	notifier         Notifier
+	seller           Seller
+	receiptTTL       time.Duration
+}
+
+func NewReceiptService(repo *repository.ReceiptRepository, paymentRepo *repository.PaymentRepository, userRepo *repository.UserRepository, courseRepo repository.CourseRepositoryImplementation, subscriptionRepo *repository.SubscriptionRepository, auditRepo *repository.AuditRepository, fileService *FileService, notifier Notifier) *ReceiptService {
+	return &ReceiptService{
+		repo:             repo,
+		paymentRepo:      paymentRepo,
+		userRepo:         userRepo,
+		courseRepo:       courseRepo,
+		subscriptionRepo: subscriptionRepo,
+		auditRepo:        auditRepo,
+		fileService:      fileService,
+		notifier:         notifier,
+		seller: Seller{
+			Name:    os.Getenv("RECEIPT_SELLER_NAME"),
+			BIN:     os.Getenv("RECEIPT_SELLER_BIN"),
+			Address: os.Getenv("RECEIPT_SELLER_ADDRESS"),
+		},
+		receiptTTL: durationFromEnv("RECEIPT_URL_TTL", defaultReceiptTTL),
+	}
+}
+
+// issue renders and stores the receipt of a paid order and emails it to the buyer. Issuing is
+// idempotent, an order that already has a receipt is left as is.
+func (s *ReceiptService) issue(ctx context.Context, order *repository.Payment) error {
+	existing, err := s.find(order.OrderID.UUID)
+	if err != nil {
+		return fmt.Errorf("receipt service issue error: %v", err)
+	}
+	if existing != nil {
+		return nil
+	}
+
+	receipt, buyer, err := s.create(ctx, order, time.Now())
+	if err != nil {
+		return fmt.Errorf("receipt service issue error: %v", err)
+	}
+
+	err = s.notifier.Notify(ctx, Notification{
+		To:       buyer.Email,
+		Locale:   buyer.Locale,
+		Template: PaymentReceiptTemplate,
+		Data: map[string]any{
+			"Name":   buyer.Name,
+			"Number": receipt.Number,
+			"Link":   receiptLink(receipt.OrderID),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("receipt service issue error sending notification: %v", err)
+	}
+
+	return nil
+}
+
+// Regenerate renders the receipt of the order again, for example after the seller details changed,
+// keeping its number and date of payment. Orders paid before receipts existed get one. The admin
+// is taken from the context and the regeneration is written to the audit log.
+func (s *ReceiptService) Regenerate(ctx context.Context, orderID uuid.UUID) (*entity.Receipt, error) {
+	operatorID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("receipt service regenerate error: operator is unknown")
+	}
+
+	order, err := s.paymentRepo.Read(&repository.PaymentFilters{OrderID: &orderID})
+	if err != nil {
+		return nil, fmt.Errorf("receipt service regenerate error: %v", err)
+	}
+	if order == nil {
+		return nil, fmt.Errorf("receipt service regenerate error: %w", ErrPaymentNotFound)
+	}
+	if order.Status != entity.PaymentSucceeded && order.Status != entity.PaymentRefunded {
+		return nil, fmt.Errorf("receipt service regenerate error: %w", ErrOrderNotPaid)
+	}
+
+	receipt, err := s.find(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("receipt service regenerate error: %v", err)
+	}
+
+	if receipt == nil {
+		var paidAt time.Time
+		paidAt, err = s.paymentRepo.PaidAt(orderID)
+		if err != nil {
+			return nil, fmt.Errorf("receipt service regenerate error: %v", err)
+		}
+		if paidAt.IsZero() {
+			// orders paid before the payment history existed have nothing closer to the payment
+			paidAt = order.UpdatedAt
+		}
+		receipt, _, err = s.create(ctx, order, paidAt)
+	} else {
+		var key string
+		key, _, err = s.render(ctx, order, receipt.Number, receipt.PaidAt)
+		if err == nil {
+			err = s.repo.SetFile(orderID, key)
+			receipt.UpdatedAt = time.Now()
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("receipt service regenerate error: %v", err)
+	}
+
+	err = s.auditRepo.Create(&repository.AuditEntry{
+		ActorID:    operatorID,
+		Action:     RegenerateReceiptAuditAction,
+		EntityType: "payment",
+		EntityID:   orderID.String(),
+		Details:    map[string]any{"number": receipt.Number},
+	})
+	if err != nil {
+		log.Printf("receipt service failed to audit regeneration of receipt %v: %v", receipt.Number, err)
+	}
+
+	receipt.FileURL = receiptLink(orderID)
+	return receipt, nil
+}
+
+// Download returns a presigned URL of the receipt file of the order, only its buyer and admins can
+// download it. Receipts issued before they were stored privately still have their public URL.
+func (s *ReceiptService) Download(ctx context.Context, orderID uuid.UUID) (string, error) {
+	receipt, err := s.find(orderID)
+	if err != nil {
+		return "", fmt.Errorf("receipt service download error: %v", err)
+	}
+
+	userID, _ := UserIDFromContext(ctx)
+	if receipt == nil || (receipt.UserID != userID && !IsAdmin(ctx)) {
+		return "", fmt.Errorf("receipt service download error: %w", ErrReceiptNotFound)
+	}
+
+	key := receipt.FileURL
+	if publicKey, ok := s.fileService.Key(key); ok {
+		key = publicKey
+	}
+
+	url, err := s.fileService.PresignGet(ctx, key, s.receiptTTL)
+	if err != nil {
+		return "", fmt.Errorf("receipt service download error: %v", err)
+	}
+
+	return url, nil
+}
+
+type PaymentRecord struct {
+	OrderID        uuid.UUID            `json:"orderId"`
+	CreatedAt      time.Time            `json:"createdAt"`
+	CourseID       *uuid.UUID           `json:"courseId,omitempty"`
+	BundleID       *uuid.UUID           `json:"bundleId,omitempty"`
+	SubscriptionID *uuid.UUID           `json:"subscriptionId,omitempty"`
+	Gift           bool                 `json:"gift,omitempty"`
+	Status         entity.PaymentStatus `json:"status"`
+	Amount         int64                `json:"amount"`
+	Discount       int64                `json:"discount"`
+	RefundedAmount int64                `json:"refundedAmount"`
+	Currency       string               `json:"currency"`
+	Receipt        *entity.Receipt      `json:"receipt,omitempty"`
+} // @name PaymentRecord
+
+// Payments returns the paid and refunded orders of the user with their receipts.
+func (s *ReceiptService) Payments(pagination entity.Pagination, userID uuid.UUID) ([]PaymentRecord, error) {
+	orders, err := s.paymentRepo.List(pagination, &repository.PaymentFilters{
+		UserID:   &userID,
+		Statuses: []entity.PaymentStatus{entity.PaymentSucceeded, entity.PaymentRefunded},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("receipt service payments error: %v", err)
+	}
+	if len(orders) == 0 {
+		return []PaymentRecord{}, nil
+	}
+
+	orderIDs := make([]uuid.UUID, 0, len(orders))
+	for _, order := range orders {
+		orderIDs = append(orderIDs, order.OrderID.UUID)
+	}
+	receipts, err := s.repo.Read(repository.ReceiptFilters{OrderIDs: orderIDs})
+	if err != nil {
+		return nil, fmt.Errorf("receipt service payments error: %v", err)
+	}
+	receiptsByOrder := make(map[uuid.UUID]*entity.Receipt, len(receipts))
+	for i := range receipts {
+		receipts[i].FileURL = receiptLink(receipts[i].OrderID)
+		receiptsByOrder[receipts[i].OrderID] = &receipts[i]
+	}
+
+	records := make([]PaymentRecord, 0, len(orders))
+	for _, order := range orders {
+		records = append(records, PaymentRecord{
+			OrderID:        order.OrderID.UUID,
+			CreatedAt:      order.CreatedAt,
+			CourseID:       optionalUUID(order.CourseID),
+			BundleID:       optionalUUID(order.BundleID),
+			SubscriptionID: optionalUUID(order.SubscriptionID),
+			Gift:           order.Gift,
+			Status:         order.Status,
+			Amount:         order.Amount,
+			Discount:       order.Discount,
+			RefundedAmount: order.RefundedAmount,
+			Currency:       order.Currency,
+			Receipt:        receiptsByOrder[order.OrderID.UUID],
+		})
+	}
+
+	return records, nil
+}
+
+func (s *ReceiptService) find(orderID uuid.UUID) (*entity.Receipt, error) {
+	receipts, err := s.repo.Read(repository.ReceiptFilters{OrderIDs: []uuid.UUID{orderID}})
+	if err != nil {
+		return nil, err
+	}
+	if len(receipts) == 0 {
+		return nil, nil
+	}
+
+	return &receipts[0], nil
+}
+
+// create numbers, renders and records a new receipt.
+func (s *ReceiptService) create(ctx context.Context, order *repository.Payment, paidAt time.Time) (*entity.Receipt, *entity.User, error) {
+	number, err := newSerial("RCP", paidAt)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generating number: %v", err)
+	}
+
+	key, buyer, err := s.render(ctx, order, number, paidAt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = s.repo.Create(&repository.ReceiptCreateBody{
+		Number:  number,
+		OrderID: order.OrderID.UUID,
+		UserID:  order.UserID,
+		PaidAt:  paidAt,
+		FileURL: key,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	return &entity.Receipt{
+		CreatedAt: now,
+		UpdatedAt: now,
+		Number:    number,
+		OrderID:   order.OrderID.UUID,
+		UserID:    order.UserID,
+		PaidAt:    paidAt,
+		FileURL:   receiptLink(order.OrderID.UUID),
+	}, buyer, nil
+}
+
+// render renders the receipt PDF in the locale of the buyer and uploads it privately, returning its
+// key. The file of a receipt is always stored under its number so regenerating it replaces the file.
+func (s *ReceiptService) render(ctx context.Context, order *repository.Payment, number string, paidAt time.Time) (string, *entity.User, error) {
+	users, err := s.userRepo.Read(entity.Pagination{Limit: 1}, entity.UserFilters{ID: &order.UserID})
+	if err != nil {
+		return "", nil, err
+	}
+	if len(users) == 0 {
+		return "", nil, fmt.Errorf("buyer not found")
+	}
+	buyer := users[0]
+
+	lines, err := s.lines(order)
+	if err != nil {
+		return "", nil, err
+	}
+
+	content, err := document.Receipt(document.ReceiptData{
+		Number:        number,
+		OrderID:       order.OrderID.UUID.String(),
+		PaidAt:        paidAt,
+		SellerName:    s.seller.Name,
+		SellerBIN:     s.seller.BIN,
+		SellerAddress: s.seller.Address,
+		BuyerName:     buyer.Name,
+		BuyerEmail:    buyer.Email,
+		Lines:         lines,
+		Gift:          order.Gift,
+		Discount:      order.Discount,
+		Total:         order.Amount,
+		Currency:      order.Currency,
+		Locale:        buyer.Locale,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	key, err := s.fileService.PutPrivate(ctx, "receipts/"+number+".pdf", bytes.NewReader(content))
+	if err != nil {
+		return "", nil, fmt.Errorf("uploading pdf: %v", err)
+	}
+
+	return key, &buyer, nil
+}
+
+// receiptLink returns the link to the receipt endpoint for the order, the key of the file never
+// reaches the client.
+func receiptLink(orderID uuid.UUID) string {
+	return fmt.Sprintf("%v/me/payments/receipt?orderId=%v", os.Getenv("APP_URL"), orderID)
+}
+
+// lines lists what the order paid for: the plan of a subscription, or the courses with the part of
+// the total paid for each. Orders made before orders had items only have a course.
+func (s *ReceiptService) lines(order *repository.Payment) ([]document.ReceiptLine, error) {
+	if order.SubscriptionID.Valid {
+		subscriptions, err := s.subscriptionRepo.Read(entity.Pagination{Limit: 1}, repository.SubscriptionFilters{ID: &order.SubscriptionID.UUID})
+		if err != nil {
+			return nil, err
+		}
+		if len(subscriptions) == 0 {
+			return nil, fmt.Errorf("subscription %v not found", order.SubscriptionID.UUID)
+		}
+
+		return []document.ReceiptLine{{Title: subscriptions[0].Plan.Name, Amount: order.Amount}}, nil
+	}
+
+	items, err := s.paymentRepo.Items(order.OrderID.UUID)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 && order.CourseID.Valid {
+		items = append(items, repository.PaymentItem{CourseID: order.CourseID.UUID, Amount: order.Amount})
+	}
+
+	lines := make([]document.ReceiptLine, 0, len(items))
+	for _, item := range items {
+		courses, err := s.courseRepo.Read(entity.Pagination{Limit: 1}, entity.CourseFilters{ID: item.CourseID})
+		if err != nil {
+			return nil, err
+		}
+
+		title := item.CourseID.String()
+		if len(courses) != 0 {
+			title = courses[0].Title
+		}
+
+		lines = append(lines, document.ReceiptLine{Title: title, Amount: item.Amount})
+	}
+
+	return lines, nil
+}
+
+func optionalUUID(id uuid.NullUUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+
+	return &id.UUID
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/AlnurZhanibek/kazusa-server/internal/payment"
+	"github.com/AlnurZhanibek/kazusa-server/internal/repository"
+	"github.com/google/uuid"
+)
+
+var ErrReviewNotFound = errors.New("open payment review not found")
+
+const (
+	ResolveReviewAuditAction = "payment.review.resolve"
+	ReconciliationLockName   = "kazusa.payment_reconciliation"
+
+	reconciliationBatchSize    = 100
+	defaultReconcileInterval   = 5 * time.Minute
+	defaultReconcilePendingFor = 15 * time.Minute
+	defaultReconcileStuckFor   = 24 * time.Hour
+)
+
+// ReconciliationService catches up with orders whose provider callback was lost: it periodically
+// asks the provider for the status of orders that have been waiting for longer than
+// RECONCILIATION_PENDING_AFTER and applies it the same way a callback would be applied. Orders the
+// provider status can not be applied to are flagged for review, and so are orders the provider
+// still can not be asked about after RECONCILIATION_STUCK_AFTER. Orders the provider still reports
+// as created or pending by then were abandoned at the checkout and are cancelled, at the provider
+// first, and a payment that still reaches such an order is flagged by ApplyCallback. Flagged orders
+// are left to an admin, so every order eventually leaves the batch. Only the replica holding the
+// leader lock runs the job.
+type ReconciliationService struct {
+	paymentService *PaymentService
+	paymentRepo    *repository.PaymentRepository
+	repo           *repository.PaymentReviewRepository
+	auditRepo      *repository.AuditRepository
+	lock           *repository.LeaderLock
+	interval       time.Duration
+	pendingFor     time.Duration
+	stuckFor       time.Duration
+}
+
+func NewReconciliationService(paymentService *PaymentService, paymentRepo *repository.PaymentRepository, repo *repository.PaymentReviewRepository, auditRepo *repository.AuditRepository, lock *repository.LeaderLock) *ReconciliationService {
+	return &ReconciliationService{
+		paymentService: paymentService,
+		paymentRepo:    paymentRepo,
+		repo:           repo,
+		auditRepo:      auditRepo,
+		lock:           lock,
+		interval:       durationFromEnv("RECONCILIATION_INTERVAL", defaultReconcileInterval),
+		pendingFor:     durationFromEnv("RECONCILIATION_PENDING_AFTER", defaultReconcilePendingFor),
+		stuckFor:       durationFromEnv("RECONCILIATION_STUCK_AFTER", defaultReconcileStuckFor),
+	}
+}
+
+// Run reconciles stale orders until the context is cancelled, an interval of zero disables it.
+func (s *ReconciliationService) Run(ctx context.Context) {
+	if s.interval <= 0 {
+		return
+	}
+	defer s.lock.Release()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		leader, err := s.lock.Acquire(ctx)
+		if err != nil {
+			log.Printf("payment reconciliation: %v", err)
+		}
+		if leader {
+			s.reconcile(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ReconciliationService) reconcile(ctx context.Context) {
+	provider := s.paymentService.Provider()
+
+	orders, err := s.paymentRepo.Stale(time.Now().Add(-s.pendingFor), provider.Name(), reconciliationBatchSize)
+	if err != nil {
+		log.Printf("payment reconciliation: %v", err)
+		return
+	}
+
+	for i := range orders {
+		if ctx.Err() != nil {
+			return
+		}
+		s.reconcileOrder(ctx, provider, &orders[i])
+	}
+}
+
+func (s *ReconciliationService) reconcileOrder(ctx context.Context, provider payment.Provider, order *repository.Payment) {
+	orderID := order.OrderID.UUID
+
+	queryCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	status, err := provider.QueryStatus(queryCtx, payment.Reference{OrderID: orderID, ProviderPaymentID: order.ProviderPaymentID.String})
+	cancel()
+
+	switch {
+	case errors.Is(err, payment.ErrProviderRejected) && order.Status == entity.PaymentCreated:
+		// the checkout was never created, so nobody could have paid the order
+		status = entity.PaymentCancelled
+	case errors.Is(err, payment.ErrProviderRejected):
+		s.flag(order, fmt.Sprintf("provider does not know the order: %v", err), "")
+		return
+	case err != nil && s.stuck(order):
+		s.flag(order, fmt.Sprintf("provider could not be asked about the order for %v: %v", s.stuckFor, err), "")
+		return
+	case err != nil:
+		log.Printf("payment reconciliation: querying order %v: %v", orderID, err)
+		return
+	case !status.Valid():
+		s.flag(order, "provider reported an unknown status", string(status))
+		return
+	case status == order.Status && s.stuck(order):
+		// the buyer never finished the checkout, it is closed first so it can not be paid later
+		cancelCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		err = provider.Cancel(cancelCtx, payment.Reference{OrderID: orderID, ProviderPaymentID: order.ProviderPaymentID.String})
+		cancel()
+		if err != nil {
+			s.flag(order, fmt.Sprintf("abandoned checkout could not be cancelled at the provider: %v", err), string(status))
+			return
+		}
+		status = entity.PaymentCancelled
+	case status == order.Status:
+		return
+	}
+
+	payload, _ := json.Marshal(map[string]any{
+		"source":     "reconciliation",
+		"status":     status,
+		"checked_at": time.Now(),
+	})
+	err = s.paymentService.UpdateStatus(orderID, status, string(payload))
+	switch {
+	case errors.Is(err, ErrInvalidPaymentTransition):
+		s.flag(order, fmt.Sprintf("provider reports %v, the order can not move there from %v", status, order.Status), string(status))
+	case err != nil:
+		log.Printf("payment reconciliation: applying %v to order %v: %v", status, orderID, err)
+	default:
+		log.Printf("payment reconciliation: order %v moved from %v to %v", orderID, order.Status, status)
+	}
+}
+
+// stuck reports whether the order has been waiting for longer than RECONCILIATION_STUCK_AFTER.
+func (s *ReconciliationService) stuck(order *repository.Payment) bool {
+	return order.CreatedAt.Before(time.Now().Add(-s.stuckFor))
+}
+
+func (s *ReconciliationService) flag(order *repository.Payment, reason string, providerStatus string) {
+	err := s.repo.Flag(order.OrderID.UUID, reason, order.Status, providerStatus)
+	if err != nil {
+		log.Printf("payment reconciliation: flagging order %v: %v", order.OrderID.UUID, err)
+		return
+	}
+
+	log.Printf("payment reconciliation: order %v flagged for review: %v", order.OrderID.UUID, reason)
+}
+
+func (s *ReconciliationService) Reviews(pagination entity.Pagination, openOnly bool) ([]entity.PaymentReview, error) {
+	reviews, err := s.repo.Read(pagination, openOnly)
+	if err != nil {
+		return nil, fmt.Errorf("reconciliation service reviews error: %v", err)
+	}
+
+	return reviews, nil
+}
+
+// Resolve closes the review of the order once an admin has sorted it out, for example by refunding
+// the order or granting the course. Resolved orders are not reconciled again. The admin is taken
+// from the context and the resolution is written to the audit log.
+func (s *ReconciliationService) Resolve(ctx context.Context, orderID uuid.UUID, note string) error {
+	operatorID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("reconciliation service resolve error: operator is unknown")
+	}
+
+	resolved, err := s.repo.Resolve(orderID, operatorID, note)
+	if err != nil {
+		return fmt.Errorf("reconciliation service resolve error: %v", err)
+	}
+	if !resolved {
+		return fmt.Errorf("reconciliation service resolve error: %w", ErrReviewNotFound)
+	}
+
+	err = s.auditRepo.Create(&repository.AuditEntry{
+		ActorID:    operatorID,
+		Action:     ResolveReviewAuditAction,
+		EntityType: "payment",
+		EntityID:   orderID.String(),
+		Details:    map[string]any{"note": note},
+	})
+	if err != nil {
+		log.Printf("reconciliation service failed to audit resolution of order %v: %v", orderID, err)
+	}
+
+	return nil
+}
+
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("invalid %v %q, using %v: %v", name, value, fallback, err)
+		return fallback
+	}
+
+	return duration
+}
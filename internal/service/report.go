@@ -0,0 +1,149 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/AlnurZhanibek/kazusa-server/internal/repository"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidReport = errors.New("invalid report")
+
+const (
+	ReportByDay   = "day"
+	ReportByWeek  = "week"
+	ReportByMonth = "month"
+
+	// defaultReportDays is the range of a report without dates, maxReportDays keeps daily reports
+	// of a few years from being requested by accident.
+	defaultReportDays = 30
+	maxReportDays     = 3 * 366
+)
+
+type ReportService struct {
+	repo *repository.ReportRepository
+}
+
+func NewReportService(repo *repository.ReportRepository) *ReportService {
+	return &ReportService{repo: repo}
+}
+
+type RevenueQuery struct {
+	// From and To are dates, both inclusive.
+	From     *time.Time
+	To       *time.Time
+	CourseID *uuid.UUID
+	// Statuses default to succeeded and refunded, the orders that were paid.
+	Statuses []entity.PaymentStatus
+	GroupBy  string
+}
+
+type RevenueRow struct {
+	// Period is the first day of the day, week or month, it is empty in totals.
+	Period   string `json:"period,omitempty"`
+	Currency string `json:"currency"`
+	Orders   int64  `json:"orders"`
+	Gross    int64  `json:"gross"`
+	Discount int64  `json:"discount"`
+	Refunded int64  `json:"refunded"`
+	// Net is the gross amount less refunds.
+	Net int64 `json:"net"`
+} // @name RevenueRow
+
+type RevenueReport struct {
+	From     string                 `json:"from"`
+	To       string                 `json:"to"`
+	CourseID *uuid.UUID             `json:"courseId,omitempty"`
+	Statuses []entity.PaymentStatus `json:"statuses"`
+	GroupBy  string                 `json:"groupBy"`
+	Rows     []RevenueRow           `json:"rows"`
+	// Totals has a row per currency, amounts in different currencies are never added up.
+	Totals []RevenueRow `json:"totals"`
+} // @name RevenueReport
+
+// Revenue sums orders by the day, week or month they were created in. Gross is what buyers paid
+// after discounts and Net what is left after refunds.
+func (s *ReportService) Revenue(query RevenueQuery) (*RevenueReport, error) {
+	if query.GroupBy == "" {
+		query.GroupBy = ReportByDay
+	}
+	if query.GroupBy != ReportByDay && query.GroupBy != ReportByWeek && query.GroupBy != ReportByMonth {
+		return nil, fmt.Errorf("report service revenue error: %w, unknown grouping %q", ErrInvalidReport, query.GroupBy)
+	}
+	if len(query.Statuses) == 0 {
+		query.Statuses = []entity.PaymentStatus{entity.PaymentSucceeded, entity.PaymentRefunded}
+	}
+	for _, status := range query.Statuses {
+		if !status.Valid() {
+			return nil, fmt.Errorf("report service revenue error: %w, unknown status %q", ErrInvalidReport, status)
+		}
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	to := today
+	if query.To != nil {
+		to = *query.To
+	}
+	from := to.AddDate(0, 0, -defaultReportDays+1)
+	if query.From != nil {
+		from = *query.From
+	}
+	switch {
+	case to.Before(from):
+		return nil, fmt.Errorf("report service revenue error: %w, the range ends before it starts", ErrInvalidReport)
+	case to.Sub(from) > maxReportDays*24*time.Hour:
+		return nil, fmt.Errorf("report service revenue error: %w, the range is longer than %v days", ErrInvalidReport, maxReportDays)
+	}
+
+	rows, err := s.repo.Revenue(repository.RevenueFilters{
+		From:     from,
+		To:       to.AddDate(0, 0, 1),
+		CourseID: query.CourseID,
+		Statuses: query.Statuses,
+		Period:   query.GroupBy,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("report service revenue error: %v", err)
+	}
+
+	report := &RevenueReport{
+		From:     from.Format(time.DateOnly),
+		To:       to.Format(time.DateOnly),
+		CourseID: query.CourseID,
+		Statuses: query.Statuses,
+		GroupBy:  query.GroupBy,
+		Rows:     make([]RevenueRow, 0, len(rows)),
+		Totals:   make([]RevenueRow, 0),
+	}
+
+	totals := make(map[string]int)
+	for _, row := range rows {
+		revenueRow := RevenueRow{
+			Period:   row.Period,
+			Currency: row.Currency,
+			Orders:   row.Orders,
+			Gross:    row.Gross,
+			Discount: row.Discount,
+			Refunded: row.Refunded,
+			Net:      row.Gross - row.Refunded,
+		}
+		report.Rows = append(report.Rows, revenueRow)
+
+		i, ok := totals[row.Currency]
+		if !ok {
+			i = len(report.Totals)
+			totals[row.Currency] = i
+			report.Totals = append(report.Totals, RevenueRow{Currency: row.Currency})
+		}
+		report.Totals[i].Orders += revenueRow.Orders
+		report.Totals[i].Gross += revenueRow.Gross
+		report.Totals[i].Discount += revenueRow.Discount
+		report.Totals[i].Refunded += revenueRow.Refunded
+		report.Totals[i].Net += revenueRow.Net
+	}
+
+	return report, nil
+}
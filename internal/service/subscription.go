@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/AlnurZhanibek/kazusa-server/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrPlanNotFound         = errors.New("plan not found")
+	ErrInvalidPlan          = errors.New("invalid plan")
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+	ErrAlreadySubscribed    = errors.New("already subscribed to another plan")
+)
+
+type SubscriptionService struct {
+	repo     *repository.SubscriptionRepository
+	planRepo *repository.PlanRepository
+}
+
+func NewSubscriptionService(repo *repository.SubscriptionRepository, planRepo *repository.PlanRepository) *SubscriptionService {
+	return &SubscriptionService{repo: repo, planRepo: planRepo}
+}
+
+func (s *SubscriptionService) CreatePlan(plan entity.NewPlan) (uuid.UUID, error) {
+	plan.Currency = strings.ToUpper(strings.TrimSpace(plan.Currency))
+	if plan.Currency == "" {
+		plan.Currency = entity.DefaultCurrency
+	}
+
+	switch {
+	case strings.TrimSpace(plan.Name) == "":
+		return uuid.Nil, fmt.Errorf("subscription service create plan error: %w, name is empty", ErrInvalidPlan)
+	case !plan.Interval.Valid():
+		return uuid.Nil, fmt.Errorf("subscription service create plan error: %w, interval should be %v or %v", ErrInvalidPlan, entity.MonthlyPlan, entity.AnnualPlan)
+	case plan.Price < 0 || plan.GraceDays < 0:
+		return uuid.Nil, fmt.Errorf("subscription service create plan error: %w, price and grace days can not be negative", ErrInvalidPlan)
+	}
+
+	id, err := s.planRepo.Create(plan)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("subscription service create plan error: %v", err)
+	}
+
+	return id, nil
+}
+
+// ReadPlans returns the plans on sale, or every plan including the deactivated ones for admins.
+func (s *SubscriptionService) ReadPlans(pagination entity.Pagination, all bool) ([]entity.Plan, error) {
+	plans, err := s.planRepo.Read(pagination, repository.PlanFilters{ActiveOnly: !all})
+	if err != nil {
+		return nil, fmt.Errorf("subscription service read plans error: %v", err)
+	}
+
+	return plans, nil
+}
+
+// DeletePlan takes the plan off sale, current subscribers keep it until their period ends but can
+// not renew.
+func (s *SubscriptionService) DeletePlan(id uuid.UUID) error {
+	err := s.planRepo.Deactivate(id)
+	if err != nil {
+		return fmt.Errorf("subscription service delete plan error: %v", err)
+	}
+
+	return nil
+}
+
+// Current returns the latest subscription of the user with its status as of now. A paid
+// subscription is preferred over a newer one still waiting for its first payment.
+func (s *SubscriptionService) Current(userID uuid.UUID) (*entity.Subscription, error) {
+	subscription, err := s.latest(userID, true)
+	if err == nil && subscription == nil {
+		subscription, err = s.latest(userID, false)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("subscription service current error: %v", err)
+	}
+	if subscription == nil {
+		return nil, fmt.Errorf("subscription service current error: %w", ErrSubscriptionNotFound)
+	}
+
+	now := time.Now()
+	if subscription.CurrentPeriodEnd != nil && !subscription.CancelAtPeriodEnd && subscription.Plan.GraceDays > 0 {
+		graceUntil := subscription.CurrentPeriodEnd.AddDate(0, 0, subscription.Plan.GraceDays)
+		subscription.GraceUntil = &graceUntil
+	}
+	subscription.Status = subscription.State(now)
+
+	return subscription, nil
+}
+
+// HasAccess reports whether the user has a subscription that unlocks the whole catalog.
+func (s *SubscriptionService) HasAccess(userID uuid.UUID) (bool, error) {
+	subscription, err := s.latest(userID, true)
+	if err != nil {
+		return false, fmt.Errorf("subscription service has access error: %v", err)
+	}
+
+	return subscription != nil && subscription.GrantsAccess(time.Now()), nil
+}
+
+// Cancel stops the renewal of the current subscription of the user, it stays active until the end
+// of the paid period and gets no grace period after it.
+func (s *SubscriptionService) Cancel(userID uuid.UUID) (*entity.Subscription, error) {
+	subscription, err := s.latest(userID, true)
+	if err != nil {
+		return nil, fmt.Errorf("subscription service cancel error: %v", err)
+	}
+	if subscription == nil || !subscription.GrantsAccess(time.Now()) {
+		return nil, fmt.Errorf("subscription service cancel error: %w", ErrSubscriptionNotFound)
+	}
+
+	if !subscription.CancelAtPeriodEnd {
+		err = s.repo.Cancel(subscription.ID, time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("subscription service cancel error: %v", err)
+		}
+	}
+
+	return s.Current(userID)
+}
+
+// prepare returns the subscription an order for the plan pays for and the plan itself. While the
+// user has a subscription to the plan that grants access the order renews it, otherwise a new
+// pending subscription is created.
+func (s *SubscriptionService) prepare(userID uuid.UUID, planID uuid.UUID) (uuid.UUID, *entity.Plan, error) {
+	plans, err := s.planRepo.Read(entity.Pagination{Limit: 1}, repository.PlanFilters{ID: &planID, ActiveOnly: true})
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	if len(plans) == 0 {
+		return uuid.Nil, nil, ErrPlanNotFound
+	}
+	plan := plans[0]
+
+	current, err := s.latest(userID, true)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	if current != nil && current.GrantsAccess(time.Now()) {
+		if current.Plan.ID != plan.ID {
+			return uuid.Nil, nil, ErrAlreadySubscribed
+		}
+		return current.ID, &plan, nil
+	}
+
+	id, err := s.repo.Create(userID, plan.ID)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	return id, &plan, nil
+}
+
+// Extend adds a paid period to the subscription. A renewal paid while the subscription still grants
+// access continues from the end of the current period, otherwise the new period starts now.
+func (s *SubscriptionService) Extend(id uuid.UUID) error {
+	subscriptions, err := s.repo.Read(entity.Pagination{Limit: 1}, repository.SubscriptionFilters{ID: &id})
+	if err != nil {
+		return fmt.Errorf("subscription service extend error: %v", err)
+	}
+	if len(subscriptions) == 0 {
+		return fmt.Errorf("subscription service extend error: %w", ErrSubscriptionNotFound)
+	}
+	subscription := subscriptions[0]
+
+	start := time.Now()
+	if subscription.GrantsAccess(start) && subscription.CurrentPeriodEnd != nil {
+		start = *subscription.CurrentPeriodEnd
+	}
+
+	err = s.repo.SetPeriod(id, start, subscription.Plan.Interval.Next(start))
+	if err != nil {
+		return fmt.Errorf("subscription service extend error: %v", err)
+	}
+
+	return nil
+}
+
+// End ends the subscription right away.
+func (s *SubscriptionService) End(id uuid.UUID) error {
+	err := s.repo.End(id, time.Now())
+	if err != nil {
+		return fmt.Errorf("subscription service end error: %v", err)
+	}
+
+	return nil
+}
+
+func (s *SubscriptionService) latest(userID uuid.UUID, paid bool) (*entity.Subscription, error) {
+	subscriptions, err := s.repo.Read(entity.Pagination{Limit: 1}, repository.SubscriptionFilters{UserID: &userID, Paid: paid})
+	if err != nil {
+		return nil, err
+	}
+	if len(subscriptions) == 0 {
+		return nil, nil
+	}
+
+	return &subscriptions[0], nil
+}
+
+// Subscribe creates an order for a period of the plan, see SubscriptionService.prepare for whether
+// it starts a new subscription or renews the current one. The period is added once the order is
+// paid, which the payment webhook reports like for any other order.
+func (s *PaymentService) Subscribe(ctx context.Context, userID uuid.UUID, planID uuid.UUID, couponCode string) (*Order, error) {
+	user, err := s.buyer(userID)
+	if err != nil {
+		return nil, fmt.Errorf("payment service subscribe error: %w", err)
+	}
+
+	subscriptionID, plan, err := s.subscriptionService.prepare(userID, planID)
+	if err != nil {
+		return nil, fmt.Errorf("payment service subscribe error: %w", err)
+	}
+
+	discount, coupon, err := s.couponService.Discount(userID, couponCode, plan.Price, plan.Currency, nil)
+	if err != nil {
+		return nil, fmt.Errorf("payment service subscribe error: %w", err)
+	}
+
+	quote := &CartQuote{
+		Items:       make([]CartItem, 0),
+		Price:       plan.Price,
+		Discount:    discount,
+		Total:       plan.Price - discount,
+		Currency:    plan.Currency,
+		description: plan.Name,
+	}
+	if coupon != nil {
+		quote.CouponCode = coupon.Code
+		quote.couponID = &coupon.ID
+	}
+
+	order, err := s.placeOrder(ctx, user, &Order{SubscriptionID: &subscriptionID}, quote)
+	if err != nil {
+		return nil, fmt.Errorf("payment service subscribe error: %v", err)
+	}
+
+	return order, nil
+}
@@ -0,0 +1,459 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AlnurZhanibek/kazusa-server/internal/entity"
+	"github.com/AlnurZhanibek/kazusa-server/internal/repository"
+	"github.com/AlnurZhanibek/kazusa-server/internal/storage"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUploadNotFound = errors.New("upload not found")
+	ErrInvalidUpload  = errors.New("invalid upload")
+	ErrUploadMismatch = errors.New("uploaded file does not match the upload")
+)
+
+const (
+	UploadCleanupLockName = "kazusa.upload_cleanup"
+
+	// multipartThreshold is the size above which files are uploaded in parts, a single PUT request
+	// can carry up to 5 GiB but a failed one has to be sent again from the start.
+	multipartThreshold = 100 << 20
+	// minPartSize is the smallest part S3 accepts, only the last part may be shorter.
+	minPartSize                = 5 << 20
+	maxParts                   = 10000
+	maxUploadSize              = 50 << 30
+	uploadCleanupBatch         = 100
+	defaultUploadTTL           = 24 * time.Hour
+	defaultUploadCleanup       = time.Hour
+	defaultUploadVerifyTimeout = time.Hour
+)
+
+// UploadService lets admins send large files, such as lecture videos, straight to the storage
+// through presigned URLs instead of through this server. The URLs carry the declared SHA-256
+// checksums, so the storage refuses a body that does not match, and the completion compares the
+// size and the checksum the storage keeps before the upload is registered as an attachment of the
+// course; the file is never read back. Uploads not completed within UPLOAD_URL_TTL, and completions
+// that did not finish within UPLOAD_VERIFY_TIMEOUT, are dropped by Run. Only the replica holding
+// the leader lock runs the cleanup.
+type UploadService struct {
+	repo          *repository.UploadRepository
+	courseRepo    *repository.CourseRepository
+	fileService   *FileService
+	storage       storage.Storage
+	lock          *repository.LeaderLock
+	ttl           time.Duration
+	interval      time.Duration
+	verifyTimeout time.Duration
+}
+
+func NewUploadService(repo *repository.UploadRepository, courseRepo *repository.CourseRepository, fileService *FileService, storage storage.Storage, lock *repository.LeaderLock) *UploadService {
+	return &UploadService{
+		repo:          repo,
+		courseRepo:    courseRepo,
+		fileService:   fileService,
+		storage:       storage,
+		lock:          lock,
+		ttl:           durationFromEnv("UPLOAD_URL_TTL", defaultUploadTTL),
+		interval:      durationFromEnv("UPLOAD_CLEANUP_INTERVAL", defaultUploadCleanup),
+		verifyTimeout: durationFromEnv("UPLOAD_VERIFY_TIMEOUT", defaultUploadVerifyTimeout),
+	}
+}
+
+type NewUpload struct {
+	CourseID    uuid.UUID `json:"courseId"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	// Checksum is the hex encoded SHA-256 of the whole file, it is required for files sent with a
+	// single request.
+	Checksum string `json:"checksum"`
+	// PartSize and PartChecksums are required for files larger than 100 MiB, which are sent in parts.
+	// Every part but the last has PartSize bytes, at least 5 MiB, and PartChecksums lists the hex
+	// encoded SHA-256 of every part in order.
+	PartSize      int64    `json:"partSize"`
+	PartChecksums []string `json:"partChecksums"`
+} // @name NewUpload
+
+type UploadPartURL struct {
+	Number int32  `json:"number"`
+	URL    string `json:"url"`
+} // @name UploadPartURL
+
+// UploadSession tells the client where to send the file. Small files are sent with a single PUT
+// request to URL. Larger ones are split into parts of PartSize bytes, the last one may be shorter,
+// each sent with a PUT request to the URL of its number. The URLs only accept a body with the
+// declared checksum. The ETag header of every part response has to be passed to the completion.
+type UploadSession struct {
+	Upload   *entity.Upload  `json:"upload"`
+	URL      string          `json:"url,omitempty"`
+	PartSize int64           `json:"partSize,omitempty"`
+	Parts    []UploadPartURL `json:"parts,omitempty"`
+} // @name UploadSession
+
+func (s *UploadService) Create(ctx context.Context, upload NewUpload) (*UploadSession, error) {
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("upload service create error: uploader is unknown")
+	}
+
+	upload.Filename = strings.TrimSpace(upload.Filename)
+	switch {
+	case upload.CourseID == uuid.Nil || upload.Filename == "":
+		return nil, fmt.Errorf("upload service create error: %w, course and file name are required", ErrInvalidUpload)
+	case upload.Size <= 0 || upload.Size > maxUploadSize:
+		return nil, fmt.Errorf("upload service create error: %w, size has to be between 1 and %v bytes", ErrInvalidUpload, int64(maxUploadSize))
+	}
+	checksum, err := uploadChecksum(&upload)
+	if err != nil {
+		return nil, fmt.Errorf("upload service create error: %w, %v", ErrInvalidUpload, err)
+	}
+	if upload.ContentType == "" {
+		upload.ContentType = mime.TypeByExtension(filepath.Ext(upload.Filename))
+	}
+	if upload.ContentType == "" {
+		upload.ContentType = "application/octet-stream"
+	}
+
+	courses, err := s.courseRepo.Read(entity.Pagination{Limit: 1}, entity.CourseFilters{ID: upload.CourseID})
+	if err != nil {
+		return nil, fmt.Errorf("upload service create error: %v", err)
+	}
+	if len(courses) == 0 {
+		return nil, fmt.Errorf("upload service create error: %w", ErrCourseNotFound)
+	}
+
+	key := courseAttachmentKey(upload.CourseID, upload.Filename)
+	err = s.fileService.Track(key, upload.CourseID, AttachmentFilePurpose)
+	if err != nil {
+		return nil, fmt.Errorf("upload service create error: %v", err)
+	}
+
+	session, err := s.start(ctx, &repository.UploadCreateBody{
+		CreatedBy:     userID,
+		CourseID:      upload.CourseID,
+		Key:           key,
+		Filename:      upload.Filename,
+		ContentType:   upload.ContentType,
+		Size:          upload.Size,
+		Checksum:      checksum,
+		PartSize:      upload.PartSize,
+		PartChecksums: upload.PartChecksums,
+		ExpiresAt:     time.Now().Add(s.ttl),
+	})
+	if err != nil {
+		if releaseErr := s.fileService.Release(key); releaseErr != nil {
+			log.Printf("upload service failed to release %v: %v", key, releaseErr)
+		}
+		return nil, fmt.Errorf("upload service create error: %v", err)
+	}
+
+	return session, nil
+}
+
+// start presigns the URLs of the upload and records it.
+func (s *UploadService) start(ctx context.Context, body *repository.UploadCreateBody) (*UploadSession, error) {
+	session := &UploadSession{}
+
+	var err error
+	if len(body.PartChecksums) == 0 {
+		session.URL, err = s.storage.PresignPut(ctx, body.Key, body.ContentType, base64Checksum(body.Checksum), s.ttl)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		body.MultipartID, err = s.storage.CreateMultipart(ctx, body.Key, body.ContentType)
+		if err != nil {
+			return nil, err
+		}
+
+		session.PartSize = body.PartSize
+		session.Parts = make([]UploadPartURL, len(body.PartChecksums))
+		for i := range session.Parts {
+			number := int32(i + 1)
+			url, err := s.storage.PresignPart(ctx, body.Key, body.MultipartID, number, base64Checksum(body.PartChecksums[i]), s.ttl)
+			if err != nil {
+				s.storage.AbortMultipart(ctx, body.Key, body.MultipartID)
+				return nil, err
+			}
+			session.Parts[i] = UploadPartURL{Number: number, URL: url}
+		}
+	}
+
+	session.Upload, err = s.repo.Create(body)
+	if err != nil {
+		s.storage.AbortMultipart(ctx, body.Key, body.MultipartID)
+		return nil, err
+	}
+
+	return session, nil
+}
+
+type UploadCompletion struct {
+	ID uuid.UUID `json:"id"`
+	// Parts are required for multipart uploads.
+	Parts []storage.Part `json:"parts"`
+} // @name UploadCompletion
+
+// Complete assembles the uploaded file, checks the size and the checksum the storage keeps for it
+// against the ones given when the upload was created and adds it to the attachments of the course. A file that does not match is
+// deleted and the upload fails with ErrUploadMismatch, an upload whose file is not in the storage
+// yet stays pending.
+func (s *UploadService) Complete(ctx context.Context, completion UploadCompletion) (*entity.Upload, error) {
+	upload, err := s.pending(completion.ID)
+	if err != nil {
+		return nil, fmt.Errorf("upload service complete error: %w", err)
+	}
+	if upload.MultipartID != "" && len(completion.Parts) == 0 {
+		return nil, fmt.Errorf("upload service complete error: %w, parts are required", ErrInvalidUpload)
+	}
+
+	claimed, err := s.repo.SetStatus(upload.ID, entity.UploadPending, entity.UploadVerifying)
+	if err != nil {
+		return nil, fmt.Errorf("upload service complete error: %v", err)
+	}
+	if !claimed {
+		return nil, fmt.Errorf("upload service complete error: %w", ErrUploadNotFound)
+	}
+
+	status, err := s.verify(ctx, upload, completion.Parts)
+	if status != entity.UploadCompleted {
+		_, statusErr := s.repo.SetStatus(upload.ID, entity.UploadVerifying, status)
+		if statusErr != nil {
+			log.Printf("upload service failed to set status of upload %v to %v: %v", upload.ID, status, statusErr)
+		}
+		return nil, fmt.Errorf("upload service complete error: %w", err)
+	}
+
+	added, err := s.courseRepo.AddAttachment(upload.CourseID, upload.Key)
+	if err == nil && !added {
+		err = ErrCourseNotFound
+	}
+	if err != nil {
+		s.repo.SetStatus(upload.ID, entity.UploadVerifying, entity.UploadPending)
+		return nil, fmt.Errorf("upload service complete error: %w", err)
+	}
+
+	_, err = s.repo.SetStatus(upload.ID, entity.UploadVerifying, entity.UploadCompleted)
+	if err != nil {
+		return nil, fmt.Errorf("upload service complete error: %v", err)
+	}
+
+	return s.repo.Read(upload.ID)
+}
+
+// verify returns the status the upload moves to: completed when the file matches, failed when it
+// does not, and pending again when it could not be checked.
+func (s *UploadService) verify(ctx context.Context, upload *entity.Upload, parts []storage.Part) (entity.UploadStatus, error) {
+	if upload.MultipartID != "" {
+		if len(parts) != len(upload.PartChecksums) {
+			return entity.UploadPending, fmt.Errorf("%w, %v parts were given instead of %v", ErrInvalidUpload, len(parts), len(upload.PartChecksums))
+		}
+
+		parts = append([]storage.Part(nil), parts...)
+		sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+		for i := range parts {
+			if parts[i].Number != int32(i+1) {
+				return entity.UploadPending, fmt.Errorf("%w, parts have to be numbered from 1 to %v", ErrInvalidUpload, len(parts))
+			}
+			parts[i].ChecksumSHA256 = base64Checksum(upload.PartChecksums[i])
+		}
+
+		err := s.storage.CompleteMultipart(ctx, upload.Key, upload.MultipartID, parts)
+		if err != nil {
+			return entity.UploadPending, fmt.Errorf("%w, assembling the parts: %v", ErrInvalidUpload, err)
+		}
+	}
+
+	info, err := s.storage.Stat(ctx, upload.Key)
+	if errors.Is(err, storage.ErrNotFound) && upload.MultipartID == "" {
+		return entity.UploadPending, fmt.Errorf("%w, the file has not been uploaded", ErrInvalidUpload)
+	}
+	if err != nil {
+		return entity.UploadPending, err
+	}
+	if info.Size != upload.Size {
+		return s.reject(ctx, upload, fmt.Errorf("%w, %v bytes were uploaded instead of %v", ErrUploadMismatch, info.Size, upload.Size))
+	}
+
+	// the checksum of an object assembled from parts ends with the number of parts
+	encoded, _, _ := strings.Cut(info.ChecksumSHA256, "-")
+	digest, err := base64.StdEncoding.DecodeString(encoded)
+	if encoded == "" || err != nil {
+		return s.reject(ctx, upload, fmt.Errorf("%w, the storage keeps no checksum of the file", ErrUploadMismatch))
+	}
+	if checksum := hex.EncodeToString(digest); checksum != upload.Checksum {
+		return s.reject(ctx, upload, fmt.Errorf("%w, the checksum is %v", ErrUploadMismatch, checksum))
+	}
+
+	return entity.UploadCompleted, nil
+}
+
+// uploadChecksum checks the declared checksums and returns the checksum of the upload, for a file
+// sent in parts the SHA-256 of the concatenated digests of the parts.
+func uploadChecksum(upload *NewUpload) (string, error) {
+	if upload.Size <= multipartThreshold {
+		upload.PartSize, upload.PartChecksums = 0, nil
+		upload.Checksum = strings.ToLower(strings.TrimSpace(upload.Checksum))
+		_, err := decodeChecksum(upload.Checksum)
+		return upload.Checksum, err
+	}
+
+	if upload.PartSize < minPartSize {
+		return "", fmt.Errorf("files larger than %v bytes are sent in parts of at least %v bytes", multipartThreshold, minPartSize)
+	}
+	parts := (upload.Size + upload.PartSize - 1) / upload.PartSize
+	if parts > maxParts {
+		return "", fmt.Errorf("a file can not be sent in more than %v parts", maxParts)
+	}
+	if int64(len(upload.PartChecksums)) != parts {
+		return "", fmt.Errorf("%v part checksums were given for %v parts", len(upload.PartChecksums), parts)
+	}
+
+	digests := sha256.New()
+	for i := range upload.PartChecksums {
+		upload.PartChecksums[i] = strings.ToLower(strings.TrimSpace(upload.PartChecksums[i]))
+		digest, err := decodeChecksum(upload.PartChecksums[i])
+		if err != nil {
+			return "", fmt.Errorf("part %v: %v", i+1, err)
+		}
+		digests.Write(digest)
+	}
+
+	return hex.EncodeToString(digests.Sum(nil)), nil
+}
+
+func decodeChecksum(checksum string) ([]byte, error) {
+	digest, err := hex.DecodeString(checksum)
+	if err != nil || len(digest) != sha256.Size {
+		return nil, fmt.Errorf("checksum has to be a hex encoded SHA-256")
+	}
+
+	return digest, nil
+}
+
+// base64Checksum turns a hex encoded checksum into the base64 encoding the storage uses.
+func base64Checksum(checksum string) string {
+	digest, _ := hex.DecodeString(checksum)
+	return base64.StdEncoding.EncodeToString(digest)
+}
+
+// reject deletes a file that does not match its upload, the parts of a multipart upload are
+// already gone once it is assembled.
+func (s *UploadService) reject(ctx context.Context, upload *entity.Upload, reason error) (entity.UploadStatus, error) {
+	err := s.storage.Delete(ctx, upload.Key)
+	if err != nil {
+		log.Printf("upload service failed to delete rejected upload %v: %v", upload.ID, err)
+	}
+
+	err = s.fileService.Release(upload.Key)
+	if err != nil {
+		log.Printf("upload service failed to release rejected upload %v: %v", upload.ID, err)
+	}
+
+	return entity.UploadFailed, reason
+}
+
+// Abort drops a pending upload and whatever was uploaded for it.
+func (s *UploadService) Abort(ctx context.Context, id uuid.UUID) error {
+	upload, err := s.pending(id)
+	if err != nil {
+		return fmt.Errorf("upload service abort error: %w", err)
+	}
+
+	err = s.drop(ctx, upload, entity.UploadAborted)
+	if err != nil {
+		return fmt.Errorf("upload service abort error: %v", err)
+	}
+
+	return nil
+}
+
+func (s *UploadService) pending(id uuid.UUID) (*entity.Upload, error) {
+	upload, err := s.repo.Read(id)
+	if err != nil {
+		return nil, err
+	}
+	if upload == nil || upload.Status != entity.UploadPending {
+		return nil, ErrUploadNotFound
+	}
+
+	return upload, nil
+}
+
+// drop moves the upload on from the status it was read in and releases its file.
+func (s *UploadService) drop(ctx context.Context, upload *entity.Upload, status entity.UploadStatus) error {
+	dropped, err := s.repo.SetStatus(upload.ID, upload.Status, status)
+	if err != nil || !dropped {
+		return err
+	}
+
+	// the file collection deletes the object should it have been uploaded
+	err = s.fileService.Release(upload.Key)
+	if err != nil {
+		return err
+	}
+
+	return s.storage.AbortMultipart(ctx, upload.Key, upload.MultipartID)
+}
+
+// Run drops expired uploads and uploads stuck verifying until the context is cancelled, an interval
+// of zero disables it.
+func (s *UploadService) Run(ctx context.Context) {
+	if s.interval <= 0 {
+		return
+	}
+	defer s.lock.Release()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		leader, err := s.lock.Acquire(ctx)
+		if err != nil {
+			log.Printf("upload cleanup: %v", err)
+		}
+		if leader {
+			s.cleanup(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *UploadService) cleanup(ctx context.Context) {
+	now := time.Now()
+	uploads, err := s.repo.Expired(now, now.Add(-s.verifyTimeout), uploadCleanupBatch)
+	if err != nil {
+		log.Printf("upload cleanup: %v", err)
+		return
+	}
+
+	for i := range uploads {
+		if ctx.Err() != nil {
+			return
+		}
+
+		err = s.drop(ctx, &uploads[i], entity.UploadExpired)
+		if err != nil {
+			log.Printf("upload cleanup: dropping upload %v: %v", uploads[i].ID, err)
+		}
+	}
+}
@@ -23,6 +23,8 @@ func NewUserService(repo *repository.UserRepository) *UserService {
 }
 
 func (s *UserService) Create(user entity.NewUser) (*uuid.UUID, error) {
+	user.Locale = NormalizeLocale(user.Locale)
+
 	userID, err := s.repo.Create(user)
 	if err != nil {
 		return nil, fmt.Errorf("user service create error: %v", err)
@@ -41,6 +43,11 @@ func (s *UserService) Read(pagination entity.Pagination, filters entity.UserFilt
 }
 
 func (s *UserService) Update(body entity.UserUpdateBody) (bool, error) {
+	if body.Locale != nil {
+		locale := NormalizeLocale(*body.Locale)
+		body.Locale = &locale
+	}
+
 	ok, err := s.repo.Update(body)
 	if err != nil {
 		return false, fmt.Errorf("user service update error: %v", err)
@@ -0,0 +1,421 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LocalRoute is the path the HTTP server serves the files of a LocalStorage under.
+const LocalRoute = "/files/"
+
+// localUploadsDir keeps the parts of multipart uploads until they are assembled, it is never served.
+const localUploadsDir = ".uploads/"
+
+// localChecksumsDir keeps the SHA-256 checksums of the objects, it is never served.
+const localChecksumsDir = ".checksums/"
+
+var errChecksumMismatch = errors.New("checksum does not match")
+
+type LocalConfig struct {
+	Dir string
+	// BaseURL is the public URL of this server.
+	BaseURL string
+	// Secret signs presigned URLs, a random one is generated when it is empty, so the URLs stop
+	// working when the server restarts.
+	Secret string
+}
+
+// LocalStorage keeps objects as files in a directory and serves them itself, see ServeHTTP. It is
+// meant for local development and tests.
+type LocalStorage struct {
+	root    *os.Root
+	baseURL string
+	secret  []byte
+}
+
+func NewLocalStorage(config LocalConfig) (*LocalStorage, error) {
+	err := os.MkdirAll(config.Dir, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("local storage: creating %v: %v", config.Dir, err)
+	}
+
+	root, err := os.OpenRoot(config.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("local storage: opening %v: %v", config.Dir, err)
+	}
+
+	secret := []byte(config.Secret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		rand.Read(secret)
+		log.Printf("local storage: STORAGE_SECRET is empty, presigned URLs will not survive a restart")
+	}
+
+	return &LocalStorage{
+		root:    root,
+		baseURL: strings.TrimSuffix(config.BaseURL, "/"),
+		secret:  secret,
+	}, nil
+}
+
+func (s *LocalStorage) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+	if reserved(key) {
+		return fmt.Errorf("local storage: put %v: key is reserved", key)
+	}
+
+	checksum, err := s.write(key, r, "")
+	if err == nil {
+		err = s.setChecksum(key, checksum)
+	}
+	if err != nil {
+		return fmt.Errorf("local storage: put %v: %v", key, err)
+	}
+
+	return nil
+}
+
+func (s *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	file, err := s.root.Open(key)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("local storage: get %v: %w", key, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("local storage: get %v: %v", key, err)
+	}
+
+	return file, nil
+}
+
+func (s *LocalStorage) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	info, err := s.root.Stat(key)
+	if errors.Is(err, fs.ErrNotExist) || err == nil && info.IsDir() {
+		return nil, fmt.Errorf("local storage: stat %v: %w", key, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("local storage: stat %v: %v", key, err)
+	}
+
+	checksum, err := s.root.ReadFile(localChecksumsDir + key)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("local storage: stat %v: %v", key, err)
+	}
+
+	return &ObjectInfo{Size: info.Size(), ChecksumSHA256: string(checksum)}, nil
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	err := s.root.Remove(key)
+	if err == nil || errors.Is(err, fs.ErrNotExist) {
+		err = s.root.Remove(localChecksumsDir + key)
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("local storage: delete %v: %v", key, err)
+	}
+
+	return nil
+}
+
+func (s *LocalStorage) Copy(ctx context.Context, from string, to string) error {
+	if reserved(from) || reserved(to) {
+		return fmt.Errorf("local storage: copy %v to %v: key is reserved", from, to)
+	}
+
+	file, err := s.root.Open(from)
+	if err != nil {
+		return fmt.Errorf("local storage: copy %v to %v: %v", from, to, err)
+	}
+	defer file.Close()
+
+	checksum, err := s.write(to, file, "")
+	if err == nil {
+		err = s.setChecksum(to, checksum)
+	}
+	if err != nil {
+		return fmt.Errorf("local storage: copy %v to %v: %v", from, to, err)
+	}
+
+	return nil
+}
+
+func (s *LocalStorage) PublicURL(key string) string {
+	return s.baseURL + LocalRoute + (&url.URL{Path: key}).EscapedPath()
+}
+
+func (s *LocalStorage) PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", s.sign(http.MethodGet, key, expires))
+
+	return s.PublicURL(key) + "?" + query.Encode(), nil
+}
+
+// PresignPut ignores the content type, the local storage does not keep it.
+func (s *LocalStorage) PresignPut(ctx context.Context, key string, contentType string, checksum string, ttl time.Duration) (string, error) {
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("checksum", checksum)
+	query.Set("signature", s.sign(http.MethodPut, key, expires, checksum))
+
+	return s.PublicURL(key) + "?" + query.Encode(), nil
+}
+
+func (s *LocalStorage) CreateMultipart(ctx context.Context, key string, contentType string) (string, error) {
+	uploadID := uuid.NewString()
+
+	err := s.root.MkdirAll(localUploadsDir+uploadID, 0o755)
+	if err != nil {
+		return "", fmt.Errorf("local storage: create multipart upload %v: %v", key, err)
+	}
+
+	return uploadID, nil
+}
+
+func (s *LocalStorage) PresignPart(ctx context.Context, key string, uploadID string, number int32, checksum string, ttl time.Duration) (string, error) {
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	partNumber := strconv.Itoa(int(number))
+
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("checksum", checksum)
+	query.Set("uploadId", uploadID)
+	query.Set("partNumber", partNumber)
+	query.Set("signature", s.sign(http.MethodPut, key, expires, checksum, uploadID, partNumber))
+
+	return s.PublicURL(key) + "?" + query.Encode(), nil
+}
+
+// CompleteMultipart concatenates the parts and checks their checksums, the ETags are not checked.
+// The checksum of the object is made from the checksums of the parts the way S3 makes it.
+func (s *LocalStorage) CompleteMultipart(ctx context.Context, key string, uploadID string, parts []Part) error {
+	readers := make([]io.Reader, len(parts))
+	hashes := make([]hash.Hash, len(parts))
+	for i, part := range parts {
+		file, err := s.root.Open(s.partName(uploadID, part.Number))
+		if err != nil {
+			return fmt.Errorf("local storage: complete multipart upload %v: part %v: %v", key, part.Number, err)
+		}
+		defer file.Close()
+		hashes[i] = sha256.New()
+		readers[i] = io.TeeReader(file, hashes[i])
+	}
+
+	_, err := s.write(key, io.MultiReader(readers...), "")
+	if err != nil {
+		return fmt.Errorf("local storage: complete multipart upload %v: %v", key, err)
+	}
+
+	checksums := sha256.New()
+	for i, part := range parts {
+		sum := hashes[i].Sum(nil)
+		if base64.StdEncoding.EncodeToString(sum) != part.ChecksumSHA256 {
+			s.Delete(ctx, key)
+			return fmt.Errorf("local storage: complete multipart upload %v: part %v: %v", key, part.Number, errChecksumMismatch)
+		}
+		checksums.Write(sum)
+	}
+
+	err = s.setChecksum(key, fmt.Sprintf("%v-%v", base64.StdEncoding.EncodeToString(checksums.Sum(nil)), len(parts)))
+	if err != nil {
+		return fmt.Errorf("local storage: complete multipart upload %v: %v", key, err)
+	}
+
+	return s.AbortMultipart(ctx, key, uploadID)
+}
+
+func (s *LocalStorage) AbortMultipart(ctx context.Context, key string, uploadID string) error {
+	if uploadID == "" {
+		return nil
+	}
+
+	err := s.root.RemoveAll(localUploadsDir + uploadID)
+	if err != nil {
+		return fmt.Errorf("local storage: abort multipart upload %v: %v", key, err)
+	}
+
+	return nil
+}
+
+// ServeHTTP serves public objects to everyone and private ones only through presigned URLs, the
+// way a bucket policy would. PUT requests upload objects and parts through presigned URLs.
+func (s *LocalStorage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, LocalRoute)
+	if reserved(key) {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		s.upload(w, r, key)
+		return
+	}
+
+	query := r.URL.Query()
+	if query.Has("signature") || strings.HasPrefix(key, PrivatePrefix) {
+		if !s.verify(query, http.MethodGet, key) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Disposition", contentDisposition(key))
+	}
+
+	file, err := s.root.Open(key)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	http.ServeContent(w, r, path.Base(key), info.ModTime(), file)
+}
+
+// upload stores the body of a presigned PUT request and answers with its MD5 as the ETag, like S3.
+// A body that does not match the checksum of the URL is refused.
+func (s *LocalStorage) upload(w http.ResponseWriter, r *http.Request, key string) {
+	query := r.URL.Query()
+	uploadID := query.Get("uploadId")
+
+	if !s.verify(query, http.MethodPut, key, query.Get("checksum"), uploadID, query.Get("partNumber")) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	name := key
+	if uploadID != "" {
+		number, err := strconv.ParseInt(query.Get("partNumber"), 10, 32)
+		if err != nil {
+			http.Error(w, "invalid part number", http.StatusBadRequest)
+			return
+		}
+		if _, err = s.root.Stat(localUploadsDir + uploadID); err != nil {
+			http.Error(w, "no such upload", http.StatusNotFound)
+			return
+		}
+		name = s.partName(uploadID, int32(number))
+	}
+
+	etag := md5.New()
+	checksum, err := s.write(name, io.TeeReader(r.Body, etag), query.Get("checksum"))
+	if err == nil && uploadID == "" {
+		err = s.setChecksum(key, checksum)
+	}
+	if errors.Is(err, errChecksumMismatch) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", `"`+hex.EncodeToString(etag.Sum(nil))+`"`)
+}
+
+// write writes the file to a temporary file first, so a half written file is never served, and
+// returns its base64 encoded SHA-256. The file is not written if expected is set and differs.
+func (s *LocalStorage) write(name string, r io.Reader, expected string) (string, error) {
+	err := s.root.MkdirAll(path.Dir(name), 0o755)
+	if err != nil {
+		return "", err
+	}
+
+	temporary := name + ".upload-" + uuid.NewString()
+	file, err := s.root.Create(temporary)
+	if err != nil {
+		return "", err
+	}
+
+	digest := sha256.New()
+	_, err = io.Copy(file, io.TeeReader(r, digest))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	checksum := base64.StdEncoding.EncodeToString(digest.Sum(nil))
+	if err == nil && expected != "" && checksum != expected {
+		err = errChecksumMismatch
+	}
+	if err == nil {
+		err = s.root.Rename(temporary, name)
+	}
+	if err != nil {
+		s.root.Remove(temporary)
+		return "", err
+	}
+
+	return checksum, nil
+}
+
+func (s *LocalStorage) setChecksum(key string, checksum string) error {
+	name := localChecksumsDir + key
+
+	err := s.root.MkdirAll(path.Dir(name), 0o755)
+	if err != nil {
+		return err
+	}
+
+	return s.root.WriteFile(name, []byte(checksum), 0o644)
+}
+
+func reserved(key string) bool {
+	return strings.HasPrefix(key, localUploadsDir) || strings.HasPrefix(key, localChecksumsDir)
+}
+
+func (s *LocalStorage) partName(uploadID string, number int32) string {
+	return fmt.Sprintf("%v%v/%v", localUploadsDir, uploadID, number)
+}
+
+// verify checks the expiry and the signature of a presigned URL, the fields are the ones signed
+// after the method, the key and the expiry.
+func (s *LocalStorage) verify(query url.Values, method string, key string, fields ...string) bool {
+	expires := query.Get("expires")
+	deadline, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > deadline {
+		return false
+	}
+
+	for len(fields) != 0 && fields[len(fields)-1] == "" {
+		fields = fields[:len(fields)-1]
+	}
+	signed := s.sign(method, key, expires, fields...)
+
+	return hmac.Equal([]byte(query.Get("signature")), []byte(signed))
+}
+
+func (s *LocalStorage) sign(method string, key string, expires string, fields ...string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(strings.Join(append([]string{method, key, expires}, fields...), "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
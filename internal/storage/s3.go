@@ -0,0 +1,270 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// defaultS3CompatibleRegion is used for S3 compatible stores that ignore the region, the request
+// signature still needs one.
+const defaultS3CompatibleRegion = "us-east-1"
+
+type S3Config struct {
+	Region string
+	Bucket string
+	// Endpoint is the URL of an S3 compatible store such as MinIO, empty for AWS.
+	Endpoint string
+	// PathStyle addresses the bucket as endpoint/bucket/key instead of bucket.endpoint/key.
+	PathStyle bool
+	// PublicURL is the base URL of public links when the store is reached from the outside under
+	// another address, such as a CDN or a reverse proxy.
+	PublicURL string
+	// AccessKeyID and SecretAccessKey are optional, the default AWS credential chain is used without them.
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// S3Storage keeps objects in an AWS S3 bucket or in a bucket of an S3 compatible store.
+type S3Storage struct {
+	client  *s3.Client
+	presign *s3.PresignClient
+	config  S3Config
+}
+
+func NewS3Storage(ctx context.Context, s3Config S3Config) (*S3Storage, error) {
+	if s3Config.Bucket == "" {
+		return nil, fmt.Errorf("s3 storage: bucket is empty")
+	}
+	if s3Config.Region == "" && s3Config.Endpoint != "" {
+		s3Config.Region = defaultS3CompatibleRegion
+	}
+
+	options := []func(*config.LoadOptions) error{config.WithRegion(s3Config.Region)}
+	if s3Config.AccessKeyID != "" {
+		options = append(options, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(s3Config.AccessKeyID, s3Config.SecretAccessKey, "")))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("s3 storage: load AWS config: %w", err)
+	}
+
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if s3Config.Endpoint != "" {
+			o.BaseEndpoint = aws.String(s3Config.Endpoint)
+		}
+		o.UsePathStyle = s3Config.PathStyle
+	})
+
+	return &S3Storage{
+		client:  client,
+		presign: s3.NewPresignClient(client),
+		config:  s3Config,
+	}, nil
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(s.config.Bucket),
+		Key:    aws.String(key),
+		Body:   r,
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+
+	_, err := s.client.PutObject(ctx, input)
+	if err != nil {
+		return fmt.Errorf("s3 storage: put %v: %v", key, err)
+	}
+
+	return nil
+}
+
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	output, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.config.Bucket),
+		Key:    aws.String(key),
+	})
+	var noSuchKey *types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return nil, fmt.Errorf("s3 storage: get %v: %w", key, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("s3 storage: get %v: %v", key, err)
+	}
+
+	return output.Body, nil
+}
+
+func (s *S3Storage) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	output, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:       aws.String(s.config.Bucket),
+		Key:          aws.String(key),
+		ChecksumMode: types.ChecksumModeEnabled,
+	})
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) {
+		return nil, fmt.Errorf("s3 storage: stat %v: %w", key, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("s3 storage: stat %v: %v", key, err)
+	}
+
+	return &ObjectInfo{Size: aws.ToInt64(output.ContentLength), ChecksumSHA256: aws.ToString(output.ChecksumSHA256)}, nil
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.config.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("s3 storage: delete %v: %v", key, err)
+	}
+
+	return nil
+}
+
+func (s *S3Storage) Copy(ctx context.Context, from string, to string) error {
+	_, err := s.client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:            aws.String(s.config.Bucket),
+		Key:               aws.String(to),
+		CopySource:        aws.String(s.config.Bucket + "/" + (&url.URL{Path: from}).EscapedPath()),
+		ChecksumAlgorithm: types.ChecksumAlgorithmSha256,
+	})
+	if err != nil {
+		return fmt.Errorf("s3 storage: copy %v to %v: %v", from, to, err)
+	}
+
+	return nil
+}
+
+func (s *S3Storage) PublicURL(key string) string {
+	switch {
+	case s.config.PublicURL != "":
+		return strings.TrimSuffix(s.config.PublicURL, "/") + "/" + key
+	case s.config.Endpoint != "" && s.config.PathStyle:
+		return fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(s.config.Endpoint, "/"), s.config.Bucket, key)
+	case s.config.Endpoint != "":
+		scheme, host, _ := strings.Cut(s.config.Endpoint, "://")
+		return fmt.Sprintf("%s://%s.%s/%s", scheme, s.config.Bucket, strings.TrimSuffix(host, "/"), key)
+	default:
+		return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.config.Bucket, s.config.Region, key)
+	}
+}
+
+func (s *S3Storage) PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	request, err := s.presign.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket:                     aws.String(s.config.Bucket),
+		Key:                        aws.String(key),
+		ResponseContentDisposition: aws.String(contentDisposition(key)),
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", fmt.Errorf("s3 storage: presign get %v: %v", key, err)
+	}
+
+	return request.URL, nil
+}
+
+func contentDisposition(key string) string {
+	return mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(key)})
+}
+
+// PresignPut signs the checksum into the URL, S3 checks the body against it.
+func (s *S3Storage) PresignPut(ctx context.Context, key string, contentType string, checksum string, ttl time.Duration) (string, error) {
+	input := &s3.PutObjectInput{
+		Bucket:         aws.String(s.config.Bucket),
+		Key:            aws.String(key),
+		ChecksumSHA256: aws.String(checksum),
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+
+	request, err := s.presign.PresignPutObject(ctx, input, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", fmt.Errorf("s3 storage: presign put %v: %v", key, err)
+	}
+
+	return request.URL, nil
+}
+
+func (s *S3Storage) CreateMultipart(ctx context.Context, key string, contentType string) (string, error) {
+	input := &s3.CreateMultipartUploadInput{
+		Bucket:            aws.String(s.config.Bucket),
+		Key:               aws.String(key),
+		ChecksumAlgorithm: types.ChecksumAlgorithmSha256,
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+
+	output, err := s.client.CreateMultipartUpload(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("s3 storage: create multipart upload %v: %v", key, err)
+	}
+
+	return aws.ToString(output.UploadId), nil
+}
+
+func (s *S3Storage) PresignPart(ctx context.Context, key string, uploadID string, number int32, checksum string, ttl time.Duration) (string, error) {
+	request, err := s.presign.PresignUploadPart(ctx, &s3.UploadPartInput{
+		Bucket:         aws.String(s.config.Bucket),
+		Key:            aws.String(key),
+		UploadId:       aws.String(uploadID),
+		PartNumber:     aws.Int32(number),
+		ChecksumSHA256: aws.String(checksum),
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", fmt.Errorf("s3 storage: presign part %v of %v: %v", number, key, err)
+	}
+
+	return request.URL, nil
+}
+
+func (s *S3Storage) CompleteMultipart(ctx context.Context, key string, uploadID string, parts []Part) error {
+	completed := make([]types.CompletedPart, len(parts))
+	for i, part := range parts {
+		completed[i] = types.CompletedPart{ETag: aws.String(part.ETag), PartNumber: aws.Int32(part.Number), ChecksumSHA256: aws.String(part.ChecksumSHA256)}
+	}
+
+	_, err := s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.config.Bucket),
+		Key:             aws.String(key),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		return fmt.Errorf("s3 storage: complete multipart upload %v: %v", key, err)
+	}
+
+	return nil
+}
+
+func (s *S3Storage) AbortMultipart(ctx context.Context, key string, uploadID string) error {
+	_, err := s.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s.config.Bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
+	var noSuchUpload *types.NoSuchUpload
+	if err != nil && !errors.As(err, &noSuchUpload) {
+		return fmt.Errorf("s3 storage: abort multipart upload %v: %v", key, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// PrivatePrefix is the key prefix of objects that must not be readable by everyone. Public read is
+// granted on every key except the ones under it, private objects are only handed out through
+// presigned URLs.
+const PrivatePrefix = "private/"
+
+var ErrNotFound = errors.New("object not found")
+
+type ObjectInfo struct {
+	Size int64
+	// ChecksumSHA256 is the base64 encoded SHA-256 the storage keeps for the object, empty if it
+	// keeps none. For an object assembled from parts it is the checksum of the concatenated
+	// checksums of the parts followed by "-" and the number of parts, the way S3 reports it.
+	ChecksumSHA256 string
+}
+
+// Part is an uploaded part of a multipart upload, the ETag is the one the storage answered the
+// upload of the part with.
+type Part struct {
+	Number int32  `json:"number"`
+	ETag   string `json:"etag"`
+	// ChecksumSHA256 is the base64 encoded SHA-256 of the part, it is filled in by the server.
+	ChecksumSHA256 string `json:"-"`
+} // @name UploadedPart
+
+// Storage is an object store keyed by slash separated paths.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	// Get opens the object for reading, it fails with ErrNotFound if there is none.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Stat fails with ErrNotFound if there is no object.
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// Delete removes the object, a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// Copy copies the object to another key inside the storage, the content does not pass through
+	// this server.
+	Copy(ctx context.Context, from string, to string) error
+	// PublicURL returns the URL anyone can download a public object from.
+	PublicURL(key string) string
+	// PresignGet returns a URL that downloads the object until ttl passes. The browser is told to
+	// save the file under the last part of its key.
+	PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error)
+	// PresignPut returns a URL the object can be uploaded to with a single PUT request until ttl
+	// passes, the request should carry contentType as its Content-Type. The storage rejects a body
+	// whose SHA-256 is not checksum, given base64 encoded, and keeps it as the checksum of the object.
+	PresignPut(ctx context.Context, key string, contentType string, checksum string, ttl time.Duration) (string, error)
+	// CreateMultipart starts an upload sent in parts, checksummed with SHA-256, and returns its id.
+	CreateMultipart(ctx context.Context, key string, contentType string) (string, error)
+	// PresignPart returns a URL a part of the upload can be sent to with a PUT request until ttl
+	// passes, the storage rejects a part whose SHA-256 is not checksum, given base64 encoded.
+	PresignPart(ctx context.Context, key string, uploadID string, number int32, checksum string, ttl time.Duration) (string, error)
+	// CompleteMultipart assembles the object from the parts, given in order, it fails if a part does
+	// not have the checksum given for it.
+	CompleteMultipart(ctx context.Context, key string, uploadID string, parts []Part) error
+	// AbortMultipart drops the parts uploaded so far, an unknown upload is not an error.
+	AbortMultipart(ctx context.Context, key string, uploadID string) error
+}
+
+// New returns the storage selected by STORAGE_BACKEND: "s3" (default), "s3-compatible" or "local".
+func New(ctx context.Context) (Storage, error) {
+	switch os.Getenv("STORAGE_BACKEND") {
+	case "", "s3":
+		return NewS3Storage(ctx, S3Config{
+			Region: os.Getenv("AWS_REGION"),
+			Bucket: os.Getenv("S3_BUCKET"),
+		})
+	case "s3-compatible":
+		pathStyle := true
+		if value := os.Getenv("S3_PATH_STYLE"); value != "" {
+			var err error
+			pathStyle, err = strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("storage: invalid S3_PATH_STYLE %q: %v", value, err)
+			}
+		}
+
+		return NewS3Storage(ctx, S3Config{
+			Region:          os.Getenv("AWS_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			PathStyle:       pathStyle,
+			PublicURL:       os.Getenv("S3_PUBLIC_URL"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		})
+	case "local":
+		dir := os.Getenv("STORAGE_DIR")
+		if dir == "" {
+			dir = "files"
+		}
+		baseURL := os.Getenv("STORAGE_BASE_URL")
+		if baseURL == "" {
+			baseURL = os.Getenv("APP_URL")
+		}
+
+		return NewLocalStorage(LocalConfig{
+			Dir:     dir,
+			BaseURL: baseURL,
+			Secret:  os.Getenv("STORAGE_SECRET"),
+		})
+	default:
+		return nil, fmt.Errorf("storage: unknown backend %q", os.Getenv("STORAGE_BACKEND"))
+	}
+}
@@ -3,13 +3,17 @@ package main
 import (
 	"context"
 	"log"
+	"net/http"
 
 	_ "github.com/AlnurZhanibek/kazusa-server/docs"
 	"github.com/AlnurZhanibek/kazusa-server/internal/database"
 	"github.com/AlnurZhanibek/kazusa-server/internal/handler"
+	"github.com/AlnurZhanibek/kazusa-server/internal/mailer"
+	"github.com/AlnurZhanibek/kazusa-server/internal/payment"
 	"github.com/AlnurZhanibek/kazusa-server/internal/repository"
 	"github.com/AlnurZhanibek/kazusa-server/internal/server"
 	"github.com/AlnurZhanibek/kazusa-server/internal/service"
+	"github.com/AlnurZhanibek/kazusa-server/internal/storage"
 )
 
 // @title Swagger KazUSA API
@@ -36,36 +40,143 @@ func main() {
 	userService := service.NewUserService(userRepo)
 	userHandler := handler.NewUserHandler(userService)
 
-	authService := service.NewAuthService(userRepo)
+	mailSender, err := mailer.New()
+	if err != nil {
+		log.Fatalf("failed to create mailer: %v", err)
+	}
+
+	mailTemplates, err := mailer.NewTemplates()
+	if err != nil {
+		log.Fatalf("failed to load mail templates: %v", err)
+	}
+
+	mailQueueRepo := repository.NewMailQueueRepository(db)
+	mailQueue := mailer.NewQueue(mailQueueRepo, mailSender)
+	go mailQueue.Run(ctx)
+
+	notifier := service.NewMailNotifier(mailTemplates, mailQueue)
+
+	emailVerificationRepo := repository.NewEmailVerificationRepository(db)
+	emailVerificationService := service.NewEmailVerificationService(userRepo, emailVerificationRepo, notifier)
+	emailVerificationHandler := handler.NewEmailVerificationHandler(emailVerificationService)
+
+	sessionRepo := repository.NewSessionRepository(db)
+	authService := service.NewAuthService(userRepo, sessionRepo, emailVerificationService)
 	authHandler := handler.NewAuthHandler(authService)
+	authMiddleware := handler.NewAuthMiddleware(authService, server.Policies)
 
+	passwordResetRepo := repository.NewPasswordResetRepository(db)
+	passwordService := service.NewPasswordService(userRepo, passwordResetRepo, authService, notifier)
+	passwordHandler := handler.NewPasswordHandler(passwordService)
+
+	fileStorage, err := storage.New(ctx)
+	if err != nil {
+		log.Fatalf("failed to create file storage: %v", err)
+	}
+	fileRepo := repository.NewFileRepository(db)
+	fileLock := repository.NewLeaderLock(db, service.FileGCLockName)
+	fileService := service.NewFileService(fileStorage, fileRepo, fileLock)
+	go fileService.Run(ctx)
+	// Only the local storage is served by this server, buckets serve their files themselves.
+	fileServer, _ := fileStorage.(http.Handler)
+
+	courseRepo := repository.NewCourseRepo(db)
+	attachmentMigrationLock := repository.NewLeaderLock(db, service.AttachmentMigrationLockName)
+	go service.NewAttachmentMigration(courseRepo, fileService, attachmentMigrationLock).Run(ctx)
 	activityRepo := repository.NewActivityRepository(db)
-	activityService := service.NewActivityService(activityRepo)
-	activityHandler := handler.NewActivityHandler(activityService)
+
+	certificateRepo := repository.NewCertificateRepository(db)
+	certificateService := service.NewCertificateService(certificateRepo, activityRepo, userRepo, courseRepo, fileService, notifier)
+	certificateHandler := handler.NewCertificateHandler(certificateService)
 
 	paymentRepo := repository.NewPaymentRepository(db)
-	paymentService := service.NewPaymentService(paymentRepo)
-	paymentHandler := handler.NewPaymentHandler(paymentService)
+	paymentProvider, err := payment.New()
+	if err != nil {
+		log.Fatalf("failed to create payment provider: %v", err)
+	}
+	couponRepo := repository.NewCouponRepository(db)
+	couponService := service.NewCouponService(couponRepo, courseRepo)
+	couponHandler := handler.NewCouponHandler(couponService)
+
+	bundleRepo := repository.NewBundleRepository(db)
+	bundleService := service.NewBundleService(bundleRepo, courseRepo)
+	bundleHandler := handler.NewBundleHandler(bundleService)
+
+	subscriptionRepo := repository.NewSubscriptionRepository(db)
+	planRepo := repository.NewPlanRepository(db)
+	subscriptionService := service.NewSubscriptionService(subscriptionRepo, planRepo)
+
+	auditRepo := repository.NewAuditRepository(db)
+	enrollmentRepo := repository.NewEnrollmentRepository(db)
+	enrollmentService := service.NewEnrollmentService(enrollmentRepo, courseRepo, auditRepo, subscriptionService)
+	enrollmentHandler := handler.NewEnrollmentHandler(enrollmentService)
 
 	moduleRepo := repository.NewModuleRepo(db)
-	moduleService := service.NewModuleService(moduleRepo, activityService)
-	moduleHandler := handler.NewModuleHandler(moduleService)
+	activityService := service.NewActivityService(activityRepo, moduleRepo, certificateService, enrollmentService)
+	activityHandler := handler.NewActivityHandler(activityService)
 
-	fileService, err := service.NewFileService(ctx)
-	if err != nil {
-		log.Fatalf("failed to create file service: %v", err)
+	accessCodeRepo := repository.NewAccessCodeRepository(db)
+	giftService := service.NewGiftService(accessCodeRepo, courseRepo, userRepo, enrollmentService, notifier)
+	giftHandler := handler.NewGiftHandler(giftService)
+
+	receiptRepo := repository.NewReceiptRepository(db)
+	receiptService := service.NewReceiptService(receiptRepo, paymentRepo, userRepo, courseRepo, subscriptionRepo, auditRepo, fileService, notifier)
+	receiptHandler := handler.NewReceiptHandler(receiptService)
+
+	paymentReviewRepo := repository.NewPaymentReviewRepository(db)
+	paymentService := service.NewPaymentService(paymentRepo, courseRepo, userRepo, auditRepo, paymentReviewRepo, couponService, bundleService, subscriptionService, enrollmentService, giftService, receiptService, paymentProvider)
+	paymentHandler := handler.NewPaymentHandler(paymentService)
+	subscriptionHandler := handler.NewSubscriptionHandler(subscriptionService, paymentService)
+	if simulator, ok := paymentProvider.(*payment.Simulator); ok {
+		simulator.SetCallback(paymentService.ApplyCallback)
 	}
 
-	courseRepo := repository.NewCourseRepo(db)
-	courseService := service.NewCourseService(courseRepo, moduleService, fileService, paymentService)
+	reconciliationLock := repository.NewLeaderLock(db, service.ReconciliationLockName)
+	reconciliationService := service.NewReconciliationService(paymentService, paymentRepo, paymentReviewRepo, auditRepo, reconciliationLock)
+	reconciliationHandler := handler.NewReconciliationHandler(reconciliationService)
+	go reconciliationService.Run(ctx)
+
+	uploadRepo := repository.NewUploadRepository(db)
+	uploadLock := repository.NewLeaderLock(db, service.UploadCleanupLockName)
+	uploadService := service.NewUploadService(uploadRepo, courseRepo, fileService, fileStorage, uploadLock)
+	uploadHandler := handler.NewUploadHandler(uploadService)
+	go uploadService.Run(ctx)
+
+	moduleService := service.NewModuleService(moduleRepo, activityService, enrollmentService)
+	moduleHandler := handler.NewModuleHandler(moduleService)
+
+	courseService := service.NewCourseService(courseRepo, moduleService, fileService, enrollmentService)
 	courseHandler := handler.NewCourseHandler(courseService)
 
+	reportRepo := repository.NewReportRepository(db)
+	reportService := service.NewReportService(reportRepo)
+	reportHandler := handler.NewReportHandler(reportService)
+
+	dashboardRepo := repository.NewDashboardRepository(db)
+	dashboardService := service.NewDashboardService(dashboardRepo, subscriptionService)
+	dashboardHandler := handler.NewDashboardHandler(dashboardService)
+
 	server.Start(&server.Handlers{
-		CourseHandler:   courseHandler,
-		ModuleHandler:   moduleHandler,
-		UserHandler:     userHandler,
-		AuthHandler:     authHandler,
-		ActivityHandler: activityHandler,
-		PaymentHandler:  paymentHandler,
+		CourseHandler:         courseHandler,
+		ModuleHandler:         moduleHandler,
+		UserHandler:           userHandler,
+		AuthHandler:           authHandler,
+		ActivityHandler:       activityHandler,
+		PaymentHandler:        paymentHandler,
+		PasswordHandler:       passwordHandler,
+		EmailHandler:          emailVerificationHandler,
+		CertificateHandler:    certificateHandler,
+		DashboardHandler:      dashboardHandler,
+		CouponHandler:         couponHandler,
+		BundleHandler:         bundleHandler,
+		SubscriptionHandler:   subscriptionHandler,
+		EnrollmentHandler:     enrollmentHandler,
+		GiftHandler:           giftHandler,
+		ReceiptHandler:        receiptHandler,
+		ReportHandler:         reportHandler,
+		ReconciliationHandler: reconciliationHandler,
+		UploadHandler:         uploadHandler,
+		AuthMiddleware:        authMiddleware,
+		FileServer:            fileServer,
 	})
 }
@@ -0,0 +1,13 @@
+create table if not exists sessions (
+    id binary(16) not null,
+    family_id binary(16) not null,
+    user_id binary(16) not null,
+    token_hash char(64) not null unique,
+    created_at timestamp default current_timestamp,
+    expires_at timestamp not null,
+    rotated_at timestamp null,
+    revoked_at timestamp null,
+    primary key (id),
+    index (family_id),
+    foreign key (user_id) references users (id) on delete cascade
+)
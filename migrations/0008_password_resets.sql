@@ -0,0 +1,10 @@
+create table if not exists password_resets (
+    id binary(16) not null,
+    user_id binary(16) not null,
+    token_hash char(64) not null unique,
+    created_at timestamp default current_timestamp,
+    expires_at timestamp not null,
+    used_at timestamp null,
+    primary key (id),
+    foreign key (user_id) references users (id) on delete cascade
+)
@@ -0,0 +1,16 @@
+alter table users
+    add column email_verified_at timestamp null;
+
+-- Accounts registered before verification existed are trusted as they are.
+update users set email_verified_at = current_timestamp where email_verified_at is null;
+
+create table if not exists email_verifications (
+    id binary(16) not null,
+    user_id binary(16) not null,
+    token_hash char(64) not null unique,
+    created_at timestamp default current_timestamp,
+    expires_at timestamp not null,
+    used_at timestamp null,
+    primary key (id),
+    foreign key (user_id) references users (id) on delete cascade
+)
@@ -0,0 +1,18 @@
+create table if not exists mail_queue (
+    id binary(16) not null,
+    created_at timestamp default current_timestamp,
+    recipient varchar(256) not null,
+    subject varchar(512) not null,
+    text_body mediumtext not null,
+    html_body mediumtext,
+    attempts int not null default 0,
+    next_attempt_at timestamp default current_timestamp,
+    locked_until timestamp null,
+    sent_at timestamp null,
+    last_error varchar(1024),
+    primary key (id),
+    index (sent_at, next_attempt_at)
+);
+
+alter table users
+    add column locale varchar(8) not null default 'ru';
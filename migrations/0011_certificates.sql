@@ -0,0 +1,14 @@
+create table if not exists certificates (
+    id binary(16) not null,
+    created_at timestamp default current_timestamp,
+    serial varchar(32) not null unique,
+    user_id binary(16) not null,
+    course_id binary(16) not null,
+    learner_name varchar(256) not null,
+    course_title varchar(256) not null,
+    file_url varchar(512) not null,
+    primary key (id),
+    unique (user_id, course_id),
+    foreign key (user_id) references users (id),
+    foreign key (course_id) references courses (id)
+)
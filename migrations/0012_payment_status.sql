@@ -0,0 +1,20 @@
+alter table course_payments
+    add column status varchar(32) not null default 'created';
+
+update course_payments set status = 'succeeded' where confirmed = 1;
+
+alter table course_payments
+    drop column confirmed,
+    add unique (order_id);
+
+create table if not exists payment_events (
+    id binary(16) not null,
+    created_at timestamp default current_timestamp,
+    order_id binary(16) not null,
+    from_status varchar(32),
+    to_status varchar(32),
+    applied bool not null default 0,
+    payload text not null,
+    primary key (id),
+    index (order_id)
+)
@@ -0,0 +1,3 @@
+alter table payment_events
+    add column payload_hash char(64) not null default '',
+    add index (payload_hash);
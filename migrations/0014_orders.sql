@@ -0,0 +1,8 @@
+alter table courses
+    add column currency char(3) not null default 'KZT';
+
+alter table course_payments
+    add column amount bigint not null default 0,
+    add column currency char(3) not null default 'KZT',
+    add column provider varchar(32),
+    add column checkout_url varchar(1024);
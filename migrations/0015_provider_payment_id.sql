@@ -0,0 +1,2 @@
+alter table course_payments
+    add column provider_payment_id varchar(128);
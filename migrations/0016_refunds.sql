@@ -0,0 +1,15 @@
+alter table course_payments
+    add column refunded_amount bigint not null default 0;
+
+create table if not exists audit_log (
+    id binary(16) not null,
+    created_at timestamp default current_timestamp,
+    actor_id binary(16),
+    action varchar(64) not null,
+    entity_type varchar(64) not null,
+    entity_id varchar(64) not null,
+    details json,
+    primary key (id),
+    index (entity_type, entity_id),
+    foreign key (actor_id) references users (id)
+)
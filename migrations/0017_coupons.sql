@@ -0,0 +1,20 @@
+create table if not exists coupons (
+    id binary(16) not null,
+    created_at timestamp default current_timestamp,
+    code varchar(64) not null unique,
+    discount_type varchar(16) not null,
+    discount_value bigint not null,
+    course_id binary(16),
+    starts_at timestamp null,
+    ends_at timestamp null,
+    max_redemptions int,
+    max_per_user int,
+    active bool not null default 1,
+    primary key (id),
+    foreign key (course_id) references courses (id)
+);
+
+alter table course_payments
+    add column coupon_id binary(16),
+    add column discount_amount bigint not null default 0,
+    add foreign key (coupon_id) references coupons (id);
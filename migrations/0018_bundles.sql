@@ -0,0 +1,42 @@
+create table if not exists bundles (
+    id binary(16) not null,
+    created_at timestamp default current_timestamp,
+    updated_at timestamp default current_timestamp on update current_timestamp,
+    title varchar(256) not null,
+    description varchar(2048) not null,
+    price bigint not null,
+    currency char(3) not null default 'KZT',
+    active bool not null default 1,
+    primary key (id)
+);
+
+create table if not exists bundle_courses (
+    bundle_id binary(16) not null,
+    course_id binary(16) not null,
+    primary key (bundle_id, course_id),
+    foreign key (bundle_id) references bundles (id),
+    foreign key (course_id) references courses (id)
+);
+
+alter table course_payments
+    modify course_id binary(16) null,
+    add column bundle_id binary(16),
+    add foreign key (bundle_id) references bundles (id);
+
+create table if not exists payment_items (
+    id binary(16) not null,
+    order_id binary(16) not null,
+    course_id binary(16) not null,
+    price bigint not null,
+    amount bigint not null,
+    primary key (id),
+    unique (order_id, course_id),
+    index (course_id),
+    foreign key (order_id) references course_payments (order_id),
+    foreign key (course_id) references courses (id)
+);
+
+insert into payment_items(id, order_id, course_id, price, amount)
+select uuid_to_bin(uuid()), order_id, course_id, amount + discount_amount, amount
+from course_payments
+where order_id is not null and course_id is not null;
@@ -0,0 +1,33 @@
+create table if not exists plans (
+    id binary(16) not null,
+    created_at timestamp default current_timestamp,
+    name varchar(256) not null,
+    description varchar(2048) not null default '',
+    billing_interval varchar(16) not null,
+    price bigint not null,
+    currency char(3) not null default 'KZT',
+    grace_days int not null default 0,
+    active bool not null default 1,
+    primary key (id)
+);
+
+create table if not exists subscriptions (
+    id binary(16) not null,
+    created_at timestamp default current_timestamp,
+    updated_at timestamp default current_timestamp on update current_timestamp,
+    user_id binary(16) not null,
+    plan_id binary(16) not null,
+    status varchar(32) not null default 'pending',
+    current_period_start timestamp null,
+    current_period_end timestamp null,
+    cancel_at_period_end bool not null default 0,
+    cancelled_at timestamp null,
+    primary key (id),
+    index (user_id),
+    foreign key (user_id) references users (id),
+    foreign key (plan_id) references plans (id)
+);
+
+alter table course_payments
+    add column subscription_id binary(16),
+    add foreign key (subscription_id) references subscriptions (id);
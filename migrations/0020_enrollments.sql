@@ -0,0 +1,22 @@
+create table if not exists enrollments (
+    id binary(16) not null,
+    created_at timestamp default current_timestamp,
+    user_id binary(16) not null,
+    course_id binary(16) not null,
+    source varchar(32) not null,
+    order_id binary(16),
+    granted_by binary(16),
+    revoked_at timestamp null,
+    primary key (id),
+    unique (user_id, course_id, source),
+    index (course_id),
+    index (order_id),
+    foreign key (user_id) references users (id),
+    foreign key (course_id) references courses (id)
+);
+
+insert ignore into enrollments(id, created_at, user_id, course_id, source, order_id)
+select uuid_to_bin(uuid()), p.created_at, p.user_id, coalesce(i.course_id, p.course_id), 'purchase', p.order_id
+from course_payments p
+left join payment_items i on i.order_id = p.order_id
+where p.status = 'succeeded' and coalesce(i.course_id, p.course_id) is not null;
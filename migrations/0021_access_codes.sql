@@ -0,0 +1,36 @@
+create table if not exists code_batches (
+    id binary(16) not null,
+    created_at timestamp default current_timestamp,
+    course_id binary(16) not null,
+    label varchar(256) not null default '',
+    quantity int not null,
+    expires_at timestamp null,
+    created_by binary(16),
+    primary key (id),
+    index (course_id),
+    foreign key (course_id) references courses (id),
+    foreign key (created_by) references users (id)
+);
+
+alter table course_payments
+    add column gift bool not null default 0,
+    add column gift_recipient_email varchar(256);
+
+create table if not exists access_codes (
+    id binary(16) not null,
+    created_at timestamp default current_timestamp,
+    code varchar(32) not null unique,
+    course_id binary(16) not null,
+    batch_id binary(16),
+    order_id binary(16) unique,
+    expires_at timestamp null,
+    redeemed_by binary(16),
+    redeemed_at timestamp null,
+    voided_at timestamp null,
+    primary key (id),
+    index (batch_id),
+    foreign key (course_id) references courses (id),
+    foreign key (batch_id) references code_batches (id),
+    foreign key (order_id) references course_payments (order_id),
+    foreign key (redeemed_by) references users (id)
+);
@@ -0,0 +1,13 @@
+create table if not exists receipts (
+    id binary(16) not null,
+    created_at timestamp default current_timestamp,
+    updated_at timestamp default current_timestamp on update current_timestamp,
+    number varchar(32) not null unique,
+    order_id binary(16) not null unique,
+    user_id binary(16) not null,
+    paid_at timestamp not null,
+    file_url varchar(512) not null,
+    primary key (id),
+    foreign key (order_id) references course_payments (order_id),
+    foreign key (user_id) references users (id)
+)
@@ -0,0 +1,18 @@
+alter table course_payments
+    add index (status, created_at);
+
+create table if not exists payment_reviews (
+    id binary(16) not null,
+    created_at timestamp default current_timestamp,
+    updated_at timestamp default current_timestamp on update current_timestamp,
+    order_id binary(16) not null unique,
+    reason varchar(512) not null,
+    local_status varchar(32) not null,
+    provider_status varchar(32),
+    resolved_at timestamp null,
+    resolved_by binary(16),
+    note varchar(1024),
+    primary key (id),
+    foreign key (order_id) references course_payments (order_id),
+    foreign key (resolved_by) references users (id)
+)
@@ -0,0 +1,2 @@
+alter table modules
+    add column free_preview bool not null default false;
@@ -0,0 +1,23 @@
+create table if not exists uploads (
+    id binary(16) not null,
+    created_at timestamp default current_timestamp,
+    updated_at timestamp default current_timestamp on update current_timestamp,
+    created_by binary(16) not null,
+    course_id binary(16) not null,
+    storage_key varchar(1024) not null,
+    filename varchar(512) not null,
+    content_type varchar(255) not null,
+    size bigint not null,
+    checksum char(64) not null,
+    multipart_upload_id varchar(1024),
+    part_size bigint not null default 0,
+    part_checksums text,
+    status varchar(16) not null,
+    expires_at timestamp not null,
+    completed_at timestamp null,
+    primary key (id),
+    index (status, expires_at),
+    index (status, updated_at),
+    foreign key (created_by) references users (id),
+    foreign key (course_id) references courses (id) on delete cascade
+)
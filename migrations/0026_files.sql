@@ -0,0 +1,34 @@
+create table if not exists files (
+    id binary(16) not null,
+    created_at timestamp default current_timestamp,
+    storage_key varchar(1024) not null,
+    owner_type varchar(32) not null,
+    owner_id binary(16) not null,
+    purpose varchar(32) not null,
+    released_at timestamp null,
+    primary key (id),
+    index (storage_key(255)),
+    index (owner_type, owner_id),
+    index (released_at)
+);
+
+-- Track the files of the courses stored before this table existed. Covers, and attachments from
+-- before attachments were private, are public URLs of keys at the root of the storage; the default
+-- cover is not ours. A legacy key used by several courses stays untracked, collecting it for one
+-- course would break the others.
+insert into files(id, created_at, storage_key, owner_type, owner_id, purpose)
+with course_files as (
+    select c.id as course_id, c.created_at, substring_index(c.cover_url, '/', -1) as storage_key, 'cover' as purpose
+    from courses c
+    where c.cover_url is not null
+        and c.cover_url <> 'https://hyderabadangels.in/wp-content/uploads/2019/11/dummy-logo.png'
+    union all
+    select c.id, c.created_at, if(a.url like 'http%', substring_index(a.url, '/', -1), a.url), 'attachment'
+    from courses c,
+        json_table(c.attachment_urls, '$.attachment_urls[*]' columns (url varchar(1024) path '$')) a
+)
+select uuid_to_bin(uuid()), f.created_at, f.storage_key, 'course', f.course_id, f.purpose
+from course_files f
+where f.storage_key <> ''
+    and f.storage_key in (select storage_key from course_files group by storage_key having count(distinct course_id) = 1)
+    and not exists (select 1 from files where files.storage_key = f.storage_key);